@@ -0,0 +1,114 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tduyng/codeme/stats"
+)
+
+// Config is codeme's config file. Every field is optional.
+type Config struct {
+	Hooks Hooks `json:"hooks"`
+	// Achievements are added to the built-in ones, or replace those with
+	// the same ID.
+	Achievements []stats.AchievementConfig `json:"achievements"`
+	// Streaks are streaks with their own rules, besides the default one.
+	Streaks []stats.StreakRule `json:"streaks"`
+}
+
+// Hooks maps event names to shell commands, which get the event as JSON on
+// stdin.
+type Hooks struct {
+	Commands map[string][]string `json:"commands"`
+	// Timeout stops a command that runs longer.
+	Timeout Duration `json:"timeout"`
+	// LongSession is how long a session runs before session.long fires.
+	LongSession Duration `json:"long_session"`
+	// AtRiskHour is the hour of the day after which a streak without
+	// activity today is at risk.
+	AtRiskHour int `json:"at_risk_hour"`
+	// StreakMilestones are the streak lengths, in days, that fire
+	// streak.milestone.
+	StreakMilestones []int `json:"streak_milestones"`
+}
+
+// Duration reads durations written as "90s" or "2h" in JSON.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"2h\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func Default() Config {
+	return Config{
+		Hooks: Hooks{
+			Timeout:          Duration{10 * time.Second},
+			LongSession:      Duration{2 * time.Hour},
+			AtRiskHour:       20,
+			StreakMilestones: []int{7, 14, 30, 50, 100, 200, 365},
+		},
+	}
+}
+
+// DefaultPath is $XDG_CONFIG_HOME/codeme/config.json, or
+// ~/.config/codeme/config.json.
+func DefaultPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "codeme", "config.json"), nil
+}
+
+// Load reads the config at path over the defaults. A missing file is not
+// an error.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// LoadDefault loads the config from DefaultPath.
+func LoadDefault() (Config, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return Default(), err
+	}
+	return Load(path)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/stats"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.json"))
+	require.NoError(t, err)
+	require.Equal(t, Default(), cfg)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"hooks": {
+			"commands": {"goal.met": ["notify-send codeme"]},
+			"long_session": "90m"
+		}
+	}`), 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"notify-send codeme"}, cfg.Hooks.Commands["goal.met"])
+	require.Equal(t, 90*time.Minute, cfg.Hooks.LongSession.Duration)
+	require.Equal(t, 10*time.Second, cfg.Hooks.Timeout.Duration, "unset fields keep their defaults")
+	require.Equal(t, 20, cfg.Hooks.AtRiskHour)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"hooks": {"timeout": 10}}`), 0644))
+	_, err = Load(path)
+	require.Error(t, err)
+}
+
+func TestLoad_AchievementsAndStreaks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"achievements": [{
+			"id": "rust",
+			"name": "Rustacean",
+			"icon": "🦀",
+			"rule": "max_week(time(\"rust\"))",
+			"tiers": [{"target": "5h"}, {"target": 36000, "name": "Crab Lord"}]
+		}],
+		"streaks": [{"id": "focus", "min_time": "30m", "rest_days": "weekends"}]
+	}`), 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, []stats.StreakRule{{ID: "focus", MinTime: "30m", RestDays: "weekends"}}, cfg.Streaks)
+	require.Len(t, cfg.Achievements, 1)
+	require.Equal(t, `max_week(time("rust"))`, cfg.Achievements[0].Rule)
+	require.Equal(t, stats.RuleTarget("5h"), cfg.Achievements[0].Tiers[0].Target)
+	require.Equal(t, stats.RuleTarget("36000"), cfg.Achievements[0].Tiers[1].Target)
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	path, err := DefaultPath()
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/xdg/codeme/config.json", path)
+}
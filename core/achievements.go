@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// UnlockedAchievement records when an achievement was earned and what
+// earned it.
+type UnlockedAchievement struct {
+	ID         string
+	UnlockedAt time.Time
+	Context    string
+	// Backfilled unlocks were earned before they could be recorded, so
+	// UnlockedAt is only when they were found.
+	Backfilled bool
+}
+
+// AchievementStorage is implemented by storages that keep unlocked
+// achievements.
+type AchievementStorage interface {
+	GetUnlockedAchievements() ([]UnlockedAchievement, error)
+	UnlockAchievement(UnlockedAchievement) (bool, error)
+}
+
+func (s *SQLiteStorage) GetUnlockedAchievements() ([]UnlockedAchievement, error) {
+	rows, err := s.db.Query("SELECT id, unlocked_at, context, backfilled FROM achievements ORDER BY unlocked_at ASC, id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievements: %w", err)
+	}
+	defer rows.Close()
+
+	var unlocked []UnlockedAchievement
+	for rows.Next() {
+		var u UnlockedAchievement
+		var at int64
+		if err := rows.Scan(&u.ID, &at, &u.Context, &u.Backfilled); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+		u.UnlockedAt = time.Unix(at, 0)
+		unlocked = append(unlocked, u)
+	}
+	return unlocked, rows.Err()
+}
+
+// UnlockAchievement records an unlock. It reports false when the
+// achievement was already unlocked, keeping the first record.
+func (s *SQLiteStorage) UnlockAchievement(u UnlockedAchievement) (bool, error) {
+	res, err := s.db.Exec(
+		"INSERT OR IGNORE INTO achievements (id, unlocked_at, context, backfilled) VALUES (?, ?, ?, ?)",
+		u.ID, u.UnlockedAt.Unix(), u.Context, boolToInt(u.Backfilled),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to save achievement: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
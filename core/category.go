@@ -0,0 +1,48 @@
+package core
+
+import "strings"
+
+const (
+	CategoryCoding    = "coding"
+	CategoryDebugging = "debugging"
+	CategoryTesting   = "testing"
+	CategoryReviewing = "reviewing"
+	CategoryDocs      = "writing-docs"
+	CategoryBuilding  = "building"
+	CategoryMeeting   = "meeting"
+	CategoryRunning   = "running"
+	CategoryTerminal  = "terminal"
+)
+
+var Categories = []string{
+	CategoryCoding,
+	CategoryDebugging,
+	CategoryTesting,
+	CategoryReviewing,
+	CategoryDocs,
+	CategoryBuilding,
+	CategoryMeeting,
+	CategoryRunning,
+	CategoryTerminal,
+}
+
+// CategoryFunc infers a category for a file that was not given one explicitly.
+type CategoryFunc func(filePath, language string) string
+
+// NormalizeCategory lowercases a category and joins words with dashes so that
+// "Writing Docs" and "writing_docs" end up in the same bucket. Custom values
+// are kept as-is otherwise.
+func NormalizeCategory(category string) string {
+	category = strings.ToLower(strings.TrimSpace(category))
+	category = strings.Join(strings.Fields(category), "-")
+	return strings.ReplaceAll(category, "_", "-")
+}
+
+func IsKnownCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -35,26 +35,40 @@ func (d *Detector) DetectProject(path string) string {
 		return cached.(string)
 	}
 
-	var project string
+	project := detectProjectIn(filepath.Dir(path))
 
-	dir := filepath.Dir(path)
+	d.projectCache.Store(path, project)
+	return project
+}
+
+// DetectProjectDir detects the project of a working directory rather than of
+// a file inside it.
+func (d *Detector) DetectProjectDir(dir string) string {
+	key := filepath.Clean(dir) + string(os.PathSeparator)
+	if cached, ok := d.projectCache.Load(key); ok {
+		return cached.(string)
+	}
+
+	project := detectProjectIn(dir)
+
+	d.projectCache.Store(key, project)
+	return project
+}
+
+func detectProjectIn(dir string) string {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
 	cmd.Dir = dir
 
 	if output, err := cmd.Output(); err == nil {
-		project = filepath.Base(strings.TrimSpace(string(output)))
-	} else {
-		abs, _ := filepath.Abs(path)
-		parts := strings.Split(filepath.Dir(abs), string(os.PathSeparator))
-		if len(parts) > 0 {
-			project = parts[len(parts)-1]
-		} else {
-			project = "unknown"
-		}
+		return filepath.Base(strings.TrimSpace(string(output)))
 	}
 
-	d.projectCache.Store(path, project)
-	return project
+	abs, _ := filepath.Abs(dir)
+	parts := strings.Split(abs, string(os.PathSeparator))
+	if len(parts) > 0 {
+		return parts[len(parts)-1]
+	}
+	return "unknown"
 }
 
 func languageFromExtension(ext string) string {
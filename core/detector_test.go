@@ -1,6 +1,8 @@
 package core
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -97,6 +99,16 @@ func TestDetector_DetectProject_Caching(t *testing.T) {
 	require.NotEmpty(t, proj1)
 }
 
+func TestDetector_DetectProjectDir(t *testing.T) {
+	d := NewDetector()
+
+	dir := filepath.Join(t.TempDir(), "myapp")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+
+	require.Equal(t, "myapp", d.DetectProjectDir(dir))
+	require.Equal(t, "myapp", d.DetectProjectDir(dir+string(os.PathSeparator)))
+}
+
 func TestLanguageFromExtension(t *testing.T) {
 	tests := []struct {
 		ext      string
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecordEvent remembers that an event fired, so that it fires only once.
+// It reports false when the event was already recorded.
+func (s *SQLiteStorage) RecordEvent(event, key string, at time.Time) (bool, error) {
+	res, err := s.db.Exec(
+		"INSERT OR IGNORE INTO hook_events (event, key, fired_at) VALUES (?, ?, ?)",
+		event, key, at.Unix(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record event: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// HasEvents reports whether any event was ever recorded.
+func (s *SQLiteStorage) HasEvents() (bool, error) {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM hook_events)").Scan(&exists)
+	return exists, err
+}
+
+// ClaimRun records that the job name runs at, unless it last ran less
+// than interval before. It reports whether the caller should run it; of
+// callers racing for the same run, only one gets true.
+func (s *SQLiteStorage) ClaimRun(name string, at time.Time, interval time.Duration) (bool, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO job_runs (name, ran_at) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET ran_at = excluded.ran_at
+		WHERE ran_at <= ?`,
+		name, at.Unix(), at.Add(-interval).Unix(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim %s: %w", name, err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStorage_ClaimRun(t *testing.T) {
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "codeme.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	claim := func(at time.Time) bool {
+		ok, err := storage.ClaimRun("check", at, time.Minute)
+		require.NoError(t, err)
+		return ok
+	}
+
+	require.True(t, claim(now), "first run")
+	require.False(t, claim(now.Add(30*time.Second)), "too soon")
+	require.True(t, claim(now.Add(time.Minute)))
+	require.False(t, claim(now.Add(90*time.Second)), "counts from the last run")
+
+	other, err := storage.ClaimRun("other", now, time.Minute)
+	require.NoError(t, err)
+	require.True(t, other, "jobs are apart")
+}
@@ -0,0 +1,389 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ActivityFilter restricts stats to a subset of activities. Empty fields
+// match everything.
+type ActivityFilter struct {
+	Machine  string `json:"machine,omitempty"`
+	Project  string `json:"project,omitempty"`
+	Language string `json:"language,omitempty"`
+	Editor   string `json:"editor,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	// File is a glob matched against the full path. Without a leading "/"
+	// it may match from any directory, so "*_test.go" and "cmd/*" work.
+	File string `json:"file,omitempty"`
+}
+
+func (f ActivityFilter) IsEmpty() bool {
+	return f == ActivityFilter{}
+}
+
+// Key identifies the filter in caches.
+func (f ActivityFilter) Key() string {
+	return fmt.Sprintf("machine=%s&project=%s&language=%s&editor=%s&branch=%s&file=%s",
+		f.Machine, f.Project, f.Language, f.Editor, f.Branch, f.File)
+}
+
+func (f ActivityFilter) where() (string, []any) {
+	var clauses []string
+	var args []any
+
+	if f.Machine != "" {
+		clauses = append(clauses, "machine = ?")
+		args = append(args, NormalizeMachine(f.Machine))
+	}
+	if f.Project != "" {
+		clauses = append(clauses, "project = ?")
+		args = append(args, f.Project)
+	}
+	if f.Language != "" {
+		clauses = append(clauses, "language = ? COLLATE NOCASE")
+		args = append(args, f.Language)
+	}
+	if f.Editor != "" {
+		clauses = append(clauses, "editor = ? COLLATE NOCASE")
+		args = append(args, f.Editor)
+	}
+	if f.Branch != "" {
+		clauses = append(clauses, "branch = ?")
+		args = append(args, f.Branch)
+	}
+	if f.File != "" {
+		if strings.HasPrefix(f.File, "/") {
+			clauses = append(clauses, "file GLOB ?")
+			args = append(args, f.File)
+		} else {
+			clauses = append(clauses, "(file GLOB ? OR file GLOB ?)")
+			args = append(args, f.File, "*/"+f.File)
+		}
+	}
+
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(clauses, " AND "), args
+}
+
+// EscapeGlob quotes the glob metacharacters in s, so a File filter built
+// from it matches that literal path.
+func EscapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[':
+			b.WriteByte('[')
+			b.WriteRune(r)
+			b.WriteByte(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// FilterableStorage is implemented by storages that can answer summary
+// queries for a filtered subset of activities.
+type FilterableStorage interface {
+	Storage
+	WithFilter(ActivityFilter) Storage
+}
+
+// WithFilter returns a view of the storage whose reads only see activities
+// matching the filter. The daily summary tables cannot be split by arbitrary
+// dimensions, so the view aggregates the activities table directly using the
+// per-activity duration recorded at save time, split over days as it is
+// when saved.
+func (s *SQLiteStorage) WithFilter(filter ActivityFilter) Storage {
+	if filter.IsEmpty() {
+		return s
+	}
+	return &filteredStorage{SQLiteStorage: s, filter: filter}
+}
+
+type filteredStorage struct {
+	*SQLiteStorage
+	filter ActivityFilter
+}
+
+// activityShare is one day's share of a matching activity, as SaveActivity
+// books it in the summary tables.
+type activityShare struct {
+	dayShare
+	lines, count                                 int
+	language, project, editor, category, machine string
+}
+
+// shares returns the shares of matching activities that fall between the
+// from and to dates, both included. Timed activities that started earlier
+// but ran into the range contribute their time, not their lines.
+func (fs *filteredStorage) shares(from, to time.Time) ([]activityShare, error) {
+	fromDate, toDate := from.Format("2006-01-02"), to.Format("2006-01-02")
+	clause, args := fs.filter.where()
+	rows, err := fs.db.Query(`
+		SELECT timestamp, COALESCE(duration, 0), lines, COALESCE(language, ''), COALESCE(project, ''),
+		       COALESCE(editor, ''), COALESCE(category, ''), COALESCE(machine, ''), COALESCE(command, '')
+		FROM activities
+		WHERE date(timestamp, 'unixepoch', 'localtime') <= ?
+		  AND (date(timestamp, 'unixepoch', 'localtime') >= ?
+		       OR (COALESCE(command, '') != '' AND duration > 0
+		           AND date(timestamp + duration, 'unixepoch', 'localtime') >= ?))`+clause+`
+		ORDER BY timestamp ASC
+	`, append([]any{toDate, fromDate, fromDate}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activities: %w", err)
+	}
+	defer rows.Close()
+
+	var results []activityShare
+	for rows.Next() {
+		var ts int64
+		var duration float64
+		var command string
+		var a activityShare
+		if err := rows.Scan(&ts, &duration, &a.lines, &a.language, &a.project,
+			&a.editor, &a.category, &a.machine, &command); err != nil {
+			return nil, err
+		}
+
+		start := time.Unix(ts, 0).In(time.Local)
+		days := []dayShare{{date: start.Format("2006-01-02"), start: start, seconds: duration}}
+		if command != "" && duration > 0 {
+			days = splitByDay(start, duration)
+		}
+		for i, d := range days {
+			if d.date < fromDate || d.date > toDate {
+				continue
+			}
+			share := a
+			share.dayShare, share.count = d, 1
+			if i > 0 {
+				share.lines, share.count = 0, 0
+			}
+			results = append(results, share)
+		}
+	}
+	return results, rows.Err()
+}
+
+func (fs *filteredStorage) GetActivitiesSince(since time.Time) ([]Activity, error) {
+	clause, args := fs.filter.where()
+	rows, err := fs.db.Query(`
+		SELECT id, timestamp, duration, lines, language, project, editor, file,
+		       branch, category, machine, command, exit_code, is_write
+		FROM activities
+		WHERE timestamp >= ?`+clause+`
+		ORDER BY timestamp ASC
+	`, append([]any{since.Unix()}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activities: %w", err)
+	}
+	defer rows.Close()
+
+	return scanActivities(rows, 1000)
+}
+
+func (fs *filteredStorage) GetActivityCount() (int, error) {
+	clause, args := fs.filter.where()
+	var count int
+	err := fs.db.QueryRow("SELECT COUNT(*) FROM activities WHERE 1 = 1"+clause, args...).Scan(&count)
+	return count, err
+}
+
+func (fs *filteredStorage) GetPeriodSummary(from, to time.Time) (PeriodSummary, error) {
+	shares, err := fs.shares(from, to)
+	if err != nil {
+		return PeriodSummary{}, err
+	}
+	var ps PeriodSummary
+	for _, s := range shares {
+		ps.TotalTime += s.seconds
+		ps.TotalLines += s.lines
+		ps.ActivityCount += s.count
+	}
+	return ps, nil
+}
+
+func (fs *filteredStorage) GetDailySummaries(from, to time.Time) ([]DailySummary, error) {
+	shares, err := fs.shares(from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]*DailySummary)
+	for _, s := range shares {
+		d := byDate[s.date]
+		if d == nil {
+			d = &DailySummary{Date: s.date}
+			byDate[s.date] = d
+		}
+		d.TotalTime += s.seconds
+		d.TotalLines += s.lines
+		d.ActivityCount += s.count
+	}
+
+	days := make([]DailySummary, 0, len(byDate))
+	for _, d := range byDate {
+		days = append(days, *d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days, nil
+}
+
+// sumBy returns total time and lines grouped by key, ordered by time like
+// the summary-table queries.
+func (fs *filteredStorage) sumBy(key func(activityShare) string, from, to time.Time) ([]groupRow, error) {
+	shares, err := fs.shares(from, to)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*groupRow)
+	for _, s := range shares {
+		r := byKey[key(s)]
+		if r == nil {
+			r = &groupRow{key: key(s)}
+			byKey[r.key] = r
+		}
+		r.time += s.seconds
+		r.lines += s.lines
+	}
+
+	results := make([]groupRow, 0, len(byKey))
+	for _, r := range byKey {
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].time != results[j].time {
+			return results[i].time > results[j].time
+		}
+		return results[i].key < results[j].key
+	})
+	return results, nil
+}
+
+type groupRow struct {
+	key   string
+	time  float64
+	lines int
+}
+
+func (fs *filteredStorage) GetLanguageSummary(from, to time.Time) ([]LanguageRow, error) {
+	groups, err := fs.sumBy(func(s activityShare) string { return s.language }, from, to)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]LanguageRow, 0, len(groups))
+	for _, g := range groups {
+		results = append(results, LanguageRow{Language: g.key, TotalTime: g.time, TotalLines: g.lines})
+	}
+	return results, nil
+}
+
+func (fs *filteredStorage) GetProjectSummary(from, to time.Time) ([]ProjectRow, error) {
+	languages, err := fs.GetProjectLanguageSummary(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Languages come sorted by time within each project, so the first one
+	// seen is the main language.
+	byProject := make(map[string]*ProjectRow)
+	for _, l := range languages {
+		pr := byProject[l.Project]
+		if pr == nil {
+			pr = &ProjectRow{Project: l.Project, MainLanguage: l.Language}
+			byProject[l.Project] = pr
+		}
+		pr.TotalTime += l.TotalTime
+		pr.TotalLines += l.TotalLines
+	}
+
+	results := make([]ProjectRow, 0, len(byProject))
+	for _, pr := range byProject {
+		results = append(results, *pr)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].TotalTime > results[j].TotalTime
+	})
+	return results, nil
+}
+
+func (fs *filteredStorage) GetProjectLanguageSummary(from, to time.Time) ([]ProjectLanguageRow, error) {
+	shares, err := fs.shares(from, to)
+	if err != nil {
+		return nil, err
+	}
+	type key struct{ project, language string }
+	byKey := make(map[key]*ProjectLanguageRow)
+	for _, s := range shares {
+		k := key{s.project, s.language}
+		r := byKey[k]
+		if r == nil {
+			r = &ProjectLanguageRow{Project: s.project, Language: s.language}
+			byKey[k] = r
+		}
+		r.TotalTime += s.seconds
+		r.TotalLines += s.lines
+	}
+
+	results := make([]ProjectLanguageRow, 0, len(byKey))
+	for _, r := range byKey {
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		if a.TotalTime != b.TotalTime {
+			return a.TotalTime > b.TotalTime
+		}
+		return a.Language < b.Language
+	})
+	return results, nil
+}
+
+func (fs *filteredStorage) GetEditorSummary(from, to time.Time) ([]EditorRow, error) {
+	groups, err := fs.sumBy(func(s activityShare) string { return s.editor }, from, to)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]EditorRow, 0, len(groups))
+	for _, g := range groups {
+		results = append(results, EditorRow{Editor: g.key, TotalTime: g.time, TotalLines: g.lines})
+	}
+	return results, nil
+}
+
+func (fs *filteredStorage) GetCategorySummary(from, to time.Time) ([]CategoryRow, error) {
+	groups, err := fs.sumBy(func(s activityShare) string { return s.category }, from, to)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]CategoryRow, 0, len(groups))
+	for _, g := range groups {
+		results = append(results, CategoryRow{Category: g.key, TotalTime: g.time, TotalLines: g.lines})
+	}
+	return results, nil
+}
+
+func (fs *filteredStorage) GetMachineSummary(from, to time.Time) ([]MachineRow, error) {
+	groups, err := fs.sumBy(func(s activityShare) string { return s.machine }, from, to)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]MachineRow, 0, len(groups))
+	for _, g := range groups {
+		results = append(results, MachineRow{Machine: g.key, TotalTime: g.time, TotalLines: g.lines})
+	}
+	return results, nil
+}
+
+func (fs *filteredStorage) GetCommandSummary(from, to time.Time) ([]CommandRow, error) {
+	clause, args := fs.filter.where()
+	return queryCommandSummary(fs.db, clause, args, from, to)
+}
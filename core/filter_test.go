@@ -0,0 +1,57 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilteredStorage_TimedActivityAcrossMidnight(t *testing.T) {
+	time.Local = time.UTC
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "codeme.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	for _, a := range []Activity{
+		{Timestamp: day.Add(22 * time.Hour), File: "/a.go", Lines: 5},
+		{Timestamp: day.Add(22*time.Hour + time.Minute), File: "/a.go", Lines: 5},
+		{Timestamp: day.Add(23*time.Hour + 30*time.Minute), Duration: 3600, Command: "cargo"},
+		{Timestamp: day.Add(25 * time.Hour), File: "/a.go", Lines: 3},
+	} {
+		a.ID = GenerateID()
+		a.Project, a.Language, a.Editor, a.Machine = "codeme", "go", "vim", "work"
+		require.NoError(t, storage.SaveActivity(a))
+	}
+
+	filtered := storage.WithFilter(ActivityFilter{Machine: "work"})
+	for _, r := range [][2]time.Time{
+		{day, day.AddDate(0, 0, 1)},
+		{day.AddDate(0, 0, 1), day.AddDate(0, 0, 1)},
+	} {
+		want, err := storage.GetDailySummaries(r[0], r[1])
+		require.NoError(t, err)
+		got, err := filtered.GetDailySummaries(r[0], r[1])
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		wantPeriod, err := storage.GetPeriodSummary(r[0], r[1])
+		require.NoError(t, err)
+		gotPeriod, err := filtered.GetPeriodSummary(r[0], r[1])
+		require.NoError(t, err)
+		require.Equal(t, wantPeriod, gotPeriod)
+
+		wantMachines, err := storage.GetMachineSummary(r[0], r[1])
+		require.NoError(t, err)
+		gotMachines, err := filtered.GetMachineSummary(r[0], r[1])
+		require.NoError(t, err)
+		require.Equal(t, wantMachines, gotMachines)
+	}
+
+	next, err := filtered.GetDailySummaries(day.AddDate(0, 0, 1), day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, 1800.0+120, next[0].TotalTime, "the half hour after midnight and a heartbeat")
+	require.Equal(t, 1, next[0].ActivityCount)
+}
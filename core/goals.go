@@ -0,0 +1,155 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	GoalDaily  = "daily"
+	GoalWeekly = "weekly"
+
+	GoalTime  = "time"
+	GoalLines = "lines"
+)
+
+// Goal is a target for time (in seconds) or lines per day or per week.
+// Project, Language and Weekdays narrow down what counts towards it; a
+// daily goal only applies on its Weekdays.
+type Goal struct {
+	ID        int64          `json:"id"`
+	Period    string         `json:"period"`
+	Metric    string         `json:"metric"`
+	Target    float64        `json:"target"`
+	Project   string         `json:"project,omitempty"`
+	Language  string         `json:"language,omitempty"`
+	Weekdays  []time.Weekday `json:"weekdays,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func (g Goal) Validate() error {
+	if g.Period != GoalDaily && g.Period != GoalWeekly {
+		return fmt.Errorf("invalid goal period %q (want %s or %s)", g.Period, GoalDaily, GoalWeekly)
+	}
+	if g.Metric != GoalTime && g.Metric != GoalLines {
+		return fmt.Errorf("invalid goal metric %q (want %s or %s)", g.Metric, GoalTime, GoalLines)
+	}
+	if g.Target <= 0 {
+		return fmt.Errorf("goal target must be positive")
+	}
+	return nil
+}
+
+// AppliesOn reports whether activity on a day of this weekday counts.
+func (g Goal) AppliesOn(day time.Weekday) bool {
+	return len(g.Weekdays) == 0 || slices.Contains(g.Weekdays, day)
+}
+
+// ParseWeekdays reads a comma-separated list of weekday names ("mon",
+// "Tuesday") or the shorthands "weekdays" and "weekends".
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "weekdays":
+			days = append(days, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+			continue
+		case "weekends":
+			days = append(days, time.Saturday, time.Sunday)
+			continue
+		}
+
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			full := strings.ToLower(d.String())
+			if name == full || name == full[:3] {
+				days = append(days, d)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown weekday: %s", name)
+		}
+	}
+	slices.Sort(days)
+	return slices.Compact(days), nil
+}
+
+// GoalStorage is implemented by storages that keep goals.
+type GoalStorage interface {
+	GetGoals() ([]Goal, error)
+	AddGoal(Goal) (Goal, error)
+	DeleteGoal(id int64) error
+}
+
+func (s *SQLiteStorage) GetGoals() ([]Goal, error) {
+	rows, err := s.db.Query(`
+		SELECT id, period, metric, target, project, language, weekdays, created_at
+		FROM goals
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query goals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []Goal
+	for rows.Next() {
+		var g Goal
+		var weekdays string
+		var createdAt int64
+		if err := rows.Scan(&g.ID, &g.Period, &g.Metric, &g.Target, &g.Project, &g.Language, &weekdays, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		for _, d := range strings.Split(weekdays, ",") {
+			if n, err := strconv.Atoi(d); err == nil {
+				g.Weekdays = append(g.Weekdays, time.Weekday(n))
+			}
+		}
+		g.CreatedAt = time.Unix(createdAt, 0)
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+func (s *SQLiteStorage) AddGoal(g Goal) (Goal, error) {
+	if err := g.Validate(); err != nil {
+		return g, err
+	}
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = time.Now()
+	}
+
+	days := make([]string, len(g.Weekdays))
+	for i, d := range g.Weekdays {
+		days[i] = strconv.Itoa(int(d))
+	}
+
+	res, err := s.db.Exec(`
+		INSERT INTO goals (period, metric, target, project, language, weekdays, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, g.Period, g.Metric, g.Target, g.Project, g.Language, strings.Join(days, ","), g.CreatedAt.Unix())
+	if err != nil {
+		return g, fmt.Errorf("failed to save goal: %w", err)
+	}
+	g.ID, err = res.LastInsertId()
+	return g, err
+}
+
+func (s *SQLiteStorage) DeleteGoal(id int64) error {
+	res, err := s.db.Exec("DELETE FROM goals WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("goal not found: %d", id)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWeekdays(t *testing.T) {
+	days, err := ParseWeekdays("fri, Monday,mon")
+	require.NoError(t, err)
+	require.Equal(t, []time.Weekday{time.Monday, time.Friday}, days)
+
+	days, err = ParseWeekdays("weekends")
+	require.NoError(t, err)
+	require.Equal(t, []time.Weekday{time.Sunday, time.Saturday}, days)
+
+	days, err = ParseWeekdays("")
+	require.NoError(t, err)
+	require.Empty(t, days)
+
+	_, err = ParseWeekdays("mon,someday")
+	require.Error(t, err)
+}
+
+func TestSQLiteStorage_Goals(t *testing.T) {
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "codeme.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	_, err = storage.AddGoal(Goal{Period: "monthly", Metric: GoalTime, Target: 60})
+	require.Error(t, err)
+
+	daily, err := storage.AddGoal(Goal{Period: GoalDaily, Metric: GoalTime, Target: 7200, Weekdays: []time.Weekday{time.Monday, time.Friday}})
+	require.NoError(t, err)
+	weekly, err := storage.AddGoal(Goal{Period: GoalWeekly, Metric: GoalLines, Target: 500, Project: "codeme"})
+	require.NoError(t, err)
+
+	goals, err := storage.GetGoals()
+	require.NoError(t, err)
+	require.Len(t, goals, 2)
+	require.Equal(t, daily.ID, goals[0].ID)
+	require.Equal(t, []time.Weekday{time.Monday, time.Friday}, goals[0].Weekdays)
+	require.True(t, goals[0].AppliesOn(time.Friday))
+	require.False(t, goals[0].AppliesOn(time.Sunday))
+	require.Equal(t, "codeme", goals[1].Project)
+	require.True(t, goals[1].AppliesOn(time.Sunday))
+
+	require.NoError(t, storage.DeleteGoal(weekly.ID))
+	require.Error(t, storage.DeleteGoal(weekly.ID))
+	goals, err = storage.GetGoals()
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+}
@@ -0,0 +1,37 @@
+package core
+
+import "time"
+
+// Heartbeat is the JSON shape integrations (rpc, HTTP) use to report an
+// activity. Empty fields are filled in by Tracker.TrackActivity.
+type Heartbeat struct {
+	File      string    `json:"file"`
+	Language  string    `json:"language,omitempty"`
+	Editor    string    `json:"editor,omitempty"`
+	Lines     int       `json:"lines,omitempty"`
+	Category  string    `json:"category,omitempty"`
+	Machine   string    `json:"machine,omitempty"`
+	Branch    string    `json:"branch,omitempty"`
+	IsWrite   *bool     `json:"is_write,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitzero"`
+}
+
+func (h Heartbeat) Activity() Activity {
+	isWrite := true
+	if h.IsWrite != nil {
+		isWrite = *h.IsWrite
+	}
+
+	return Activity{
+		ID:        GenerateID(),
+		Timestamp: h.Timestamp,
+		File:      h.File,
+		Language:  h.Language,
+		Editor:    h.Editor,
+		Lines:     h.Lines,
+		Category:  h.Category,
+		Machine:   h.Machine,
+		Branch:    h.Branch,
+		IsWrite:   isWrite,
+	}
+}
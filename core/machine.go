@@ -0,0 +1,42 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	machineOnce sync.Once
+	machineName string
+)
+
+// DetectMachine returns the name stored with every activity tracked on this
+// computer. CODEME_MACHINE overrides the hostname, which is useful when the
+// hostname changes between networks or is shared by several machines.
+func DetectMachine() string {
+	machineOnce.Do(func() {
+		machineName = NormalizeMachine(os.Getenv("CODEME_MACHINE"))
+		if machineName != "" {
+			return
+		}
+
+		host, err := os.Hostname()
+		if err != nil {
+			machineName = "unknown"
+			return
+		}
+		machineName = NormalizeMachine(host)
+		if machineName == "" {
+			machineName = "unknown"
+		}
+	})
+	return machineName
+}
+
+// NormalizeMachine lowercases a hostname and drops the mDNS ".local" suffix
+// macOS adds on some networks, so the same laptop keeps a single name.
+func NormalizeMachine(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.TrimSuffix(name, ".local")
+}
@@ -23,7 +23,11 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	// Shell hooks save from several processes at once. The busy timeout has
+	// to apply from the very first statement, and transactions take the write
+	// lock up front: SaveActivity reads before it writes, and a deferred
+	// transaction would fail to upgrade instead of waiting.
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)&_txlock=immediate")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -55,6 +59,11 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 		return nil, err
 	}
 
+	if err := migrateSchema(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	storage := &SQLiteStorage{db: db}
 	if err := storage.prepareStatements(); err != nil {
 		db.Close()
@@ -83,16 +92,16 @@ func (s *SQLiteStorage) prepareStatements() error {
 
 	s.saveStmt, err = s.db.Prepare(`
 		INSERT INTO activities 
-		(id, timestamp, lines, language, project, editor, file, branch, is_write)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		(id, timestamp, duration, lines, language, project, editor, file, branch, category, machine, command, exit_code, is_write)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare save statement: %w", err)
 	}
 
 	s.getRecentStmt, err = s.db.Prepare(`
-		SELECT id, timestamp, lines, language, project, editor, file, 
-		       branch, is_write
+		SELECT id, timestamp, duration, lines, language, project, editor, file, 
+		       branch, category, machine, command, exit_code, is_write
 		FROM activities
 		WHERE timestamp >= ?
 		ORDER BY timestamp ASC
@@ -117,6 +126,7 @@ func (s *SQLiteStorage) estimateDuration(tx *sql.Tx, date string, now time.Time)
 		SELECT COALESCE(MAX(timestamp), 0) FROM activities
 		WHERE date(timestamp, 'unixepoch', 'localtime') = ?
 		  AND timestamp < ?
+		  AND NOT (COALESCE(command, '') != '' AND duration > 0)
 	`, date, now.Unix()).Scan(&lastTS)
 	if err != nil {
 		return maxGap, err
@@ -140,72 +150,125 @@ func (s *SQLiteStorage) SaveActivity(activity Activity) error {
 	}
 	defer tx.Rollback()
 
+	if activity.Category == "" {
+		activity.Category = CategoryCoding
+	}
+
+	date := activity.Timestamp.Format("2006-01-02")
+	duration := activity.Duration
+	shares := []dayShare{{date: date, start: activity.Timestamp}}
+	if activity.IsTimed() {
+		overlap, err := timedOverlap(tx, activity)
+		if err != nil {
+			return fmt.Errorf("failed to clip duration: %w", err)
+		}
+		duration = max(0, duration-overlap)
+		shares = splitByDay(activity.Timestamp, duration)
+	} else {
+		duration, err = s.estimateDuration(tx, date, activity.Timestamp)
+		if err != nil {
+			return fmt.Errorf("failed to estimate duration: %w", err)
+		}
+		shares[0].seconds = duration
+	}
+
 	_, err = tx.Exec(`
 		INSERT INTO activities 
-		(id, timestamp, lines, language, project, editor, file, branch, is_write)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		(id, timestamp, duration, lines, language, project, editor, file, branch, category, machine, command, exit_code, is_write)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		activity.ID,
 		activity.Timestamp.Unix(),
+		duration,
 		activity.Lines,
 		activity.Language,
 		activity.Project,
 		activity.Editor,
 		activity.File,
 		activity.Branch,
+		activity.Category,
+		activity.Machine,
+		activity.Command,
+		activity.ExitCode,
 		boolToInt(activity.IsWrite),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert activity: %w", err)
 	}
 
-	date := activity.Timestamp.Format("2006-01-02")
-	duration, err := s.estimateDuration(tx, date, activity.Timestamp)
-	if err != nil {
-		return fmt.Errorf("failed to estimate duration: %w", err)
+	for i, share := range shares {
+		// Only the day the activity started counts its lines and files.
+		count, lines := 1, activity.Lines
+		if i > 0 {
+			count, lines = 0, 0
+		}
+		if err := addToSummaries(tx, activity, share, count, lines); err != nil {
+			return err
+		}
 	}
 
-	_, err = tx.Exec(`
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return nil
+}
+
+// addToSummaries books one day's share of an activity in the daily summary
+// tables.
+func addToSummaries(tx *sql.Tx, activity Activity, share dayShare, count, lines int) error {
+	_, err := tx.Exec(`
 		INSERT INTO daily_summary (date, total_time, total_lines, activity_count, first_activity, last_activity)
-		VALUES (?, ?, ?, 1, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?)
 		ON CONFLICT(date) DO UPDATE SET
 			total_time = total_time + excluded.total_time,
 			total_lines = total_lines + excluded.total_lines,
-			activity_count = activity_count + 1,
+			activity_count = activity_count + excluded.activity_count,
 			first_activity = CASE WHEN excluded.first_activity < daily_summary.first_activity THEN excluded.first_activity ELSE daily_summary.first_activity END,
 			last_activity = CASE WHEN excluded.last_activity > daily_summary.last_activity THEN excluded.last_activity ELSE daily_summary.last_activity END,
 			updated_at = strftime('%s', 'now')
-	`, date, duration, activity.Lines, activity.Timestamp.Unix(), activity.Timestamp.Unix())
+	`, share.date, share.seconds, lines, count, share.start.Unix(), share.start.Unix())
 	if err != nil {
 		return fmt.Errorf("failed to update daily summary: %w", err)
 	}
 
 	_, err = tx.Exec(`
 		INSERT INTO daily_language_summary (date, language, total_time, total_lines, file_count)
-		VALUES (?, ?, ?, ?, 1)
+		VALUES (?, ?, ?, ?, ?)
 		ON CONFLICT(date, language) DO UPDATE SET
 			total_time = total_time + excluded.total_time,
 			total_lines = total_lines + excluded.total_lines,
-			file_count = file_count + 1
-	`, date, activity.Language, duration, activity.Lines)
+			file_count = file_count + excluded.file_count
+	`, share.date, activity.Language, share.seconds, lines, count)
 	if err != nil {
 		return fmt.Errorf("failed to update language summary: %w", err)
 	}
 
+	_, err = tx.Exec(`
+		INSERT INTO daily_project_language_summary (date, project, language, total_time, total_lines, file_count)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(date, project, language) DO UPDATE SET
+			total_time = total_time + excluded.total_time,
+			total_lines = total_lines + excluded.total_lines,
+			file_count = file_count + excluded.file_count
+	`, share.date, activity.Project, activity.Language, share.seconds, lines, count)
+	if err != nil {
+		return fmt.Errorf("failed to update project language summary: %w", err)
+	}
+
 	_, err = tx.Exec(`
 		INSERT INTO daily_project_summary (date, project, total_time, total_lines, main_language, file_count)
-		VALUES (?, ?, ?, ?, ?, 1)
+		VALUES (?, ?, ?, ?, ?, ?)
 		ON CONFLICT(date, project) DO UPDATE SET
 			total_time = total_time + excluded.total_time,
 			total_lines = total_lines + excluded.total_lines,
-			main_language = CASE 
-				WHEN (SELECT total_time FROM daily_language_summary WHERE date = excluded.date AND language = excluded.main_language) > 
-				      (SELECT COALESCE(MAX(total_time), 0) FROM daily_language_summary WHERE date = excluded.date AND language = excluded.main_language)
-				THEN excluded.main_language
-				ELSE daily_project_summary.main_language
-			END,
-			file_count = file_count + 1
-	`, date, activity.Project, duration, activity.Lines, activity.Language)
+			main_language = (
+				SELECT language FROM daily_project_language_summary
+				WHERE date = excluded.date AND project = excluded.project
+				ORDER BY total_time DESC, language LIMIT 1
+			),
+			file_count = file_count + excluded.file_count
+	`, share.date, activity.Project, share.seconds, lines, activity.Language, count)
 	if err != nil {
 		return fmt.Errorf("failed to update project summary: %w", err)
 	}
@@ -216,18 +279,74 @@ func (s *SQLiteStorage) SaveActivity(activity Activity) error {
 		ON CONFLICT(date, editor) DO UPDATE SET
 			total_time = total_time + excluded.total_time,
 			total_lines = total_lines + excluded.total_lines
-	`, date, activity.Editor, duration, activity.Lines)
+	`, share.date, activity.Editor, share.seconds, lines)
 	if err != nil {
 		return fmt.Errorf("failed to update editor summary: %w", err)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+	_, err = tx.Exec(`
+		INSERT INTO daily_category_summary (date, category, total_time, total_lines)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(date, category) DO UPDATE SET
+			total_time = total_time + excluded.total_time,
+			total_lines = total_lines + excluded.total_lines
+	`, share.date, activity.Category, share.seconds, lines)
+	if err != nil {
+		return fmt.Errorf("failed to update category summary: %w", err)
 	}
 
+	_, err = tx.Exec(`
+		INSERT INTO daily_machine_summary (date, machine, total_time, total_lines)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(date, machine) DO UPDATE SET
+			total_time = total_time + excluded.total_time,
+			total_lines = total_lines + excluded.total_lines
+	`, share.date, activity.Machine, share.seconds, lines)
+	if err != nil {
+		return fmt.Errorf("failed to update machine summary: %w", err)
+	}
 	return nil
 }
 
+// dayShare is the part of an activity's time that falls on one local day.
+type dayShare struct {
+	date    string
+	start   time.Time
+	seconds float64
+}
+
+// splitByDay spreads the run time of a timed activity over the days it ran
+// on, so a command going past midnight is credited to both days.
+func splitByDay(start time.Time, seconds float64) []dayShare {
+	var shares []dayShare
+	for {
+		y, m, d := start.Date()
+		midnight := time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+		left := midnight.Sub(start).Seconds()
+		if seconds <= left {
+			return append(shares, dayShare{date: start.Format("2006-01-02"), start: start, seconds: seconds})
+		}
+		shares = append(shares, dayShare{date: start.Format("2006-01-02"), start: start, seconds: left})
+		start, seconds = midnight, seconds-left
+	}
+}
+
+// timedOverlap is how much of a timed activity's run is already covered by
+// the durations of heartbeats saved while it ran.
+func timedOverlap(tx *sql.Tx, activity Activity) (float64, error) {
+	start := float64(activity.Timestamp.Unix())
+	end := start + activity.Duration
+
+	var overlap float64
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(MAX(0, MIN(timestamp, ?) - MAX(timestamp - COALESCE(duration, 0), ?))), 0)
+		FROM activities
+		WHERE timestamp > ? AND timestamp - COALESCE(duration, 0) < ?
+		  AND NOT (COALESCE(command, '') != '' AND duration > 0)
+	`, end, start, start, end).Scan(&overlap)
+	return overlap, err
+}
+
 func (s *SQLiteStorage) GetActivitiesSince(since time.Time) ([]Activity, error) {
 	rows, err := s.getRecentStmt.Query(since.Unix())
 	if err != nil {
@@ -244,6 +363,14 @@ func (s *SQLiteStorage) GetActivityCount() (int, error) {
 	return count, err
 }
 
+// DataVersion changes whenever another connection commits to the database.
+// Writes made through this storage do not change it.
+func (s *SQLiteStorage) DataVersion() (int64, error) {
+	var version int64
+	err := s.db.QueryRow("PRAGMA data_version").Scan(&version)
+	return version, err
+}
+
 func (s *SQLiteStorage) GetPeriodSummary(from, to time.Time) (PeriodSummary, error) {
 	var ps PeriodSummary
 	err := s.db.QueryRow(`
@@ -255,6 +382,34 @@ func (s *SQLiteStorage) GetPeriodSummary(from, to time.Time) (PeriodSummary, err
 	return ps, err
 }
 
+// GetDailySummaries returns one row per day with activity, oldest first.
+func (s *SQLiteStorage) GetDailySummaries(from, to time.Time) ([]DailySummary, error) {
+	rows, err := s.db.Query(`
+		SELECT date, total_time, total_lines, activity_count
+		FROM daily_summary
+		WHERE date >= ? AND date <= ?
+		ORDER BY date ASC
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDailySummaries(rows)
+}
+
+func scanDailySummaries(rows *sql.Rows) ([]DailySummary, error) {
+	var results []DailySummary
+	for rows.Next() {
+		var d DailySummary
+		if err := rows.Scan(&d.Date, &d.TotalTime, &d.TotalLines, &d.ActivityCount); err != nil {
+			return nil, err
+		}
+		results = append(results, d)
+	}
+	return results, rows.Err()
+}
+
 func (s *SQLiteStorage) GetLanguageSummary(from, to time.Time) ([]LanguageRow, error) {
 	rows, err := s.db.Query(`
 		SELECT language, SUM(total_time), SUM(total_lines)
@@ -278,13 +433,21 @@ func (s *SQLiteStorage) GetLanguageSummary(from, to time.Time) ([]LanguageRow, e
 	return results, nil
 }
 
+// GetProjectSummary returns each project's totals. MainLanguage is the
+// language with the most time in the project over the whole range.
 func (s *SQLiteStorage) GetProjectSummary(from, to time.Time) ([]ProjectRow, error) {
+	fromDate, toDate := from.Format("2006-01-02"), to.Format("2006-01-02")
 	rows, err := s.db.Query(`
-		SELECT project, SUM(total_time), SUM(total_lines), main_language
-		FROM daily_project_summary 
-		WHERE date >= ? AND date <= ?
-		GROUP BY project ORDER BY SUM(total_time) DESC
-	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+		SELECT p.project, SUM(p.total_time), SUM(p.total_lines),
+		       COALESCE((
+		           SELECT l.language FROM daily_project_language_summary l
+		           WHERE l.project = p.project AND l.date >= ? AND l.date <= ?
+		           GROUP BY l.language ORDER BY SUM(l.total_time) DESC, l.language LIMIT 1
+		       ), '')
+		FROM daily_project_summary p
+		WHERE p.date >= ? AND p.date <= ?
+		GROUP BY p.project ORDER BY SUM(p.total_time) DESC
+	`, fromDate, toDate, fromDate, toDate)
 	if err != nil {
 		return nil, err
 	}
@@ -301,6 +464,29 @@ func (s *SQLiteStorage) GetProjectSummary(from, to time.Time) ([]ProjectRow, err
 	return results, nil
 }
 
+func (s *SQLiteStorage) GetProjectLanguageSummary(from, to time.Time) ([]ProjectLanguageRow, error) {
+	rows, err := s.db.Query(`
+		SELECT project, language, SUM(total_time), SUM(total_lines)
+		FROM daily_project_language_summary
+		WHERE date >= ? AND date <= ?
+		GROUP BY project, language ORDER BY project, SUM(total_time) DESC, language
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ProjectLanguageRow
+	for rows.Next() {
+		var r ProjectLanguageRow
+		if err := rows.Scan(&r.Project, &r.Language, &r.TotalTime, &r.TotalLines); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
 func (s *SQLiteStorage) GetEditorSummary(from, to time.Time) ([]EditorRow, error) {
 	rows, err := s.db.Query(`
 		SELECT editor, SUM(total_time), SUM(total_lines)
@@ -324,6 +510,87 @@ func (s *SQLiteStorage) GetEditorSummary(from, to time.Time) ([]EditorRow, error
 	return results, nil
 }
 
+func (s *SQLiteStorage) GetCategorySummary(from, to time.Time) ([]CategoryRow, error) {
+	rows, err := s.db.Query(`
+		SELECT category, SUM(total_time), SUM(total_lines)
+		FROM daily_category_summary 
+		WHERE date >= ? AND date <= ?
+		GROUP BY category ORDER BY SUM(total_time) DESC
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []CategoryRow
+	for rows.Next() {
+		var cr CategoryRow
+		if err := rows.Scan(&cr.Category, &cr.TotalTime, &cr.TotalLines); err != nil {
+			return nil, err
+		}
+		results = append(results, cr)
+	}
+	return results, nil
+}
+
+func (s *SQLiteStorage) GetMachineSummary(from, to time.Time) ([]MachineRow, error) {
+	rows, err := s.db.Query(`
+		SELECT machine, SUM(total_time), SUM(total_lines)
+		FROM daily_machine_summary 
+		WHERE date >= ? AND date <= ?
+		GROUP BY machine ORDER BY SUM(total_time) DESC
+	`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []MachineRow
+	for rows.Next() {
+		var mr MachineRow
+		if err := rows.Scan(&mr.Machine, &mr.TotalTime, &mr.TotalLines); err != nil {
+			return nil, err
+		}
+		results = append(results, mr)
+	}
+	return results, nil
+}
+
+// GetCommandSummary reports wrapped command runs per project, category and
+// program. Commands are rare compared to file edits, so this reads the
+// activities table instead of keeping another summary table.
+func (s *SQLiteStorage) GetCommandSummary(from, to time.Time) ([]CommandRow, error) {
+	return queryCommandSummary(s.db, "", nil, from, to)
+}
+
+func queryCommandSummary(db *sql.DB, clause string, args []any, from, to time.Time) ([]CommandRow, error) {
+	rows, err := db.Query(`
+		SELECT project, category, command, COUNT(*),
+		       SUM(CASE WHEN exit_code != 0 THEN 1 ELSE 0 END),
+		       COALESCE(SUM(duration), 0)
+		FROM activities
+		WHERE command != ''
+		  AND date(timestamp, 'unixepoch', 'localtime') >= ?
+		  AND date(timestamp, 'unixepoch', 'localtime') <= ?`+clause+`
+		GROUP BY project, category, command
+		ORDER BY SUM(duration) DESC
+	`, append([]any{from.Format("2006-01-02"), to.Format("2006-01-02")}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []CommandRow
+	for rows.Next() {
+		var cr CommandRow
+		if err := rows.Scan(&cr.Project, &cr.Category, &cr.Command, &cr.Runs, &cr.Failures, &cr.TotalTime); err != nil {
+			return nil, err
+		}
+		results = append(results, cr)
+	}
+	return results, rows.Err()
+}
+
 func (s *SQLiteStorage) Optimize() error {
 	if _, err := s.db.Exec("VACUUM"); err != nil {
 		return fmt.Errorf("failed to vacuum: %w", err)
@@ -344,7 +611,7 @@ func (s *SQLiteStorage) RebuildSummaries() error {
 	defer tx.Rollback()
 
 	rows, err := tx.Query(`
-		SELECT id, timestamp, lines, language, project, editor, file
+		SELECT id, timestamp, duration, lines, language, project, editor, file, category, machine, command
 		FROM activities
 		ORDER BY timestamp ASC
 	`)
@@ -360,15 +627,28 @@ func (s *SQLiteStorage) RebuildSummaries() error {
 		project   string
 		editor    string
 		file      string
+		category  string
+		machine   string
+		command   string
+		duration  float64
 	}
 
 	var activitiesList []rawActivity
 	for rows.Next() {
 		var a rawActivity
-		if err := rows.Scan(&a.id, &a.timestamp, &a.lines, &a.language, &a.project, &a.editor, &a.file); err != nil {
+		var category, machine, command sql.NullString
+		var duration sql.NullFloat64
+		if err := rows.Scan(&a.id, &a.timestamp, &duration, &a.lines, &a.language, &a.project, &a.editor, &a.file, &category, &machine, &command); err != nil {
 			rows.Close()
 			return err
 		}
+		a.category = category.String
+		if a.category == "" {
+			a.category = CategoryCoding
+		}
+		a.machine = machine.String
+		a.command = command.String
+		a.duration = duration.Float64
 		activitiesList = append(activitiesList, a)
 	}
 	rows.Close()
@@ -397,15 +677,30 @@ func (s *SQLiteStorage) RebuildSummaries() error {
 		mainLanguage string
 		fileCount    int
 	}
+	type projLangKey struct {
+		date, project, language string
+	}
 	type editorAgg struct {
 		totalTime  float64
 		totalLines int
 	}
+	type categoryAgg struct {
+		totalTime  float64
+		totalLines int
+	}
+	type machineAgg struct {
+		totalTime  float64
+		totalLines int
+	}
 
 	dailySummary := make(map[string]dailyAgg)
 	langSummary := make(map[string]langAgg)
 	projSummary := make(map[string]projAgg)
+	projLangSummary := make(map[projLangKey]langAgg)
 	editorSummary := make(map[string]editorAgg)
+	categorySummary := make(map[string]categoryAgg)
+	machineSummary := make(map[string]machineAgg)
+	durations := make(map[string]float64, len(activitiesList))
 
 	var prevTS int64
 	for i, a := range activitiesList {
@@ -419,43 +714,78 @@ func (s *SQLiteStorage) RebuildSummaries() error {
 				gap = maxGap
 			}
 		}
-
-		ds := dailySummary[date]
-		ds.totalTime += gap
-		ds.totalLines += a.lines
-		ds.activityCount++
-		if ds.firstActivity == 0 || ts < ds.firstActivity {
-			ds.firstActivity = ts
-		}
-		if ts > ds.lastActivity {
-			ds.lastActivity = ts
+		shares := []dayShare{{date: date, start: time.Unix(ts, 0), seconds: gap}}
+		if a.command != "" && a.duration > 0 {
+			gap = a.duration
+			shares = splitByDay(time.Unix(ts, 0).In(time.Local), gap)
 		}
-		dailySummary[date] = ds
 
-		langKey := date + "|" + a.language
-		ls := langSummary[langKey]
-		ls.totalTime += gap
-		ls.totalLines += a.lines
-		ls.fileCount++
-		langSummary[langKey] = ls
-
-		projKey := date + "|" + a.project
-		ps := projSummary[projKey]
-		ps.totalTime += gap
-		ps.totalLines += a.lines
-		if a.language != "" {
-			ps.mainLanguage = a.language
+		for j, share := range shares {
+			count, lines := 1, a.lines
+			if j > 0 {
+				count, lines = 0, 0
+			}
+			start := share.start.Unix()
+
+			ds := dailySummary[share.date]
+			ds.totalTime += share.seconds
+			ds.totalLines += lines
+			ds.activityCount += count
+			if ds.firstActivity == 0 || start < ds.firstActivity {
+				ds.firstActivity = start
+			}
+			if start > ds.lastActivity {
+				ds.lastActivity = start
+			}
+			dailySummary[share.date] = ds
+
+			langKey := share.date + "|" + a.language
+			ls := langSummary[langKey]
+			ls.totalTime += share.seconds
+			ls.totalLines += lines
+			ls.fileCount += count
+			langSummary[langKey] = ls
+
+			projKey := share.date + "|" + a.project
+			ps := projSummary[projKey]
+			ps.totalTime += share.seconds
+			ps.totalLines += lines
+			ps.fileCount += count
+			projSummary[projKey] = ps
+
+			plKey := projLangKey{share.date, a.project, a.language}
+			pls := projLangSummary[plKey]
+			pls.totalTime += share.seconds
+			pls.totalLines += lines
+			pls.fileCount += count
+			projLangSummary[plKey] = pls
+
+			editorKey := share.date + "|" + a.editor
+			es := editorSummary[editorKey]
+			es.totalTime += share.seconds
+			es.totalLines += lines
+			editorSummary[editorKey] = es
+
+			categoryKey := share.date + "|" + a.category
+			cs := categorySummary[categoryKey]
+			cs.totalTime += share.seconds
+			cs.totalLines += lines
+			categorySummary[categoryKey] = cs
+
+			machineKey := share.date + "|" + a.machine
+			ms := machineSummary[machineKey]
+			ms.totalTime += share.seconds
+			ms.totalLines += lines
+			machineSummary[machineKey] = ms
 		}
-		ps.fileCount++
-		projSummary[projKey] = ps
 
-		editorKey := date + "|" + a.editor
-		es := editorSummary[editorKey]
-		es.totalTime += gap
-		es.totalLines += a.lines
-		editorSummary[editorKey] = es
+		durations[a.id] = gap
 
-		prevTS = ts
+		// Timed activities book their own run, heartbeats are measured
+		// against each other as when they were saved.
+		if a.command == "" || a.duration <= 0 {
+			prevTS = ts
+		}
 	}
 
 	for date, ds := range dailySummary {
@@ -483,6 +813,29 @@ func (s *SQLiteStorage) RebuildSummaries() error {
 		}
 	}
 
+	// A project's main language on a day is the one it spent the most time
+	// in, ties going to the first name.
+	mainTime := make(map[string]float64)
+	for key, pls := range projLangSummary {
+		projKey := key.date + "|" + key.project
+		ps := projSummary[projKey]
+		if best, seen := mainTime[projKey]; !seen || pls.totalTime > best ||
+			(pls.totalTime == best && key.language < ps.mainLanguage) {
+			mainTime[projKey] = pls.totalTime
+			ps.mainLanguage = key.language
+			projSummary[projKey] = ps
+		}
+
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO daily_project_language_summary
+				(date, project, language, total_time, total_lines, file_count)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, key.date, key.project, key.language, pls.totalTime, pls.totalLines, pls.fileCount)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild project language summary: %w", err)
+		}
+	}
+
 	for key, ps := range projSummary {
 		parts := splitKey(key)
 		date := parts[0]
@@ -511,6 +864,38 @@ func (s *SQLiteStorage) RebuildSummaries() error {
 		}
 	}
 
+	for key, cs := range categorySummary {
+		parts := splitKey(key)
+		date := parts[0]
+		category := parts[1]
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO daily_category_summary
+				(date, category, total_time, total_lines)
+			VALUES (?, ?, ?, ?)
+		`, date, category, cs.totalTime, cs.totalLines)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild category summary: %w", err)
+		}
+	}
+
+	for key, ms := range machineSummary {
+		parts := splitKey(key)
+		date := parts[0]
+		machine := parts[1]
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO daily_machine_summary
+				(date, machine, total_time, total_lines)
+			VALUES (?, ?, ?, ?)
+		`, date, machine, ms.totalTime, ms.totalLines)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild machine summary: %w", err)
+		}
+	}
+
+	if err := updateDurations(tx, durations); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit: %w", err)
 	}
@@ -554,12 +939,14 @@ func scanActivities(rows *sql.Rows, capacityHint int) ([]Activity, error) {
 		var a Activity
 		var timestamp int64
 		var isWriteInt int
-		var branch sql.NullString
+		var duration sql.NullFloat64
+		var branch, category, machine, command sql.NullString
+		var exitCode sql.NullInt64
 
 		err := rows.Scan(
-			&a.ID, &timestamp, &a.Lines, &a.Language,
+			&a.ID, &timestamp, &duration, &a.Lines, &a.Language,
 			&a.Project, &a.Editor, &a.File, &branch,
-			&isWriteInt,
+			&category, &machine, &command, &exitCode, &isWriteInt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan activity: %w", err)
@@ -568,6 +955,11 @@ func scanActivities(rows *sql.Rows, capacityHint int) ([]Activity, error) {
 		a.Timestamp = time.Unix(timestamp, 0)
 		a.IsWrite = isWriteInt == 1
 		a.Branch = branch.String
+		a.Category = category.String
+		a.Machine = machine.String
+		a.Duration = duration.Float64
+		a.Command = command.String
+		a.ExitCode = int(exitCode.Int64)
 
 		activities = append(activities, a)
 	}
@@ -586,6 +978,11 @@ func createSchema(db *sql.DB) error {
 		editor TEXT DEFAULT 'unknown',
 		file TEXT DEFAULT '',
 		branch TEXT,
+		category TEXT DEFAULT 'coding',
+		machine TEXT DEFAULT '',
+		duration REAL,
+		command TEXT DEFAULT '',
+		exit_code INTEGER DEFAULT 0,
 		is_write INTEGER DEFAULT 1,
 		created_at INTEGER DEFAULT (strftime('%s', 'now'))
 	) WITHOUT ROWID;
@@ -628,6 +1025,16 @@ func createSchema(db *sql.DB) error {
 		PRIMARY KEY (date, project)
 	);
 
+	CREATE TABLE IF NOT EXISTS daily_project_language_summary (
+		date TEXT NOT NULL,
+		project TEXT NOT NULL,
+		language TEXT NOT NULL,
+		total_time REAL DEFAULT 0,
+		total_lines INTEGER DEFAULT 0,
+		file_count INTEGER DEFAULT 0,
+		PRIMARY KEY (date, project, language)
+	);
+
 	CREATE TABLE IF NOT EXISTS daily_editor_summary (
 		date TEXT NOT NULL,
 		editor TEXT NOT NULL,
@@ -635,12 +1042,198 @@ func createSchema(db *sql.DB) error {
 		total_lines INTEGER DEFAULT 0,
 		PRIMARY KEY (date, editor)
 	);
+
+	CREATE TABLE IF NOT EXISTS daily_category_summary (
+		date TEXT NOT NULL,
+		category TEXT NOT NULL,
+		total_time REAL DEFAULT 0,
+		total_lines INTEGER DEFAULT 0,
+		PRIMARY KEY (date, category)
+	);
+
+	CREATE TABLE IF NOT EXISTS daily_machine_summary (
+		date TEXT NOT NULL,
+		machine TEXT NOT NULL,
+		total_time REAL DEFAULT 0,
+		total_lines INTEGER DEFAULT 0,
+		PRIMARY KEY (date, machine)
+	);
+
+	CREATE TABLE IF NOT EXISTS goals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		period TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		target REAL NOT NULL,
+		project TEXT DEFAULT '',
+		language TEXT DEFAULT '',
+		weekdays TEXT DEFAULT '',
+		created_at INTEGER DEFAULT (strftime('%s', 'now'))
+	);
+
+	CREATE TABLE IF NOT EXISTS hook_events (
+		event TEXT NOT NULL,
+		key TEXT NOT NULL,
+		fired_at INTEGER NOT NULL,
+		PRIMARY KEY (event, key)
+	);
+
+	CREATE TABLE IF NOT EXISTS job_runs (
+		name TEXT PRIMARY KEY,
+		ran_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS achievements (
+		id TEXT PRIMARY KEY,
+		unlocked_at INTEGER NOT NULL,
+		context TEXT DEFAULT '',
+		backfilled INTEGER DEFAULT 0
+	);
 	`
 
 	_, err := db.Exec(schema)
 	return err
 }
 
+// activityColumns lists columns added to activities after the first release.
+// Databases created before a column existed get it added by migrateSchema.
+var activityColumns = []struct {
+	name       string
+	definition string
+}{
+	{"category", "category TEXT DEFAULT 'coding'"},
+	{"machine", "machine TEXT DEFAULT ''"},
+	{"duration", "duration REAL"},
+	{"command", "command TEXT DEFAULT ''"},
+	{"exit_code", "exit_code INTEGER DEFAULT 0"},
+}
+
+func migrateSchema(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(activities)")
+	if err != nil {
+		return fmt.Errorf("failed to read activities schema: %w", err)
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read activities schema: %w", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, col := range activityColumns {
+		if existing[col.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE activities ADD COLUMN " + col.definition); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", col.name, err)
+		}
+	}
+
+	if !existing["duration"] {
+		if err := backfillDurations(db); err != nil {
+			return err
+		}
+	}
+
+	return backfillProjectLanguages(db)
+}
+
+// backfillProjectLanguages fills daily_project_language_summary, which
+// databases created before it existed have empty, from the activities.
+func backfillProjectLanguages(db *sql.DB) error {
+	var filled, empty bool
+	err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM daily_project_language_summary),
+		       NOT EXISTS (SELECT 1 FROM activities)
+	`).Scan(&filled, &empty)
+	if err != nil {
+		return fmt.Errorf("failed to check project language summary: %w", err)
+	}
+	if filled || empty {
+		return nil
+	}
+
+	_, err = db.Exec(`
+		INSERT OR REPLACE INTO daily_project_language_summary
+			(date, project, language, total_time, total_lines, file_count)
+		SELECT date(timestamp, 'unixepoch', 'localtime'), project, language,
+		       COALESCE(SUM(duration), 0), COALESCE(SUM(lines), 0), COUNT(*)
+		FROM activities
+		GROUP BY 1, 2, 3
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to backfill project language summary: %w", err)
+	}
+	return nil
+}
+
+// backfillDurations fills the duration column of rows written before it
+// existed, using the same capped gap as RebuildSummaries.
+func backfillDurations(db *sql.DB) error {
+	const maxGap = 120.0
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, timestamp FROM activities ORDER BY timestamp ASC")
+	if err != nil {
+		return fmt.Errorf("failed to load activities: %w", err)
+	}
+
+	durations := make(map[string]float64)
+	var prevTS int64
+	for rows.Next() {
+		var id string
+		var ts int64
+		if err := rows.Scan(&id, &ts); err != nil {
+			rows.Close()
+			return err
+		}
+		gap := maxGap
+		if prevTS != 0 && float64(ts-prevTS) < maxGap {
+			gap = float64(ts - prevTS)
+		}
+		durations[id] = gap
+		prevTS = ts
+	}
+	rows.Close()
+
+	if err := updateDurations(tx, durations); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func updateDurations(tx *sql.Tx, durations map[string]float64) error {
+	stmt, err := tx.Prepare("UPDATE activities SET duration = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare duration update: %w", err)
+	}
+	defer stmt.Close()
+
+	for id, d := range durations {
+		if _, err := stmt.Exec(d, id); err != nil {
+			return fmt.Errorf("failed to update duration: %w", err)
+		}
+	}
+	return nil
+}
+
 func GetDefaultDBPath() (string, error) {
 	dataHome := os.Getenv("XDG_DATA_HOME")
 	if dataHome == "" {
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dailyTimes(t *testing.T, storage *SQLiteStorage, from, to time.Time) map[string]float64 {
+	t.Helper()
+	days, err := storage.GetDailySummaries(from, to)
+	require.NoError(t, err)
+	times := make(map[string]float64)
+	for _, d := range days {
+		times[d.Date] = d.TotalTime
+	}
+	return times
+}
+
+func TestSQLiteStorage_SaveTimedActivity(t *testing.T) {
+	time.Local = time.UTC
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "codeme.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	save := func(a Activity) {
+		a.ID = GenerateID()
+		a.Project, a.Language, a.Editor = "codeme", "go", "vim"
+		require.NoError(t, storage.SaveActivity(a))
+	}
+
+	// Heartbeats at 10:00, 10:01 and 10:02 while `make test` runs from
+	// 10:00:30 to 10:05:30; the 90s they cover of the run are not booked twice.
+	day := time.Date(2026, 3, 10, 10, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		save(Activity{Timestamp: day.Add(time.Duration(i) * time.Minute), File: "/a.go"})
+	}
+	save(Activity{Timestamp: day.Add(30 * time.Second), Duration: 300, Command: "make"})
+	require.Equal(t, map[string]float64{"2026-03-10": 120 + 60 + 60 + 210}, dailyTimes(t, storage, day, day))
+
+	commands, err := storage.GetCommandSummary(day, day)
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+	require.Equal(t, 210.0, commands[0].TotalTime)
+
+	// An hour-long build from 23:30 is split over both days.
+	late := time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC)
+	save(Activity{Timestamp: late, Duration: 3600, Command: "cargo"})
+	want := map[string]float64{"2026-03-10": 450 + 1800, "2026-03-11": 1800}
+	require.Equal(t, want, dailyTimes(t, storage, day, day.AddDate(0, 0, 1)))
+
+	days, err := storage.GetDailySummaries(day.AddDate(0, 0, 1), day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, 0, days[0].ActivityCount, "the run is counted on the day it started")
+
+	require.NoError(t, storage.RebuildSummaries())
+	require.Equal(t, want, dailyTimes(t, storage, day, day.AddDate(0, 0, 1)), "rebuilding gives the same")
+}
+
+func TestSplitByDay(t *testing.T) {
+	start := time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)
+	require.Equal(t, []dayShare{{date: "2026-03-10", start: start, seconds: 600}}, splitByDay(start, 600))
+
+	shares := splitByDay(start, 30*3600)
+	require.Len(t, shares, 3)
+	require.Equal(t, []float64{7200, 86400, 14400}, []float64{shares[0].seconds, shares[1].seconds, shares[2].seconds})
+	require.Equal(t, "2026-03-12", shares[2].date)
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type Tracker struct {
-	storage  Storage
-	detector *Detector
+	storage    Storage
+	detector   *Detector
+	categorize CategoryFunc
 }
 
 func NewTracker(storage Storage) *Tracker {
@@ -18,29 +19,56 @@ func NewTracker(storage Storage) *Tracker {
 	}
 }
 
+// SetCategorizer sets the function used to infer a category when an activity
+// is tracked without one. Without it every such activity counts as coding.
+func (t *Tracker) SetCategorizer(fn CategoryFunc) {
+	t.categorize = fn
+}
+
 func (t *Tracker) TrackFileActivity(filePath, language, editor string, linesChanged int, isWrite bool) error {
-	now := time.Now()
+	return t.TrackActivity(Activity{
+		File:     filePath,
+		Language: language,
+		Editor:   editor,
+		Lines:    linesChanged,
+		IsWrite:  isWrite,
+	})
+}
 
-	if language == "" || language == "unknown" {
-		language = t.detector.DetectLanguage(filePath)
+// TrackActivity fills in whatever the caller left empty (ID, timestamp,
+// language, project, editor, category, machine) and saves the activity.
+func (t *Tracker) TrackActivity(activity Activity) error {
+	if activity.ID == "" {
+		activity.ID = GenerateID()
 	}
 
-	project := t.detector.DetectProject(filePath)
+	if activity.Timestamp.IsZero() {
+		activity.Timestamp = time.Now()
+	}
+
+	if (activity.Language == "" || activity.Language == "unknown") && activity.File != "" {
+		activity.Language = t.detector.DetectLanguage(activity.File)
+	}
 
-	if editor == "" {
-		editor = "neovim"
+	if activity.Project == "" {
+		activity.Project = t.detector.DetectProject(activity.File)
 	}
 
-	activity := Activity{
-		ID:        GenerateID(),
-		Timestamp: now,
-		Duration:  0,
-		Lines:     linesChanged,
-		Language:  language,
-		Project:   project,
-		Editor:    editor,
-		File:      filePath,
-		IsWrite:   isWrite,
+	if activity.Editor == "" {
+		activity.Editor = "neovim"
+	}
+
+	activity.Machine = NormalizeMachine(activity.Machine)
+	if activity.Machine == "" {
+		activity.Machine = DetectMachine()
+	}
+
+	activity.Category = NormalizeCategory(activity.Category)
+	if activity.Category == "" && t.categorize != nil {
+		activity.Category = t.categorize(activity.File, activity.Language)
+	}
+	if activity.Category == "" {
+		activity.Category = CategoryCoding
 	}
 
 	if err := t.storage.SaveActivity(activity); err != nil {
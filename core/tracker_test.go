@@ -35,6 +35,14 @@ func (m *mockStorage) GetPeriodSummary(from, to time.Time) (PeriodSummary, error
 	return PeriodSummary{}, nil
 }
 
+func (m *mockStorage) GetDailySummaries(from, to time.Time) ([]DailySummary, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) GetProjectLanguageSummary(from, to time.Time) ([]ProjectLanguageRow, error) {
+	return nil, nil
+}
+
 func (m *mockStorage) GetLanguageSummary(from, to time.Time) ([]LanguageRow, error) {
 	return nil, nil
 }
@@ -47,6 +55,18 @@ func (m *mockStorage) GetEditorSummary(from, to time.Time) ([]EditorRow, error)
 	return nil, nil
 }
 
+func (m *mockStorage) GetCategorySummary(from, to time.Time) ([]CategoryRow, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) GetMachineSummary(from, to time.Time) ([]MachineRow, error) {
+	return nil, nil
+}
+
+func (m *mockStorage) GetCommandSummary(from, to time.Time) ([]CommandRow, error) {
+	return nil, nil
+}
+
 func (m *mockStorage) Optimize() error {
 	return nil
 }
@@ -213,3 +233,55 @@ func TestTracker_ProjectDetection(t *testing.T) {
 	require.Len(t, storage.activities, 1)
 	require.NotEmpty(t, storage.activities[0].Project)
 }
+
+func TestTracker_Category(t *testing.T) {
+	t.Run("defaults to coding", func(t *testing.T) {
+		storage := &mockStorage{}
+		tracker := NewTracker(storage)
+
+		require.NoError(t, tracker.TrackFileActivity("/p/main_test.go", "go", "vim", 1, true))
+		require.Equal(t, CategoryCoding, storage.activities[0].Category)
+	})
+
+	t.Run("uses categorizer", func(t *testing.T) {
+		storage := &mockStorage{}
+		tracker := NewTracker(storage)
+		tracker.SetCategorizer(func(filePath, language string) string {
+			return CategoryTesting
+		})
+
+		require.NoError(t, tracker.TrackFileActivity("/p/main_test.go", "go", "vim", 1, true))
+		require.Equal(t, CategoryTesting, storage.activities[0].Category)
+	})
+
+	t.Run("explicit category wins and is normalized", func(t *testing.T) {
+		storage := &mockStorage{}
+		tracker := NewTracker(storage)
+		tracker.SetCategorizer(func(filePath, language string) string {
+			return CategoryTesting
+		})
+
+		err := tracker.TrackActivity(Activity{File: "/p/main.go", Category: " Writing Docs "})
+		require.NoError(t, err)
+		require.Equal(t, CategoryDocs, storage.activities[0].Category)
+	})
+}
+
+func TestTracker_Machine(t *testing.T) {
+	t.Run("defaults to detected machine", func(t *testing.T) {
+		storage := &mockStorage{}
+		tracker := NewTracker(storage)
+
+		require.NoError(t, tracker.TrackFileActivity("/p/main.go", "go", "vim", 1, true))
+		require.Equal(t, DetectMachine(), storage.activities[0].Machine)
+		require.NotEmpty(t, storage.activities[0].Machine)
+	})
+
+	t.Run("explicit machine is normalized", func(t *testing.T) {
+		storage := &mockStorage{}
+		tracker := NewTracker(storage)
+
+		require.NoError(t, tracker.TrackActivity(Activity{File: "/p/main.go", Machine: " Work-Laptop.local "}))
+		require.Equal(t, "work-laptop", storage.activities[0].Machine)
+	})
+}
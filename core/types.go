@@ -12,9 +12,20 @@ type Activity struct {
 	Editor    string
 	File      string
 	Branch    string
+	Category  string
+	Machine   string
+	Command   string
+	ExitCode  int
 	IsWrite   bool
 }
 
+// IsTimed reports whether the activity carries its own measured duration
+// (a wrapped command) instead of one derived from the gap to the previous
+// activity.
+func (a Activity) IsTimed() bool {
+	return a.Command != "" && a.Duration > 0
+}
+
 type Session struct {
 	ID         string
 	StartTime  time.Time
@@ -52,20 +63,54 @@ type ProjectRow struct {
 	MainLanguage string
 }
 
+// ProjectLanguageRow is the time spent in one language within a project.
+type ProjectLanguageRow struct {
+	Project    string
+	Language   string
+	TotalTime  float64
+	TotalLines int
+}
+
 type EditorRow struct {
 	Editor     string
 	TotalTime  float64
 	TotalLines int
 }
 
+type CategoryRow struct {
+	Category   string
+	TotalTime  float64
+	TotalLines int
+}
+
+type MachineRow struct {
+	Machine    string
+	TotalTime  float64
+	TotalLines int
+}
+
+type CommandRow struct {
+	Project   string
+	Category  string
+	Command   string
+	Runs      int
+	Failures  int
+	TotalTime float64
+}
+
 type Storage interface {
 	SaveActivity(Activity) error
 	GetActivitiesSince(time.Time) ([]Activity, error)
 	GetActivityCount() (int, error)
 	GetPeriodSummary(from, to time.Time) (PeriodSummary, error)
+	GetDailySummaries(from, to time.Time) ([]DailySummary, error)
 	GetLanguageSummary(from, to time.Time) ([]LanguageRow, error)
 	GetProjectSummary(from, to time.Time) ([]ProjectRow, error)
+	GetProjectLanguageSummary(from, to time.Time) ([]ProjectLanguageRow, error)
 	GetEditorSummary(from, to time.Time) ([]EditorRow, error)
+	GetCategorySummary(from, to time.Time) ([]CategoryRow, error)
+	GetMachineSummary(from, to time.Time) ([]MachineRow, error)
+	GetCommandSummary(from, to time.Time) ([]CommandRow, error)
 	Optimize() error
 	RebuildSummaries() error
 	Close() error
@@ -0,0 +1,32 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard's HTML, CSS and JavaScript. The page loads its
+// data from the /api endpoints of the server it is mounted on, so it needs
+// nothing from the network.
+func Handler() http.Handler {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(sub)
+}
+
+// IsAsset reports whether a path belongs to the dashboard rather than the
+// API. Assets hold no activity data and are served without a token, so a
+// browser can load the page before the script attaches the token.
+func IsAsset(path string) bool {
+	switch path {
+	case "/", "/index.html", "/app.js", "/style.css", "/favicon.svg":
+		return true
+	}
+	return false
+}
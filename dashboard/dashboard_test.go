@@ -0,0 +1,42 @@
+package dashboard
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ServesIndex(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rec.Body.String(), `<script src="app.js">`)
+}
+
+// Every file the page references must be embedded and public, or the page
+// breaks offline or behind a token.
+func TestHandler_AssetsAreEmbeddedAndPublic(t *testing.T) {
+	index, err := fs.ReadFile(static, "static/index.html")
+	require.NoError(t, err)
+
+	refs := regexp.MustCompile(`(?:src|href)="([^"]+)"`).FindAllStringSubmatch(string(index), -1)
+	require.NotEmpty(t, refs)
+
+	for _, ref := range refs {
+		path := "/" + ref[1]
+		require.True(t, IsAsset(path), "%s is not public", path)
+
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+	}
+
+	require.False(t, IsAsset("/api/stats"))
+	require.False(t, IsAsset("/metrics"))
+}
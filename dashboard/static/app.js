@@ -0,0 +1,345 @@
+"use strict";
+
+// The page is served by `codeme dashboard` (or `codeme serve --dashboard`) and
+// reads everything from the same server's /api/stats. A token given as
+// ?api_key=... in the page URL is passed on to the API.
+
+const SVG_NS = "http://www.w3.org/2000/svg";
+const REFRESH_MS = 60 * 1000;
+const PALETTE = ["#39d353", "#58a6ff", "#d2a8ff", "#ffa657", "#ff7b72", "#79c0ff", "#f2cc60", "#56d4dd", "#8b949e"];
+
+const apiKey = new URLSearchParams(location.search).get("api_key");
+let period = "today";
+let data = null;
+
+function apiURL(path) {
+  const url = new URL(path, location.href);
+  if (apiKey) url.searchParams.set("api_key", apiKey);
+  return url;
+}
+
+// The server sends ETags with Cache-Control: no-cache, so the browser
+// revalidates each refresh and an unchanged database costs a 304.
+async function load() {
+  try {
+    const resp = await fetch(apiURL("api/stats"));
+    if (!resp.ok) {
+      const body = await resp.json().catch(() => ({}));
+      throw new Error(body.error || `${resp.status} ${resp.statusText}`);
+    }
+    data = await resp.json();
+    showError(null);
+    render();
+  } catch (err) {
+    showError(`Could not load stats: ${err.message}`);
+  }
+}
+
+function showError(message) {
+  const el = document.getElementById("error");
+  el.hidden = !message;
+  el.textContent = message || "";
+}
+
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k === "class") node.className = v;
+    else if (k === "style") node.style.cssText = v;
+    else node.setAttribute(k, v);
+  }
+  for (const child of children) {
+    node.append(child);
+  }
+  return node;
+}
+
+function svg(tag, attrs = {}, ...children) {
+  const node = document.createElementNS(SVG_NS, tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    node.setAttribute(k, v);
+  }
+  for (const child of children) {
+    node.append(child);
+  }
+  return node;
+}
+
+function title(text) {
+  const t = document.createElementNS(SVG_NS, "title");
+  t.textContent = text;
+  return t;
+}
+
+function formatDuration(seconds) {
+  seconds = Math.round(seconds || 0);
+  const h = Math.floor(seconds / 3600);
+  const m = Math.floor((seconds % 3600) / 60);
+  if (h > 0) return `${h}h ${m}m`;
+  if (m > 0) return `${m}m`;
+  return `${seconds}s`;
+}
+
+function formatNumber(n) {
+  return (n || 0).toLocaleString();
+}
+
+function replace(id, ...children) {
+  document.getElementById(id).replaceChildren(...children);
+}
+
+function render() {
+  const stats = data[period];
+
+  renderStreak(data.streak_info);
+  renderSummary(stats);
+  renderHeatmap(data.weekly_heatmap || []);
+  renderHourly(stats.hourly_activity || []);
+  renderDonut("languages", stats.languages || []);
+  renderDonut("projects", stats.projects || []);
+  renderSessions(stats.sessions || []);
+  renderRecords(data.records);
+  renderAchievements(data.achievements || []);
+
+  document.getElementById("footer").textContent =
+    `Updated ${new Date(data.generated_at).toLocaleTimeString()} · ` +
+    `${formatNumber(data._meta.total_activities)} activities · ${data._meta.data_window.replaceAll("_", " ")}`;
+}
+
+function renderStreak(streak) {
+  const current = el("strong", {}, `${streak.current} day${streak.current === 1 ? "" : "s"}`);
+  const longest = el("strong", {}, `${streak.longest}`);
+  replace("streak", "🔥 Streak ", current, " · longest ", longest);
+}
+
+function renderSummary(stats) {
+  const cards = [
+    ["Time", formatDuration(stats.total_time)],
+    ["Lines", formatNumber(stats.total_lines)],
+    ["Files", formatNumber(stats.total_files)],
+    ["Sessions", formatNumber(stats.session_count)],
+    ["Focus", `${stats.focus_score}%`],
+  ];
+  replace("summary", ...cards.map(([label, value]) =>
+    el("div", { class: "card" }, el("div", { class: "value" }, value), el("div", { class: "label" }, label))));
+}
+
+// renderHeatmap draws one column per week, Monday on top, like GitHub.
+// Days in the future have level -1 and are left out.
+function renderHeatmap(days) {
+  if (days.length === 0) {
+    replace("heatmap", el("p", { class: "empty" }, "No activity yet."));
+    return;
+  }
+
+  const cell = 12, gap = 3, left = 28, top = 16;
+  const weeks = Math.ceil(days.length / 7);
+  const root = svg("svg", {
+    width: left + weeks * (cell + gap),
+    height: top + 7 * (cell + gap),
+    role: "img",
+    "aria-label": "Daily activity heatmap",
+  });
+
+  ["Mon", "", "Wed", "", "Fri", "", ""].forEach((label, row) => {
+    if (label) {
+      root.append(svg("text", { x: 0, y: top + row * (cell + gap) + cell - 2 }, label));
+    }
+  });
+
+  let lastMonth = -1;
+  days.forEach((day, i) => {
+    const week = Math.floor(i / 7);
+    const row = i % 7;
+    const x = left + week * (cell + gap);
+
+    const date = new Date(day.date + "T00:00:00");
+    if (row === 0 && date.getMonth() !== lastMonth) {
+      lastMonth = date.getMonth();
+      root.append(svg("text", { x, y: 10 }, date.toLocaleString(undefined, { month: "short" })));
+    }
+    if (day.level < 0) return;
+
+    root.append(svg("rect", {
+      x, y: top + row * (cell + gap), width: cell, height: cell, rx: 2,
+      class: `level-${day.level}`,
+    }, title(`${day.date}: ${formatDuration(day.time)}, ${formatNumber(day.lines)} lines`)));
+  });
+
+  replace("heatmap", root);
+}
+
+function renderHourly(hours) {
+  const width = 720, height = 120, bottom = 16;
+  const max = Math.max(1, ...hours.map((h) => h.duration));
+  const slot = width / 24;
+  const root = svg("svg", { viewBox: `0 0 ${width} ${height}`, width: "100%", role: "img", "aria-label": "Hourly activity" });
+
+  for (let hour = 0; hour < 24; hour++) {
+    const h = hours.find((x) => x.hour === hour) || { duration: 0 };
+    const barHeight = (h.duration / max) * (height - bottom - 4);
+    root.append(svg("rect", {
+      x: hour * slot + 2, y: height - bottom - barHeight, width: slot - 4, height: barHeight, rx: 2,
+      class: h.is_peak ? "bar peak" : "bar",
+    }, title(`${String(hour).padStart(2, "0")}:00 · ${formatDuration(h.duration)}`)));
+
+    if (hour % 3 === 0) {
+      root.append(svg("text", { x: hour * slot + 2, y: height - 2 }, `${String(hour).padStart(2, "0")}h`));
+    }
+  }
+
+  replace("hourly", root);
+}
+
+// renderDonut shows the top eight items; the rest are folded into "other".
+function renderDonut(id, items) {
+  items = items.filter((x) => x.time > 0);
+  if (items.length === 0) {
+    replace(id, el("p", { class: "empty" }, "Nothing tracked in this period."));
+    return;
+  }
+
+  let slices = items.slice(0, 8).map((x) => ({ name: x.name, time: x.time }));
+  const rest = items.slice(8).reduce((sum, x) => sum + x.time, 0);
+  if (rest > 0) slices.push({ name: "other", time: rest });
+
+  const total = slices.reduce((sum, x) => sum + x.time, 0);
+  const r = 60, stroke = 24, size = 2 * (r + stroke);
+  const circumference = 2 * Math.PI * r;
+  const root = svg("svg", { width: size, height: size, viewBox: `0 0 ${size} ${size}`, role: "img" });
+
+  let offset = 0;
+  slices.forEach((slice, i) => {
+    const length = (slice.time / total) * circumference;
+    root.append(svg("circle", {
+      cx: size / 2, cy: size / 2, r,
+      fill: "none",
+      stroke: PALETTE[i % PALETTE.length],
+      "stroke-width": stroke,
+      "stroke-dasharray": `${length} ${circumference - length}`,
+      "stroke-dashoffset": -offset,
+      transform: `rotate(-90 ${size / 2} ${size / 2})`,
+    }, title(`${slice.name}: ${formatDuration(slice.time)}`)));
+    offset += length;
+  });
+
+  const legend = el("ul", { class: "legend" }, ...slices.map((slice, i) =>
+    el("li", {},
+      el("span", { class: "swatch", style: `background:${PALETTE[i % PALETTE.length]}` }),
+      slice.name,
+      el("span", { class: "amount" }, `${formatDuration(slice.time)} · ${Math.round((slice.time / total) * 100)}%`))));
+
+  replace(id, root, legend);
+}
+
+// renderSessions draws one 24-hour row per day, most recent first, with a
+// block for each session. Sessions crossing midnight are drawn on the day
+// they started.
+function renderSessions(sessions) {
+  if (sessions.length === 0) {
+    replace("sessions", el("p", { class: "empty" }, "No sessions in this period."));
+    return;
+  }
+
+  const byDay = new Map();
+  for (const s of sessions) {
+    const start = new Date(s.start_time);
+    const key = start.toDateString();
+    if (!byDay.has(key)) byDay.set(key, { date: start, sessions: [] });
+    byDay.get(key).sessions.push(s);
+  }
+
+  const days = [...byDay.values()].sort((a, b) => b.date - a.date).slice(0, 14);
+  const rows = days.map((day) => {
+    const track = el("div", { class: "track" });
+    for (const s of day.sessions) {
+      const start = new Date(s.start_time);
+      const end = new Date(s.end_time);
+      const startHour = start.getHours() + start.getMinutes() / 60;
+      const endHour = Math.min(24, startHour + (end - start) / 3600000);
+      const block = el("div", {
+        class: s.is_active ? "session active" : "session",
+        style: `left:${(startHour / 24) * 100}%;width:${((endHour - startHour) / 24) * 100}%`,
+        title: `${start.toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" })}–` +
+          `${end.toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" })} · ` +
+          `${formatDuration(s.duration)} · ${(s.projects || []).join(", ")}`,
+      });
+      track.append(block);
+    }
+    const label = day.date.toLocaleDateString(undefined, { weekday: "short", month: "short", day: "numeric" });
+    return el("div", { class: "timeline-row" }, el("span", { class: "day" }, label), track);
+  });
+
+  const axis = el("div", { class: "timeline-axis" }, ...["0h", "6h", "12h", "18h", "24h"].map((x) => el("span", {}, x)));
+  replace("sessions", ...rows, axis);
+}
+
+function renderRecords(records) {
+  const items = [];
+  const add = (label, value) => {
+    if (value) items.push(el("dt", {}, label), el("dd", {}, value));
+  };
+
+  const r = records || {};
+  if (r.most_productive_day?.date) {
+    add("Most productive day", `${r.most_productive_day.date} · ${formatDuration(r.most_productive_day.time)}`);
+  }
+  if (r.highest_daily_output?.date) {
+    add("Most lines in a day", `${r.highest_daily_output.date} · ${formatNumber(r.highest_daily_output.lines)}`);
+  }
+  if (r.longest_session?.duration) {
+    add("Longest session", `${r.longest_session.date} · ${formatDuration(r.longest_session.duration)}`);
+  }
+  if (r.best_streak?.day_count) {
+    add("Best streak", `${r.best_streak.day_count} days (${r.best_streak.start_date} – ${r.best_streak.end_date})`);
+  }
+  if (r.earliest_start?.time) add("Earliest start", `${r.earliest_start.time} on ${r.earliest_start.date}`);
+  if (r.latest_end?.time) add("Latest end", `${r.latest_end.time} on ${r.latest_end.date}`);
+  if (r.most_languages_day?.count) {
+    add("Most languages in a day", `${r.most_languages_day.count} on ${r.most_languages_day.date}`);
+  }
+
+  if (items.length === 0) {
+    replace("records", el("p", { class: "empty" }, "No records yet."));
+    return;
+  }
+  replace("records", ...items);
+}
+
+function renderAchievements(achievements) {
+  // Latest unlocks first, then locked ones.
+  const sorted = [...achievements].sort((a, b) =>
+    Number(b.unlocked) - Number(a.unlocked) || (b.unlocked_at || "").localeCompare(a.unlocked_at || ""));
+  replace("achievements", ...sorted.map((a) => {
+    const classes = [a.unlocked ? "unlocked" : "locked", a.is_new ? "new" : ""].join(" ").trim();
+    const value = (v) => a.unit === "seconds" ? formatDuration(v) : formatNumber(Math.round(v));
+    let title = a.description;
+    if (a.unlocked_at) {
+      title += `\nUnlocked ${new Date(a.unlocked_at).toLocaleDateString()}${a.context ? ` (${a.context})` : ""}`;
+    } else if (a.target) {
+      title += `\n${value(a.current)} / ${value(a.target)}`;
+      if (a.estimated_at) title += `\nAt this pace: ${new Date(a.estimated_at).toLocaleDateString()}`;
+    }
+    const item = el("li", { class: classes, title },
+      `${a.icon} ${a.name}`,
+      el("span", { class: "desc" }, a.description));
+    if (!a.unlocked && a.progress > 0) {
+      item.append(el("span", { class: "progress" }, el("span", { style: `width: ${a.progress}%` })));
+    }
+    return item;
+  }));
+}
+
+document.getElementById("periods").addEventListener("click", (event) => {
+  const button = event.target.closest("button");
+  if (!button) return;
+
+  period = button.dataset.period;
+  for (const b of document.querySelectorAll("#periods button")) {
+    b.classList.toggle("active", b === button);
+  }
+  if (data) render();
+});
+
+load();
+setInterval(load, REFRESH_MS);
@@ -0,0 +1,102 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tduyng/codeme/config"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+	"github.com/tduyng/codeme/util"
+)
+
+const (
+	AchievementUnlocked = "achievement.unlocked"
+	GoalMet             = "goal.met"
+	StreakMilestone     = "streak.milestone"
+	SessionLong         = "session.long"
+	StreakAtRisk        = "streak.at_risk"
+)
+
+var Events = []string{AchievementUnlocked, GoalMet, StreakMilestone, SessionLong, StreakAtRisk}
+
+// Event is what a hook command reads on stdin, as JSON. Key tells apart
+// occurrences of the same event: each name and key pair fires once.
+type Event struct {
+	Name    string    `json:"event"`
+	Key     string    `json:"key"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Data    any       `json:"data"`
+}
+
+type milestoneData struct {
+	stats.StreakInfo
+	Milestone int    `json:"milestone"`
+	Since     string `json:"since"`
+}
+
+// Detect lists the events s shows as of now, whether or not they fired
+// before.
+func Detect(s *stats.APIStats, cfg config.Hooks, now time.Time, tz *time.Location) []Event {
+	var events []Event
+	add := func(name, key, message string, data any) {
+		events = append(events, Event{Name: name, Key: key, Time: now, Message: message, Data: data})
+	}
+
+	for _, a := range s.Achievements {
+		if a.Unlocked {
+			add(AchievementUnlocked, a.ID, fmt.Sprintf("%s Achievement unlocked: %s", a.Icon, a.Name), a)
+		}
+	}
+
+	for _, g := range s.Goals {
+		if !g.Met || len(g.Recent) == 0 {
+			continue
+		}
+		start := g.Recent[len(g.Recent)-1].Start
+		add(GoalMet, fmt.Sprintf("%d:%s", g.ID, start), fmt.Sprintf("🎯 Goal met: %s %s", g.Period, goalTarget(g)), g)
+	}
+
+	streak := s.StreakInfo
+	today := util.DateString(now, tz)
+	if streak.IsActive && streak.Current > 0 {
+		since := util.DateString(util.StartOfDay(streak.LastActivity, tz).AddDate(0, 0, 1-streak.Current), tz)
+		for _, m := range cfg.StreakMilestones {
+			if m > 0 && streak.Current >= m {
+				add(StreakMilestone, fmt.Sprintf("%d:%s", m, since),
+					fmt.Sprintf("🔥 %d-day streak!", m),
+					milestoneData{StreakInfo: streak, Milestone: m, Since: since})
+			}
+		}
+
+		if util.DateString(streak.LastActivity, tz) != today && now.In(tz).Hour() >= cfg.AtRiskHour {
+			add(StreakAtRisk, today, fmt.Sprintf("⏳ Your %d-day streak ends at midnight", streak.Current), streak)
+		}
+	}
+
+	if cfg.LongSession.Duration > 0 {
+		for _, sess := range s.Today.Sessions {
+			if sess.Duration >= cfg.LongSession.Seconds() {
+				add(SessionLong, sess.ID,
+					fmt.Sprintf("☕ %s session, time for a break", util.FormatDuration(sess.Duration)), sess)
+			}
+		}
+	}
+
+	return events
+}
+
+func goalTarget(g stats.GoalStatus) string {
+	target := fmt.Sprintf("%d lines", int(g.Target))
+	if g.Metric == core.GoalTime {
+		target = util.FormatDuration(g.Target)
+	}
+	if g.Project != "" {
+		target += " in " + g.Project
+	}
+	if g.Language != "" {
+		target += " of " + g.Language
+	}
+	return target
+}
@@ -0,0 +1,187 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tduyng/codeme/config"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+// startedEvent is recorded on the first check, whose events are only
+// recorded: hooks set up today should not replay every past achievement.
+const startedEvent = "hooks.started"
+
+// MinInterval is the least time between checks set off by new activity,
+// which comes in bursts of heartbeats.
+const MinInterval = 30 * time.Second
+
+// CheckJob names checks in core's ClaimRun, so that processes tracking
+// activity share MinInterval.
+const CheckJob = "hooks.check"
+
+// Runner detects events and runs the commands hooked to them.
+type Runner struct {
+	cfg      config.Hooks
+	storage  *core.SQLiteStorage
+	calc     *stats.Calculator
+	timezone *time.Location
+	trigger  chan struct{}
+
+	mu sync.Mutex
+}
+
+// New returns a runner that detects events in what calc computes, so that
+// achievements come from the same rules.
+func New(cfg config.Hooks, storage *core.SQLiteStorage, calc *stats.Calculator) *Runner {
+	return &Runner{
+		cfg:      cfg,
+		storage:  storage,
+		calc:     calc,
+		timezone: time.Local,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Enabled reports whether any command is hooked to an event.
+func (r *Runner) Enabled() bool {
+	for _, commands := range r.cfg.Commands {
+		if len(commands) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Check calculates stats as of now, which saves new achievement unlocks,
+// then detects events and fires those that never fired before. It returns
+// the events fired.
+func (r *Runner) Check(now time.Time) ([]Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calc.Invalidate()
+	s, err := r.calc.CalculateAPI(r.storage, stats.APIOptions{Now: now})
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate stats: %w", err)
+	}
+	if !r.Enabled() {
+		return nil, nil
+	}
+
+	started, err := r.storage.HasEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	var fired []Event
+	var errs []error
+	for _, e := range Detect(s, r.cfg, now, r.timezone) {
+		isNew, err := r.storage.RecordEvent(e.Name, e.Key, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !isNew || !started {
+			continue
+		}
+		if err := r.Fire(e); err != nil {
+			errs = append(errs, err)
+		}
+		fired = append(fired, e)
+	}
+
+	if !started {
+		if _, err := r.storage.RecordEvent(startedEvent, "", now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return fired, errors.Join(errs...)
+}
+
+// Fire runs the commands hooked to e with e as JSON on stdin and
+// $CODEME_EVENT set to its name.
+func (r *Runner) Fire(e Event) error {
+	commands := r.cfg.Commands[e.Name]
+	if len(commands) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var errs []error
+	for _, command := range commands {
+		if err := r.run(command, e.Name, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Runner) run(command, event string, payload []byte) error {
+	ctx := context.Background()
+	if r.cfg.Timeout.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.Timeout.Duration)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "CODEME_EVENT="+event)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("hook %q for %s failed: %w: %s", command, event, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Trigger asks Watch for a check without waiting for it. While a check is
+// pending, more triggers add nothing.
+func (r *Runner) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Watch checks every interval until ctx is done, for the events that
+// depend on the time rather than on new activity, like streak.at_risk, and
+// when triggered, at most every MinInterval.
+func (r *Runner) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Check(time.Now()); err != nil && onError != nil {
+			onError(err)
+		}
+		last := time.Now()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+			if wait := MinInterval - time.Since(last); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/config"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+func names(events []Event) []string {
+	var result []string
+	for _, e := range events {
+		result = append(result, e.Name+" "+e.Key)
+	}
+	return result
+}
+
+func TestDetect(t *testing.T) {
+	now := time.Date(2026, 3, 10, 21, 0, 0, 0, time.UTC)
+	cfg := config.Default().Hooks
+
+	s := &stats.APIStats{
+		Achievements: []stats.Achievement{
+			{ID: "streak_7", Name: "Week Warrior", Unlocked: true},
+			{ID: "streak_30", Name: "Monthly Master"},
+		},
+		Goals: []stats.GoalStatus{
+			{Goal: core.Goal{ID: 1, Period: core.GoalDaily, Metric: core.GoalTime, Target: 3600}, Met: true,
+				Recent: []stats.GoalResult{{Start: "2026-03-10", Met: true}}},
+			{Goal: core.Goal{ID: 2, Period: core.GoalDaily, Metric: core.GoalLines, Target: 500}},
+		},
+		// Eight days up to yesterday, nothing today yet.
+		StreakInfo: stats.StreakInfo{Current: 8, IsActive: true, LastActivity: now.AddDate(0, 0, -1)},
+		Today: stats.APIPeriodStats{Sessions: []stats.APISession{
+			{ID: "a", Duration: 3 * 3600},
+			{ID: "b", Duration: 600},
+		}},
+	}
+
+	events := Detect(s, cfg, now, time.UTC)
+	require.Equal(t, []string{
+		"achievement.unlocked streak_7",
+		"goal.met 1:2026-03-10",
+		"streak.milestone 7:2026-03-02",
+		"streak.at_risk 2026-03-10",
+		"session.long a",
+	}, names(events))
+
+	events = Detect(s, cfg, now.Add(-2*time.Hour), time.UTC)
+	require.NotContains(t, names(events), "streak.at_risk 2026-03-10", "not late enough")
+}
+
+func TestRunner_Check(t *testing.T) {
+	time.Local = time.UTC
+	dir := t.TempDir()
+	storage, err := core.NewSQLiteStorage(filepath.Join(dir, "codeme.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	out := filepath.Join(dir, "events.log")
+	cfg := config.Default().Hooks
+	cfg.Commands = map[string][]string{
+		GoalMet: {`echo "$CODEME_EVENT $(cat)" >> ` + out},
+	}
+	runner := New(cfg, storage, stats.NewCalculator(time.Local))
+	require.True(t, runner.Enabled())
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	_, err = storage.AddGoal(core.Goal{Period: core.GoalDaily, Metric: core.GoalLines, Target: 100})
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveActivity(core.Activity{
+		ID: core.GenerateID(), Timestamp: now.AddDate(0, 0, -1), Lines: 150,
+		Language: "go", Project: "codeme", Editor: "vim", File: "/a.go", IsWrite: true,
+	}))
+
+	fired, err := runner.Check(now)
+	require.NoError(t, err)
+	require.Empty(t, fired, "the first check only records what already happened")
+
+	require.NoError(t, storage.SaveActivity(core.Activity{
+		ID: core.GenerateID(), Timestamp: now.Add(-time.Minute), Lines: 120,
+		Language: "go", Project: "codeme", Editor: "vim", File: "/a.go", IsWrite: true,
+	}))
+	fired, err = runner.Check(now)
+	require.NoError(t, err)
+	require.Equal(t, []string{"goal.met 1:2026-03-10"}, names(fired))
+
+	fired, err = runner.Check(now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, fired, "events fire once")
+
+	log, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(log), "\n"))
+	require.True(t, strings.HasPrefix(string(log), `goal.met {"event":"goal.met","key":"1:2026-03-10"`))
+}
+
+func TestRunner_CheckWithoutHooks(t *testing.T) {
+	storage, err := core.NewSQLiteStorage(filepath.Join(t.TempDir(), "codeme.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.SaveActivity(core.Activity{
+		ID: core.GenerateID(), Timestamp: now.Add(-time.Minute), Lines: 1500,
+		Language: "go", Project: "codeme", Editor: "vim", File: "/a.go", IsWrite: true,
+	}))
+
+	runner := New(config.Default().Hooks, storage, stats.NewCalculator(time.UTC))
+	require.False(t, runner.Enabled())
+	fired, err := runner.Check(now)
+	require.NoError(t, err)
+	require.Empty(t, fired)
+
+	unlocked, err := storage.GetUnlockedAchievements()
+	require.NoError(t, err)
+	require.NotEmpty(t, unlocked, "checks save unlocks even without hooks")
+}
+
+func TestRunner_FireError(t *testing.T) {
+	cfg := config.Default().Hooks
+	cfg.Commands = map[string][]string{StreakAtRisk: {"echo oops; exit 3"}}
+	err := New(cfg, nil, nil).Fire(Event{Name: StreakAtRisk})
+	require.ErrorContains(t, err, "oops")
+}
+
+func TestRunner_TriggerCoalesces(t *testing.T) {
+	runner := New(config.Default().Hooks, nil, nil)
+	for range 100 {
+		runner.Trigger()
+	}
+	require.Len(t, runner.trigger, 1, "one pending check at most")
+}
@@ -2,14 +2,36 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/tduyng/codeme/config"
 	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/hooks"
+	"github.com/tduyng/codeme/metrics"
+	"github.com/tduyng/codeme/render"
+	"github.com/tduyng/codeme/report"
+	"github.com/tduyng/codeme/rpc"
+	"github.com/tduyng/codeme/server"
+	"github.com/tduyng/codeme/shell"
 	"github.com/tduyng/codeme/stats"
+	"github.com/tduyng/codeme/util"
 )
 
 var (
@@ -31,14 +53,44 @@ func main() {
 	switch cmd {
 	case "track":
 		handleTrack(os.Args[2:])
+	case "exec":
+		handleExec(os.Args[2:])
+	case "shell-init":
+		handleShellInit(os.Args[2:])
+	case "shell-track":
+		handleShellTrack(os.Args[2:])
 	case "stats":
 		handleStats(os.Args[2:])
 	case "today":
-		handleToday()
+		handleToday(os.Args[2:])
 	case "projects":
 		handleProjects()
+	case "project":
+		handleProject(os.Args[2:])
+	case "file":
+		handleFile(os.Args[2:])
+	case "goal", "goals":
+		handleGoal(os.Args[2:])
+	case "hooks":
+		handleHooks(os.Args[2:])
+	case "achievements":
+		handleAchievements(os.Args[2:])
+	case "calendar":
+		handleCalendar(os.Args[2:])
 	case "api":
 		handleAPI(os.Args[2:])
+	case "rpc":
+		handleRPC()
+	case "serve":
+		handleServe(os.Args[2:])
+	case "dashboard":
+		handleDashboard(os.Args[2:])
+	case "render":
+		handleRender(os.Args[2:])
+	case "metrics":
+		handleMetrics(os.Args[2:])
+	case "report":
+		handleReport(os.Args[2:])
 	case "optimize":
 		handleOptimize()
 	case "rebuild-summaries":
@@ -70,10 +122,24 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  track      Track a file activity")
+	fmt.Println("  exec       Run a command and track its run time")
+	fmt.Println("  shell-init Print shell hooks that track terminal activity")
 	fmt.Println("  stats      Show statistics (pretty printed)")
 	fmt.Println("  today      Show today's activity")
 	fmt.Println("  projects   Show project breakdown")
+	fmt.Println("  project    Show everything about one project")
+	fmt.Println("  file       Show the history of a file, or --churn for hotspots")
+	fmt.Println("  goal       Manage daily and weekly goals (add, list, rm)")
+	fmt.Println("  hooks      Show, test or watch event hooks (list, test, check, watch)")
+	fmt.Println("  achievements  List earned and locked achievements")
+	fmt.Println("  calendar   Show a contribution calendar")
 	fmt.Println("  api        Output JSON for external tools (Neovim, etc)")
+	fmt.Println("  rpc        Serve JSON-RPC 2.0 over stdio for editor plugins")
+	fmt.Println("  serve      Serve stats over a local HTTP API")
+	fmt.Println("  dashboard  Open the web dashboard in your browser")
+	fmt.Println("  render     Render SVG cards and badges for READMEs")
+	fmt.Println("  metrics    Print Prometheus metrics or write a textfile")
+	fmt.Println("  report     Write a weekly or monthly report in Markdown or HTML")
 	fmt.Println("  optimize   Optimize database (run monthly)")
 	fmt.Println("  info       Show database information")
 	fmt.Println("  version    Show version information")
@@ -81,12 +147,34 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  codeme track --file main.go --lang go --lines 10")
+	fmt.Println("  codeme track --file main.go --category debugging")
+	fmt.Println("  codeme exec -- go test ./...")
+	fmt.Println("  eval \"$(codeme shell-init zsh)\"")
 	fmt.Println("  codeme stats")
 	fmt.Println("  codeme stats --today")
+	fmt.Println("  codeme stats --machine work-laptop")
+	fmt.Println("  codeme stats --calendar --language go")
+	fmt.Println("  codeme stats --period last_30_days,this_year")
+	fmt.Println("  codeme stats --project codeme --file '*_test.go'")
+	fmt.Println("  codeme stats --from 2026-01-01 --to 2026-03-31")
+	fmt.Println("  codeme calendar --year 2026 --project codeme")
 	fmt.Println("  codeme today")
+	fmt.Println("  codeme project codeme")
+	fmt.Println("  codeme file stats/api.go")
+	fmt.Println("  codeme file --churn --project codeme")
+	fmt.Println("  codeme goal add --time 2h --days weekdays")
+	fmt.Println("  codeme goal add --period weekly --lines 2000 --project codeme")
+	fmt.Println("  codeme achievements --next")
+	fmt.Println("  codeme hooks test goal.met")
 	fmt.Println("  codeme api              # JSON output for Neovim")
 	fmt.Println("  codeme api --compact    # Minified JSON")
 	fmt.Println("  codeme api --days=30    # Load last 30 days only")
+	fmt.Println("  codeme api --period this_quarter")
+	fmt.Println("  codeme serve --addr 127.0.0.1:7317 --read-only")
+	fmt.Println("  codeme dashboard --open=false")
+	fmt.Println("  codeme render calendar --weeks 52 --theme dark --out calendar.svg")
+	fmt.Println("  codeme metrics --textfile /var/lib/node_exporter/codeme.prom")
+	fmt.Println("  codeme report --period month --date 2026-09-01 --format html --out september.html")
 	fmt.Println("  codeme optimize         # Vacuum and analyze database")
 	fmt.Println()
 	fmt.Println("For more information, visit: https://github.com/tduyng/codeme")
@@ -99,6 +187,8 @@ func handleTrack(args []string) {
 	lang := fs.String("lang", "", "Language")
 	editor := fs.String("editor", "", "Editor name (e.g. neovim, vscode)")
 	lines := fs.Int("lines", 0, "Lines changed")
+	category := fs.String("category", "", "Activity category (coding, debugging, testing, reviewing, writing-docs, building, meeting or custom)")
+	machine := fs.String("machine", "", "Machine name (default: $CODEME_MACHINE or hostname)")
 
 	fs.Parse(args)
 
@@ -125,105 +215,1273 @@ func handleTrack(args []string) {
 	defer storage.Close()
 
 	tracker := core.NewTracker(storage)
+	tracker.SetCategorizer(stats.InferCategory)
+
+	activity := core.Activity{
+		File:     *file,
+		Language: *lang,
+		Editor:   *editor,
+		Lines:    *lines,
+		Category: *category,
+		Machine:  *machine,
+		IsWrite:  true,
+	}
+
+	if err := tracker.TrackActivity(activity); err != nil {
+		fmt.Printf("Error tracking: %v\n", err)
+		os.Exit(1)
+	}
+	checkHooksInBackground(storage)
+
+	fmt.Println("✓ Activity tracked successfully")
+}
+
+func handleExec(args []string) {
+	fs := flag.NewFlagSet("exec", flag.ExitOnError)
+	category := fs.String("category", "", "Activity category (default: inferred from the command)")
+	fs.Parse(args)
+
+	cmdArgs := fs.Args()
+	if len(cmdArgs) == 0 {
+		fmt.Println("Error: command required (codeme exec -- <cmd> [args...])")
+		os.Exit(1)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving working directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// The terminal delivers Ctrl-C to the child as well; stay alive so the
+	// interrupted run still gets recorded. Catching the signal, unlike
+	// ignoring it, leaves the child's default handling in place.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		for range interrupts {
+		}
+	}()
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start)
+
+	exitCode := 0
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "codeme: %v\n", err)
+			os.Exit(127)
+		}
+		exitCode = exitErr.ExitCode()
+		if exitCode < 0 {
+			exitCode = 1
+		}
+	}
+
+	if *category == "" {
+		*category = stats.InferCommandCategory(cmdArgs[0], cmdArgs[1:])
+	}
+	if *category == "" {
+		*category = core.CategoryRunning
+	}
+
+	language := stats.CommandLanguage(cmdArgs[0])
+	if language == "" {
+		language = "shell"
+	}
+
+	if err := trackCommand(core.Activity{
+		Timestamp: start,
+		Duration:  elapsed.Seconds(),
+		Language:  language,
+		Project:   core.NewDetector().DetectProjectDir(cwd),
+		Editor:    "terminal",
+		Category:  *category,
+		Command:   stats.CommandName(cmdArgs[0]),
+		ExitCode:  exitCode,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "codeme: failed to track command: %v\n", err)
+	}
+
+	os.Exit(exitCode)
+}
+
+func trackCommand(activity core.Activity) error {
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		return err
+	}
+
+	storage, err := core.NewSQLiteStorage(dbPath)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	if err := core.NewTracker(storage).TrackActivity(activity); err != nil {
+		return err
+	}
+	checkHooksInBackground(storage)
+	return nil
+}
+
+func handleShellInit(args []string) {
+	if len(args) == 0 {
+		fmt.Printf("Error: shell required (%s)\n", strings.Join(shell.Supported, ", "))
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("shell-init", flag.ExitOnError)
+	withArgs := fs.Bool("with-args", false, "Record full command lines instead of program names only")
+	fs.Parse(args[1:])
+
+	binary, err := os.Executable()
+	if err != nil {
+		binary = "codeme"
+	}
+
+	script, err := shell.Init(args[0], binary, *withArgs)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(script)
+}
+
+// handleShellTrack is called by the shell-init hooks after every command.
+func handleShellTrack(args []string) {
+	fs := flag.NewFlagSet("shell-track", flag.ExitOnError)
+	cwd := fs.String("cwd", "", "Working directory of the command")
+	duration := fs.Float64("duration", 0, "Run time in seconds")
+	exitCode := fs.Int("exit", 0, "Exit status")
+	withArgs := fs.Bool("args", false, "Record the full command line")
+	fs.Parse(args)
+
+	line := strings.Join(fs.Args(), " ")
+	program, cmdArgs := shell.ParseCommand(line)
+	if program == "" || program == "codeme" {
+		return
+	}
+
+	if *cwd == "" {
+		*cwd, _ = os.Getwd()
+	}
+
+	category := stats.InferCommandCategory(program, cmdArgs)
+	if category == "" {
+		category = core.CategoryTerminal
+	}
+
+	language := stats.CommandLanguage(program)
+	if language == "" {
+		language = "shell"
+	}
+
+	command := stats.CommandName(program)
+	if *withArgs {
+		command = strings.Join(strings.Fields(line), " ")
+	}
+
+	// Short and full-screen commands are plain heartbeats; only commands
+	// that ran for a while carry their own duration.
+	seconds := *duration
+	if seconds < 1 || shell.IsInteractive(program) {
+		seconds = 0
+	}
+
+	now := time.Now()
+	err := trackCommand(core.Activity{
+		Timestamp: now.Add(-time.Duration(seconds * float64(time.Second))),
+		Duration:  seconds,
+		Language:  language,
+		Project:   core.NewDetector().DetectProjectDir(*cwd),
+		Editor:    "terminal",
+		Category:  category,
+		Command:   command,
+		ExitCode:  *exitCode,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "codeme: failed to track command: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func handleStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	todayOnly := fs.Bool("today", false, "Show only today's stats")
+	calendar := fs.Bool("calendar", false, "Show a contribution calendar of the last 52 weeks")
+	color := fs.String("color", "auto", "Calendar colors (auto, truecolor, 256, ascii)")
+	filter := filterFlags(fs)
+	period := fs.String("period", "", "Show these periods, comma-separated ("+strings.Join(stats.NamedPeriods, ", ")+")")
+	from := fs.String("from", "", "Show the period starting on this day (YYYY-MM-DD)")
+	to := fs.String("to", "", "End of the --from period, included (default: today)")
+	fs.Parse(args)
+
+	colorMode, err := render.ParseColorMode(*color, os.Stdout)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	periods, err := parsePeriods(*period, *from, *to)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.OpenReadOnlyStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	calc := newCalculator()
+	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{
+		LoadRecentDays: LOOKBACK_DAYS,
+		HeatmapWeeks:   52,
+		Filter:         *filter,
+		Periods:        periods,
+	})
+	if err != nil {
+		fmt.Printf("Error calculating stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case len(apiStats.Periods) > 0:
+		for _, p := range apiStats.Periods {
+			printPeriodStats(p)
+		}
+	case *todayOnly:
+		printTodayStats(apiStats)
+	default:
+		printAllStats(apiStats)
+	}
+
+	if *calendar {
+		// WeeklyHeatmap starts at the first week with activity; pad it so the
+		// calendar always spans the same 52 weeks.
+		printCalendar("Last 52 Weeks", render.PadWeeks(apiStats.WeeklyHeatmap, 52), colorMode)
+	}
+}
+
+func handleCalendar(args []string) {
+	fs := flag.NewFlagSet("calendar", flag.ExitOnError)
+	year := fs.Int("year", 0, "Show a calendar year instead of the last 52 weeks")
+	color := fs.String("color", "auto", "Colors (auto, truecolor, 256, ascii)")
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	colorMode, err := render.ParseColorMode(*color, os.Stdout)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.OpenReadOnlyStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	filtered := storage.WithFilter(*filter)
+
+	to := time.Now()
+	from := util.StartOfWeek(to, time.Local).AddDate(0, 0, -51*7)
+	title := "Last 52 Weeks"
+	if *year != 0 {
+		from = time.Date(*year, time.January, 1, 0, 0, 0, 0, time.Local)
+		to = time.Date(*year, time.December, 31, 0, 0, 0, 0, time.Local)
+		title = strconv.Itoa(*year)
+	}
+
+	daily, err := filtered.GetDailySummaries(from, to)
+	if err != nil {
+		fmt.Printf("Error loading daily activity: %v\n", err)
+		os.Exit(1)
+	}
+
+	printCalendar(title, stats.CalendarDays(daily, from, to, time.Local), colorMode)
+}
+
+func printCalendar(title string, days []stats.HeatmapDay, mode render.ColorMode) {
+	var total float64
+	activeDays := 0
+	for _, d := range days {
+		if d.Level >= 0 && d.Time > 0 {
+			total += float64(d.Time)
+			activeDays++
+		}
+	}
+
+	fmt.Printf("\n  📅 %s\n", title)
+	fmt.Printf("  ─────────────────────────────────\n")
+	dayWord := "days"
+	if activeDays == 1 {
+		dayWord = "day"
+	}
+	fmt.Printf("  %s on %d %s\n\n", formatDuration(total), activeDays, dayWord)
+
+	if err := render.TerminalCalendar(os.Stdout, days, mode, render.Themes["dark"]); err != nil {
+		fmt.Printf("Error rendering calendar: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println()
+}
+
+func handleToday(args []string) {
+	fs := flag.NewFlagSet("today", flag.ExitOnError)
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.OpenReadOnlyStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	calc := newCalculator()
+	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{
+		LoadRecentDays: 2,
+		Filter:         *filter,
+	})
+	if err != nil {
+		fmt.Printf("Error calculating stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	printTodayStats(apiStats)
+}
+
+func handleProjects() {
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.OpenReadOnlyStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	calc := newCalculator()
+	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{
+		LoadRecentDays: 90,
+	})
+	if err != nil {
+		fmt.Printf("Error calculating stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	printProjectStats(apiStats)
+}
+
+func handleProject(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Println("Error: project name required (see codeme projects)")
+		os.Exit(1)
+	}
+	name := args[0]
+
+	fs := flag.NewFlagSet("project", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Output the project detail as JSON")
+	filter := filterFlags(fs)
+	fs.Parse(args[1:])
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.OpenReadOnlyStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	calc := newCalculator()
+	detail, err := calc.CalculateProject(storage, name, stats.APIOptions{Filter: *filter})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(detail); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printProjectDetail(detail)
+}
+
+func handleFile(args []string) {
+	var path string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("file", flag.ExitOnError)
+	churn := fs.Bool("churn", false, "List the most revisited files instead of one file's history")
+	days := fs.Int("days", 0, "With --churn, only count the last N days (default: all history)")
+	limit := fs.Int("limit", 15, "With --churn, files shown")
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	if path == "" && !*churn {
+		fmt.Println("Error: file path or --churn required")
+		os.Exit(1)
+	}
+	// Files on disk are looked up by their absolute path; anything else is
+	// matched as a path suffix.
+	if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+		}
+	}
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.OpenReadOnlyStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	calc := newCalculator()
+	if *churn {
+		files, err := calc.CalculateChurn(storage, stats.APIOptions{LoadRecentDays: *days, Filter: *filter}, *limit)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		printChurn(files)
+		return
+	}
+
+	history, err := calc.CalculateFile(storage, path, stats.APIOptions{Filter: *filter})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	printFileHistory(history)
+}
+
+func handleGoal(args []string) {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.NewSQLiteStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	switch sub {
+	case "add":
+		fs := flag.NewFlagSet("goal add", flag.ExitOnError)
+		period := fs.String("period", core.GoalDaily, "Goal period (daily, weekly)")
+		timeTarget := fs.Duration("time", 0, "Time target, e.g. 2h or 90m")
+		linesTarget := fs.Int("lines", 0, "Lines target")
+		project := fs.String("project", "", "Only count activity in this project")
+		language := fs.String("language", "", "Only count activity in this language")
+		days := fs.String("days", "", "Only count these weekdays, e.g. mon,wed,fri or weekdays")
+		fs.Parse(args)
+
+		if (*timeTarget > 0) == (*linesTarget > 0) {
+			fmt.Println("Error: exactly one of --time or --lines is required")
+			os.Exit(1)
+		}
+		goal := core.Goal{Period: *period, Project: *project, Language: *language}
+		if *timeTarget > 0 {
+			goal.Metric, goal.Target = core.GoalTime, timeTarget.Seconds()
+		} else {
+			goal.Metric, goal.Target = core.GoalLines, float64(*linesTarget)
+		}
+		if goal.Weekdays, err = core.ParseWeekdays(*days); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		goal, err = storage.AddGoal(goal)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Goal %d added: %s\n", goal.ID, describeGoal(goal))
+
+	case "list", "ls":
+		goals := newCalculator().EvaluateGoals(storage, core.ActivityFilter{}, time.Now())
+		if len(goals) == 0 {
+			fmt.Println("No goals yet. Add one with: codeme goal add --time 2h")
+			return
+		}
+		fmt.Println("\n  🎯 Goals")
+		fmt.Printf("  ─────────────────────────────────\n")
+		for _, g := range goals {
+			printGoalStatus(g)
+			fmt.Printf("       streak %d (best %d), met %d of %d (%.0f%%)  %s\n",
+				g.CurrentStreak, g.LongestStreak, g.PeriodsMet, g.PeriodsTotal, g.SuccessRate, goalHistory(g.Recent))
+		}
+		fmt.Println()
+
+	case "rm", "remove":
+		if len(args) != 1 {
+			fmt.Println("Usage: codeme goal rm <id>")
+			os.Exit(1)
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Printf("Error: invalid goal id: %s\n", args[0])
+			os.Exit(1)
+		}
+		if err := storage.DeleteGoal(id); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Goal %d removed\n", id)
+
+	default:
+		fmt.Printf("Unknown goal command: %s (want add, list or rm)\n", sub)
+		os.Exit(1)
+	}
+}
+
+func handleHooks(args []string) {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+
+	cfgPath, err := config.DefaultPath()
+	if err != nil {
+		fmt.Printf("Error resolving config path: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if sub == "list" || sub == "ls" {
+		fmt.Printf("Config: %s\n\n", cfgPath)
+		for _, event := range hooks.Events {
+			commands := cfg.Hooks.Commands[event]
+			if len(commands) == 0 {
+				fmt.Printf("  %-22s -\n", event)
+			}
+			for _, command := range commands {
+				fmt.Printf("  %-22s %s\n", event, command)
+			}
+		}
+		return
+	}
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.NewSQLiteStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	runner := hooks.New(cfg.Hooks, storage, newCalculator())
+
+	switch sub {
+	case "check":
+		fired, err := runner.Check(time.Now())
+		for _, e := range fired {
+			fmt.Printf("✓ %s: %s\n", e.Name, e.Message)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(fired) == 0 {
+			fmt.Println("No new events")
+		}
+
+	case "test":
+		if len(args) != 1 || !slices.Contains(hooks.Events, args[0]) {
+			fmt.Printf("Usage: codeme hooks test <event> (%s)\n", strings.Join(hooks.Events, ", "))
+			os.Exit(1)
+		}
+		e := hooks.Event{Name: args[0], Key: "test", Time: time.Now(), Message: "codeme hook test"}
+		if err := runner.Fire(e); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Fired %s\n", e.Name)
+
+	case "watch":
+		if !runner.Enabled() {
+			fmt.Printf("No hooks configured in %s\n", cfgPath)
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Println("Watching for events (Ctrl+C to stop)")
+		runner.Watch(ctx, time.Minute, hookError)
+
+	default:
+		fmt.Printf("Unknown hooks command: %s (want list, check, test or watch)\n", sub)
+		os.Exit(1)
+	}
+}
+
+// loadHooks returns a runner for the hooks of the config file. Without
+// hooks its checks still save achievement unlocks.
+func loadHooks(storage *core.SQLiteStorage) *hooks.Runner {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		hookError(err)
+	}
+	return hooks.New(cfg.Hooks, storage, newCalculator())
+}
+
+// newCalculator returns a calculator with the achievements and streaks of
+// the config file. Broken parts of the config are reported and left out.
+func newCalculator() *stats.Calculator {
+	calc := stats.NewCalculator(time.Local)
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "codeme: %v\n", err)
+		return calc
+	}
+	if err := calc.SetAchievements(cfg.Achievements); err != nil {
+		fmt.Fprintf(os.Stderr, "codeme: %v\n", err)
+	}
+	if err := calc.SetStreakRules(cfg.Streaks); err != nil {
+		fmt.Fprintf(os.Stderr, "codeme: %v\n", err)
+	}
+	return calc
+}
+
+// checkHooksInBackground starts `codeme hooks check` without waiting for
+// it, so that tracking stays fast. The check saves achievement unlocks and
+// fires hooks. Runs are at most hooks.MinInterval apart across processes.
+func checkHooksInBackground(storage *core.SQLiteStorage) {
+	due, err := storage.ClaimRun(hooks.CheckJob, time.Now(), hooks.MinInterval)
+	if err != nil {
+		hookError(err)
+		return
+	}
+	if !due {
+		return
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		hookError(err)
+		return
+	}
+	cmd := exec.Command(exe, "hooks", "check")
+	if err := cmd.Start(); err != nil {
+		hookError(err)
+		return
+	}
+	cmd.Process.Release()
+}
+
+func hookError(err error) {
+	fmt.Fprintf(os.Stderr, "codeme: %v\n", err)
+}
+
+func handleAchievements(args []string) {
+	fs := flag.NewFlagSet("achievements", flag.ExitOnError)
+	next := fs.Bool("next", false, "Show the locked achievements closest to unlocking")
+	limit := fs.Int("limit", 5, "Number of achievements to show with --next")
+	fs.Parse(args)
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Printf("Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Writable, so that new unlocks are recorded.
+	storage, err := core.NewSQLiteStorage(dbPath)
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	calc := newCalculator()
+	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{})
+	if err != nil {
+		fmt.Printf("Error calculating stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *next {
+		printNextAchievements(apiStats.Achievements, *limit)
+		return
+	}
+
+	var earned, locked []stats.Achievement
+	for _, a := range apiStats.Achievements {
+		if a.Unlocked {
+			earned = append(earned, a)
+		} else {
+			locked = append(locked, a)
+		}
+	}
+	// Unlocks not saved yet have no time; they come last.
+	slices.SortStableFunc(earned, func(a, b stats.Achievement) int {
+		switch {
+		case a.UnlockedAt == nil && b.UnlockedAt == nil:
+			return 0
+		case a.UnlockedAt == nil:
+			return 1
+		case b.UnlockedAt == nil:
+			return -1
+		}
+		return a.UnlockedAt.Compare(*b.UnlockedAt)
+	})
+
+	fmt.Printf("\n  🏆 Achievements (%d/%d)\n", len(earned), len(apiStats.Achievements))
+	fmt.Printf("  ─────────────────────────────────\n")
+	for _, a := range earned {
+		mark := ""
+		if a.IsNew {
+			mark = "  🆕"
+		}
+		date := "          "
+		if a.UnlockedAt != nil {
+			date = a.UnlockedAt.Local().Format("2006-01-02")
+		}
+		fmt.Printf("  %s  %s %-24s %s%s\n", date, a.Icon, a.Name, a.Context, mark)
+	}
+
+	if len(locked) > 0 {
+		fmt.Println("\n  🔒 Locked")
+		for _, a := range locked {
+			fmt.Printf("  %-24s %s\n", a.Name, a.Description)
+		}
+	}
+	fmt.Println()
+}
+
+// printNextAchievements lists the locked achievements with the most
+// progress, with when they should unlock at the recent pace.
+func printNextAchievements(achievements []stats.Achievement, limit int) {
+	var next []stats.Achievement
+	for _, a := range achievements {
+		if !a.Unlocked && a.Target > 0 {
+			next = append(next, a)
+		}
+	}
+	slices.SortStableFunc(next, func(a, b stats.Achievement) int {
+		return cmp.Compare(b.Progress, a.Progress)
+	})
+	next = next[:min(limit, len(next))]
+
+	fmt.Printf("\n  🎯 Next Achievements\n")
+	fmt.Printf("  ─────────────────────────────────\n")
+	if len(next) == 0 {
+		fmt.Println("  Nothing left to unlock")
+	}
+	for _, a := range next {
+		value := func(v float64) string {
+			if a.Unit == "seconds" {
+				return formatDuration(v)
+			}
+			return fmt.Sprintf("%.0f", v)
+		}
+		eta := ""
+		if a.EstimatedAt != nil {
+			eta = "  ~" + a.EstimatedAt.Local().Format("2006-01-02")
+		}
+		fmt.Printf("  %s %-24s %s %3.0f%%  %s / %s%s\n",
+			a.Icon, a.Name, progressBar(a.Progress, 10), a.Progress, value(a.Current), value(a.Target), eta)
+	}
+	fmt.Println()
+}
+
+// progressBar draws percent, from 0 to 100, in width blocks.
+func progressBar(percent float64, width int) string {
+	filled := max(0, min(width, int(percent/100*float64(width))))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func handleAPI(args []string) {
+	fs := flag.NewFlagSet("api", flag.ExitOnError)
+	compact := fs.Bool("compact", false, "Output compact JSON (no indentation)")
+	days := fs.Int("days", LOOKBACK_DAYS, "Load activities from last N days (default: 365)")
+	filter := filterFlags(fs)
+	period := fs.String("period", "", "Add these periods to \"periods\", comma-separated ("+strings.Join(stats.NamedPeriods, ", ")+")")
+	from := fs.String("from", "", "Add the period starting on this day (YYYY-MM-DD)")
+	to := fs.String("to", "", "End of the --from period, included (default: today)")
+	fs.Parse(args)
+
+	periods, err := parsePeriods(*period, *from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.OpenReadOnlyStorage(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	calc := newCalculator()
+	opts := stats.APIOptions{
+		LoadRecentDays: *days,
+		Filter:         *filter,
+		Periods:        periods,
+	}
+
+	result, err := calc.CalculateAPI(storage, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error calculating stats: %v\n", err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	if !*compact {
+		encoder.SetIndent("", "  ")
+	}
 
-	if err := tracker.TrackFileActivity(*file, *lang, *editor, *lines, true); err != nil {
-		fmt.Printf("Error tracking: %v\n", err)
+	if err := encoder.Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
 		os.Exit(1)
 	}
+}
 
-	fmt.Println("✓ Activity tracked successfully")
+// filterFlags registers the flags that narrow stats down to a subset of
+// activities.
+func filterFlags(fs *flag.FlagSet) *core.ActivityFilter {
+	f := &core.ActivityFilter{}
+	fs.StringVar(&f.Machine, "machine", "", "Only include activity from this machine")
+	fs.StringVar(&f.Project, "project", "", "Only include activity in this project")
+	fs.StringVar(&f.Language, "language", "", "Only include activity in this language")
+	fs.StringVar(&f.Editor, "editor", "", "Only include activity in this editor")
+	fs.StringVar(&f.Branch, "branch", "", "Only include activity on this git branch")
+	fs.StringVar(&f.File, "file", "", "Only include files matching this glob, e.g. '*_test.go'")
+	return f
 }
 
-func handleStats(args []string) {
-	fs := flag.NewFlagSet("stats", flag.ExitOnError)
-	todayOnly := fs.Bool("today", false, "Show only today's stats")
+// namedPeriod requests name when it is one of the named periods, for
+// commands that pick a single period.
+func namedPeriod(name string) []stats.PeriodRequest {
+	if slices.Contains(stats.NamedPeriods, name) {
+		return []stats.PeriodRequest{{Name: name}}
+	}
+	return nil
+}
+
+// parsePeriods turns --period, --from and --to into period requests.
+func parsePeriods(names, from, to string) ([]stats.PeriodRequest, error) {
+	var periods []stats.PeriodRequest
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			periods = append(periods, stats.PeriodRequest{Name: name})
+		}
+	}
+
+	if from == "" {
+		if to != "" {
+			return nil, fmt.Errorf("--to needs --from")
+		}
+		return periods, nil
+	}
+	custom := stats.PeriodRequest{Name: stats.CustomPeriod}
+	var err error
+	if custom.From, err = time.ParseInLocation("2006-01-02", from, time.Local); err != nil {
+		return nil, fmt.Errorf("invalid --from %q (want YYYY-MM-DD)", from)
+	}
+	if to != "" {
+		if custom.To, err = time.ParseInLocation("2006-01-02", to, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid --to %q (want YYYY-MM-DD)", to)
+		}
+	}
+	return append(periods, custom), nil
+}
+
+func handleRPC() {
+	dbPath, err := core.GetDefaultDBPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving DB path: %v\n", err)
+		os.Exit(1)
+	}
+
+	storage, err := core.NewSQLiteStorage(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer storage.Close()
+
+	server := rpc.NewServer(storage, newCalculator(), version, dbPath)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := loadHooks(storage)
+	go runner.Watch(ctx, time.Minute, hookError)
+	server.OnTrack(func(core.Activity) { runner.Trigger() })
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error serving RPC: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func handleServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", server.DefaultAddr, "Address to listen on")
+	token := fs.String("token", os.Getenv("CODEME_TOKEN"), "Require this bearer token (default: $CODEME_TOKEN)")
+	readOnly := fs.Bool("read-only", false, "Serve stats only; reject heartbeats")
+	withDashboard := fs.Bool("dashboard", false, "Also serve the web dashboard at /")
+	fs.Parse(args)
+
+	runServer(*addr, server.Config{
+		Token:     *token,
+		ReadOnly:  *readOnly,
+		Dashboard: *withDashboard,
+	}, false)
+}
+
+func handleDashboard(args []string) {
+	fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	addr := fs.String("addr", server.DefaultAddr, "Address to listen on")
+	token := fs.String("token", os.Getenv("CODEME_TOKEN"), "Require this token, passed as ?api_key= (default: $CODEME_TOKEN)")
+	open := fs.Bool("open", true, "Open the dashboard in the browser")
 	fs.Parse(args)
 
+	runServer(*addr, server.Config{
+		Token:     *token,
+		ReadOnly:  true,
+		Dashboard: true,
+	}, *open)
+}
+
+func runServer(addr string, cfg server.Config, openBrowser bool) {
 	dbPath, err := core.GetDefaultDBPath()
 	if err != nil {
 		fmt.Printf("Error resolving DB path: %v\n", err)
 		os.Exit(1)
 	}
 
-	storage, err := core.OpenReadOnlyStorage(dbPath)
+	var storage *core.SQLiteStorage
+	if cfg.ReadOnly {
+		storage, err = core.OpenReadOnlyStorage(dbPath)
+	} else {
+		storage, err = core.NewSQLiteStorage(dbPath)
+	}
 	if err != nil {
 		fmt.Printf("Error opening database: %v\n", err)
 		os.Exit(1)
 	}
 	defer storage.Close()
 
-	calc := stats.NewCalculator(time.Local)
-	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{
-		LoadRecentDays: LOOKBACK_DAYS,
-	})
+	if host, _, err := net.SplitHostPort(addr); err == nil && cfg.Token == "" {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			fmt.Fprintf(os.Stderr, "Warning: serving on %s without --token exposes your activity to the network\n", addr)
+		}
+	}
+
+	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		fmt.Printf("Error calculating stats: %v\n", err)
+		fmt.Printf("Error listening on %s: %v\n", addr, err)
 		os.Exit(1)
 	}
 
-	if *todayOnly {
-		printTodayStats(apiStats)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if !cfg.ReadOnly {
+		runner := loadHooks(storage)
+		go runner.Watch(ctx, time.Minute, hookError)
+		cfg.OnTrack = func(core.Activity) { runner.Trigger() }
+	}
+
+	srv := &http.Server{
+		Handler:           server.New(storage, newCalculator(), cfg),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	url := "http://" + listener.Addr().String()
+	if cfg.Dashboard {
+		dashboardURL := url + "/"
+		if cfg.Token != "" {
+			dashboardURL += "?api_key=" + cfg.Token
+		}
+		fmt.Printf("Serving codeme dashboard on %s\n", dashboardURL)
+		if openBrowser {
+			if err := openURL(dashboardURL); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not open browser: %v\n", err)
+			}
+		}
 	} else {
-		printAllStats(apiStats)
+		fmt.Printf("Serving codeme API on %s\n", url)
 	}
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Printf("Error serving: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
 }
 
-func handleToday() {
+func handleRender(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Printf("Error: image required (%s or all)\n", strings.Join(render.Kinds, ", "))
+		os.Exit(1)
+	}
+	kind := args[0]
+
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	out := fs.String("out", "", "Output file (default: stdout)")
+	dir := fs.String("dir", ".", "Output directory when rendering all images")
+	weeks := fs.Int("weeks", 52, "Weeks shown in the calendar")
+	theme := fs.String("theme", "light", "Theme ("+strings.Join(render.ThemeNames(), ", ")+")")
+	transparent := fs.Bool("transparent", false, "Leave out the card background")
+	hideProjects := fs.Bool("hide-projects", false, "Replace project names with Project 1, Project 2, ...")
+	period := fs.String("period", "", "Period for languages, projects and badge (today, this_week, this_month, all_time, ...)")
+	limit := fs.Int("limit", 8, "Rows shown in language and project charts")
+	machine := fs.String("machine", "", "Only include activity from this machine")
+	fs.Parse(args[1:])
+
+	opts := render.Options{Transparent: *transparent, HideProjects: *hideProjects, Limit: *limit}
+	var ok bool
+	if opts.Theme, ok = render.Themes[*theme]; !ok {
+		fmt.Printf("Error: unknown theme %q (%s)\n", *theme, strings.Join(render.ThemeNames(), ", "))
+		os.Exit(1)
+	}
+
 	dbPath, err := core.GetDefaultDBPath()
 	if err != nil {
-		fmt.Printf("Error resolving DB path: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error resolving DB path: %v\n", err)
 		os.Exit(1)
 	}
 
 	storage, err := core.OpenReadOnlyStorage(dbPath)
 	if err != nil {
-		fmt.Printf("Error opening database: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
 		os.Exit(1)
 	}
 	defer storage.Close()
 
-	calc := stats.NewCalculator(time.Local)
+	calc := newCalculator()
 	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{
-		LoadRecentDays: 2,
+		LoadRecentDays: max(LOOKBACK_DAYS, *weeks*7+7),
+		HeatmapWeeks:   *weeks,
+		Filter:         core.ActivityFilter{Machine: *machine},
+		Periods:        namedPeriod(*period),
 	})
 	if err != nil {
-		fmt.Printf("Error calculating stats: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error calculating stats: %v\n", err)
 		os.Exit(1)
 	}
 
-	printTodayStats(apiStats)
+	if kind == "all" {
+		for _, k := range render.Kinds {
+			path := filepath.Join(*dir, k+".svg")
+			if err := renderToFile(path, k, apiStats, *period, *weeks, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error rendering %s: %v\n", k, err)
+				os.Exit(1)
+			}
+			fmt.Printf("✓ %s\n", path)
+		}
+		return
+	}
+
+	if *out == "" {
+		if err := render.Stats(os.Stdout, kind, apiStats, *period, *weeks, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering %s: %v\n", kind, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := renderToFile(*out, kind, apiStats, *period, *weeks, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering %s: %v\n", kind, err)
+		os.Exit(1)
+	}
 }
 
-func handleProjects() {
+func renderToFile(path, kind string, apiStats *stats.APIStats, period string, weeks int, opts render.Options) error {
+	var buf bytes.Buffer
+	if err := render.Stats(&buf, kind, apiStats, period, weeks, opts); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func handleReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	period := fs.String("period", "week", "Report period (week, month)")
+	date := fs.String("date", "", "Any day in the period to report on, as YYYY-MM-DD (default: today)")
+	format := fs.String("format", "md", "Output format (md, html)")
+	out := fs.String("out", "", "Output file (default: stdout)")
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	write := report.Markdown
+	switch *format {
+	case "md", "markdown":
+	case "html":
+		write = report.HTML
+	default:
+		fmt.Printf("Error: unknown format %q (md or html)\n", *format)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	day := now
+	if *date != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", *date, time.Local); err != nil {
+			fmt.Printf("Error: invalid date %q (want YYYY-MM-DD)\n", *date)
+			os.Exit(1)
+		}
+	}
+	end, err := report.PeriodEnd(*period, day, now, time.Local)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	dbPath, err := core.GetDefaultDBPath()
 	if err != nil {
-		fmt.Printf("Error resolving DB path: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error resolving DB path: %v\n", err)
 		os.Exit(1)
 	}
 
 	storage, err := core.OpenReadOnlyStorage(dbPath)
 	if err != nil {
-		fmt.Printf("Error opening database: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
 		os.Exit(1)
 	}
 	defer storage.Close()
 
-	calc := stats.NewCalculator(time.Local)
+	calc := newCalculator()
 	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{
-		LoadRecentDays: 90,
+		LoadRecentDays: LOOKBACK_DAYS,
+		Filter:         *filter,
+		Now:            end,
 	})
 	if err != nil {
-		fmt.Printf("Error calculating stats: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error calculating stats: %v\n", err)
 		os.Exit(1)
 	}
 
-	printProjectStats(apiStats)
+	r, err := report.Build(apiStats, *period, time.Local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building report: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		if err := write(os.Stdout, r); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, r); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ %s\n", *out)
 }
 
-func handleAPI(args []string) {
-	fs := flag.NewFlagSet("api", flag.ExitOnError)
-	compact := fs.Bool("compact", false, "Output compact JSON (no indentation)")
-	days := fs.Int("days", LOOKBACK_DAYS, "Load activities from last N days (default: 365)")
+func handleMetrics(args []string) {
+	fs := flag.NewFlagSet("metrics", flag.ExitOnError)
+	textfile := fs.String("textfile", "", "Write to this file for node_exporter's textfile collector instead of stdout")
 	fs.Parse(args)
 
 	dbPath, err := core.GetDefaultDBPath()
@@ -239,22 +1497,19 @@ func handleAPI(args []string) {
 	}
 	defer storage.Close()
 
-	calc := stats.NewCalculator(time.Local)
-	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{
-		LoadRecentDays: *days,
-	})
+	collected, err := metrics.Collect(storage, newCalculator())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error calculating stats: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error collecting metrics: %v\n", err)
 		os.Exit(1)
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
-	if !*compact {
-		encoder.SetIndent("", "  ")
+	if *textfile != "" {
+		err = metrics.WriteTextfile(*textfile, collected)
+	} else {
+		err = metrics.Write(os.Stdout, collected, metrics.Prometheus)
 	}
-
-	if err := encoder.Encode(apiStats); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
 		os.Exit(1)
 	}
 }
@@ -317,6 +1572,10 @@ func handleRebuildSummaries() {
 	fmt.Println("  • daily_summary")
 	fmt.Println("  • daily_language_summary")
 	fmt.Println("  • daily_project_summary")
+	fmt.Println("  • daily_project_language_summary")
+	fmt.Println("  • daily_editor_summary")
+	fmt.Println("  • daily_category_summary")
+	fmt.Println("  • daily_machine_summary")
 }
 
 func handleInfo() {
@@ -397,7 +1656,19 @@ func printTodayStats(s *stats.APIStats) {
 		}
 	}
 
-	if today.DailyGoals.TimeGoal > 0 {
+	if len(today.Categories) > 0 {
+		fmt.Println("\n  Categories:")
+		for _, cat := range today.Categories {
+			fmt.Printf("    %-15s %s (%.1f%%)\n", cat.Name, formatDuration(cat.Time), cat.PercentTotal)
+		}
+	}
+
+	if len(s.Goals) > 0 {
+		fmt.Println("\n  Goals:")
+		for _, g := range s.Goals {
+			printGoalStatus(g)
+		}
+	} else if today.DailyGoals.TimeGoal > 0 {
 		fmt.Println("\n  Daily Goals:")
 		fmt.Printf("    Time:  %.1f%% of %s\n",
 			today.DailyGoals.TimeProgress,
@@ -417,6 +1688,37 @@ func printTodayStats(s *stats.APIStats) {
 	fmt.Println()
 }
 
+func printPeriodStats(p stats.APIPeriodStats) {
+	title := strings.ReplaceAll(p.Period, "_", " ")
+	title = strings.ToUpper(title[:1]) + title[1:]
+	last := p.EndDate.Add(-time.Nanosecond)
+
+	fmt.Printf("\n  📅 %s: %s – %s\n", title, p.StartDate.Format("Jan 2, 2006"), last.Format("Jan 2, 2006"))
+	fmt.Printf("  ─────────────────────────────────\n")
+	fmt.Printf("  Time:     %s\n", formatDuration(p.TotalTime))
+	fmt.Printf("  Lines:    %d\n", p.TotalLines)
+	fmt.Printf("  Files:    %d\n", p.TotalFiles)
+	if p.SessionCount > 0 {
+		fmt.Printf("  Sessions: %d (Focus: %d%%)\n", p.SessionCount, p.FocusScore)
+		fmt.Printf("  Peak:     %02d:00\n", p.PeakHour)
+	}
+
+	if len(p.Languages) > 0 {
+		fmt.Println("\n  Languages:")
+		for _, lang := range p.Languages[:min(5, len(p.Languages))] {
+			fmt.Printf("    %-15s %s (%.1f%%)\n", lang.Name, formatDuration(lang.Time), lang.PercentTotal)
+		}
+	}
+
+	if len(p.Projects) > 0 {
+		fmt.Println("\n  Projects:")
+		for _, proj := range p.Projects[:min(5, len(p.Projects))] {
+			fmt.Printf("    %-15s %s (%.1f%%)\n", proj.Name, formatDuration(proj.Time), proj.PercentTotal)
+		}
+	}
+	fmt.Println()
+}
+
 func printAllStats(s *stats.APIStats) {
 	fmt.Println("\n╭────────────────────────────────────╮")
 	fmt.Println("│       CodeMe Statistics            │")
@@ -424,18 +1726,45 @@ func printAllStats(s *stats.APIStats) {
 
 	fmt.Printf("\n  📊 Overview\n")
 	fmt.Printf("  ─────────────────────────────────\n")
-	fmt.Printf("  Today:      %s (%d lines)\n", formatDuration(s.Today.TotalTime), s.Today.TotalLines)
-	fmt.Printf("  This Week:  %s (%d lines)\n", formatDuration(s.ThisWeek.TotalTime), s.ThisWeek.TotalLines)
+	fmt.Printf("  Today:      %s (%d lines)%s\n", formatDuration(s.Today.TotalTime), s.Today.TotalLines,
+		trendSuffix(s.Trends.DayOverDay.Time, "yesterday"))
+	fmt.Printf("  This Week:  %s (%d lines)%s\n", formatDuration(s.ThisWeek.TotalTime), s.ThisWeek.TotalLines,
+		trendSuffix(s.Trends.WeekOverWeek.Time, "last week"))
 	fmt.Printf("  All Time:   %s (%d lines)\n", formatDuration(s.AllTime.TotalTime), s.AllTime.TotalLines)
 
-	if s.StreakInfo.Current > 0 {
+	if n := len(s.Trends.MovingAverages); n > 0 {
+		avg := s.Trends.MovingAverages[n-1]
+		fmt.Printf("\n  📈 Trends\n")
+		fmt.Printf("  ─────────────────────────────────\n")
+		fmt.Printf("  7-day avg:  %s/day (%.0f lines)\n", formatDuration(avg.Time7d), avg.Lines7d)
+		fmt.Printf("  30-day avg: %s/day (%.0f lines)\n", formatDuration(avg.Time30d), avg.Lines30d)
+		for _, l := range s.Trends.WeekOverWeek.Languages[:min(3, len(s.Trends.WeekOverWeek.Languages))] {
+			fmt.Printf("  %-15s %s%s\n", l.Name, formatDuration(l.Current), trendSuffix(l.Delta, "last week"))
+		}
+	}
+
+	if s.StreakInfo.Current > 0 || len(s.Streaks) > 0 {
 		fmt.Printf("\n  🔥 Streak\n")
 		fmt.Printf("  ─────────────────────────────────\n")
 		fmt.Printf("  Current: %d days\n", s.StreakInfo.Current)
 		fmt.Printf("  Longest: %d days\n", s.StreakInfo.Longest)
+		if best := s.Records.BestStreak; best.DayCount > 0 {
+			fmt.Printf("  Best:    %s to %s (%s)\n", best.StartDate, best.EndDate, formatDuration(best.TotalTime))
+		}
 		if s.StreakInfo.IsActive {
 			fmt.Printf("  ✓ Active today!\n")
 		}
+		for _, r := range s.Streaks {
+			unit := "days"
+			if r.Period == core.GoalWeekly {
+				unit = "weeks"
+			}
+			line := fmt.Sprintf("  %-20s %d %s (longest %d)", cmp.Or(r.Name, r.ID), r.Current, unit, r.Longest)
+			if r.FreezesLeft > 0 {
+				line += fmt.Sprintf(" · %d ❄️ left", r.FreezesLeft)
+			}
+			fmt.Println(line)
+		}
 	}
 
 	if len(s.AllTime.Languages) > 0 {
@@ -464,6 +1793,14 @@ func printAllStats(s *stats.APIStats) {
 		}
 	}
 
+	if len(s.AllTime.Machines) > 1 {
+		fmt.Printf("\n  💻 Machines (All Time)\n")
+		fmt.Printf("  ─────────────────────────────────\n")
+		for _, m := range s.AllTime.Machines {
+			fmt.Printf("  %-20s %s (%.1f%%)\n", m.Name, formatDuration(m.Time), m.PercentTotal)
+		}
+	}
+
 	unlockedCount := 0
 	for _, ach := range s.Achievements {
 		if ach.Unlocked {
@@ -479,7 +1816,11 @@ func printAllStats(s *stats.APIStats) {
 		shown := 0
 		for _, ach := range s.Achievements {
 			if ach.Unlocked && shown < 3 {
-				fmt.Printf("  %s %s\n", ach.Icon, ach.Name)
+				if ach.IsNew {
+					fmt.Printf("  %s %s 🆕\n", ach.Icon, ach.Name)
+				} else {
+					fmt.Printf("  %s %s\n", ach.Icon, ach.Name)
+				}
 				shown++
 			}
 		}
@@ -496,6 +1837,15 @@ func printAllStats(s *stats.APIStats) {
 	fmt.Println()
 }
 
+// trendSuffix formats a change for the end of a stats line, e.g.
+// "  ▲ 25% vs last week".
+func trendSuffix(d stats.Delta, than string) string {
+	if d.Current == 0 && d.Previous == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  %s vs %s", report.Change(d.Current, d.Previous), than)
+}
+
 func printProjectStats(s *stats.APIStats) {
 	fmt.Println("\n╭────────────────────────────────────╮")
 	fmt.Println("│           Projects                 │")
@@ -516,10 +1866,221 @@ func printProjectStats(s *stats.APIStats) {
 		fmt.Printf("     Lines:    %d\n", proj.Lines)
 		fmt.Printf("     Files:    %d\n", proj.Files)
 		fmt.Printf("     Language: %s\n", proj.MainLanguage)
+		if len(proj.Languages) > 1 {
+			var parts []string
+			for _, l := range proj.Languages[:min(4, len(proj.Languages))] {
+				parts = append(parts, fmt.Sprintf("%s %.0f%%", l.Name, l.PercentTotal))
+			}
+			fmt.Printf("     Mix:      %s\n", strings.Join(parts, ", "))
+		}
+
+		for _, cmd := range commandTotals(s.AllTime.Commands, proj.Name) {
+			fmt.Printf("     %-9s %s (%d runs, %.0f%% failed)\n",
+				cmd.Category+":", formatDuration(cmd.Time), cmd.Runs, cmd.FailureRate)
+		}
+	}
+	fmt.Println()
+}
+
+func printProjectDetail(p *stats.ProjectDetail) {
+	fmt.Printf("\n  📁 %s\n", p.Name)
+	fmt.Printf("  ─────────────────────────────────\n")
+	fmt.Printf("  Time:        %s (%d lines, %d files)\n", formatDuration(p.TotalTime), p.TotalLines, p.TotalFiles)
+	fmt.Printf("  First seen:  %s\n", p.FirstActivity.Format("Jan 2, 2006"))
+	fmt.Printf("  Last active: %s\n", p.LastActivity.Format("Jan 2, 2006 15:04"))
+	fmt.Printf("  Active days: %d\n", p.ActiveDays)
+	fmt.Printf("  Streak:      %d days (longest %d)\n", p.StreakInfo.Current, p.StreakInfo.Longest)
+
+	if len(p.Weekly) > 1 {
+		weekly := make([]float64, len(p.Weekly))
+		for i, w := range p.Weekly {
+			weekly[i] = w.Time
+		}
+		fmt.Printf("\n  Weekly (since %s):\n", p.Weekly[0].Start)
+		fmt.Printf("    %s  %s this week\n", sparkline(weekly), formatDuration(weekly[len(weekly)-1]))
+	}
+
+	if len(p.Languages) > 0 {
+		fmt.Println("\n  Languages:")
+		for _, l := range p.Languages[:min(5, len(p.Languages))] {
+			fmt.Printf("    %-15s %s (%.1f%%)\n", l.Name, formatDuration(l.Time), l.PercentTotal)
+		}
+	}
+
+	if len(p.Branches) > 0 {
+		fmt.Println("\n  Branches:")
+		for _, b := range p.Branches[:min(5, len(p.Branches))] {
+			fmt.Printf("    %-25s %s (%.1f%%)\n", b.Name, formatDuration(b.Time), b.PercentTotal)
+		}
+	}
+
+	if len(p.Files) > 0 {
+		fmt.Println("\n  Top Files:")
+		for _, f := range p.Files {
+			fmt.Printf("    %-35s %s\n", f.Name, formatDuration(f.Time))
+		}
+	}
+
+	if len(p.Sessions) > 0 {
+		fmt.Printf("\n  Recent Sessions (%d total):\n", p.SessionCount)
+		for _, sess := range p.Sessions[:min(5, len(p.Sessions))] {
+			fmt.Printf("    %s %s–%s  %s\n", sess.StartTime.Format("Mon Jan 2"),
+				sess.StartTime.Format("15:04"), sess.EndTime.Format("15:04"), formatDuration(sess.Duration))
+		}
+	}
+
+	if p.TotalTime > 0 {
+		hourly := make([]float64, 24)
+		for _, h := range p.HourlyActivity {
+			hourly[h.Hour] = h.Duration
+		}
+		fmt.Printf("\n  Hours of day (peak %02d:00):\n", p.PeakHour)
+		fmt.Printf("    %s\n", sparkline(hourly))
+		fmt.Printf("    0     6     12    18   23\n")
+	}
+	fmt.Println()
+}
+
+func printFileHistory(h *stats.FileHistory) {
+	fmt.Printf("\n  📄 %s\n", h.Path)
+	fmt.Printf("  ─────────────────────────────────\n")
+	if h.Project != "" {
+		fmt.Printf("  Project:      %s\n", h.Project)
+	}
+	fmt.Printf("  Time:         %s (%d lines, %d edits)\n", formatDuration(h.TotalTime), h.TotalLines, h.Edits)
+	fmt.Printf("  First edited: %s\n", h.FirstEdited.Format("Jan 2, 2006 15:04"))
+	fmt.Printf("  Last edited:  %s\n", h.LastEdited.Format("Jan 2, 2006 15:04"))
+	fmt.Printf("  Sessions:     %d\n", h.SessionCount)
+	fmt.Printf("  Days:         %d\n", len(h.Days))
+
+	if len(h.Branches) > 0 {
+		fmt.Println("\n  Branches:")
+		for _, b := range h.Branches {
+			fmt.Printf("    %-25s %s (%.1f%%)\n", b.Name, formatDuration(b.Time), b.PercentTotal)
+		}
+	}
+
+	fmt.Println("\n  History:")
+	for _, d := range h.Days {
+		fmt.Printf("    %s  %-8s %5d lines  %d edits\n", d.Date, formatDuration(d.Time), d.Lines, d.Edits)
+	}
+	fmt.Println()
+}
+
+func printChurn(files []stats.FileChurn) {
+	if len(files) == 0 {
+		fmt.Println("No files tracked yet")
+		return
+	}
+
+	fmt.Println("\n  🔥 Most Revisited Files")
+	fmt.Printf("  ─────────────────────────────────\n")
+	fmt.Printf("    %-40s %8s %5s %6s %8s\n", "File", "Sessions", "Days", "Edits", "Time")
+	for _, f := range files {
+		name := f.Name
+		if f.Project != "" && !strings.HasPrefix(name, "/") {
+			name = f.Project + ":" + name
+		}
+		fmt.Printf("    %-40s %8d %5d %6d %8s\n", name, f.Sessions, f.Days, f.Edits, formatDuration(f.Time))
 	}
 	fmt.Println()
 }
 
+func describeGoal(g core.Goal) string {
+	target := fmt.Sprintf("%d lines", int(g.Target))
+	if g.Metric == core.GoalTime {
+		target = formatDuration(g.Target)
+	}
+	desc := target + " " + g.Period
+	if g.Project != "" {
+		desc += " in " + g.Project
+	}
+	if g.Language != "" {
+		desc += " of " + g.Language
+	}
+	if len(g.Weekdays) > 0 {
+		days := make([]string, len(g.Weekdays))
+		for i, d := range g.Weekdays {
+			days[i] = d.String()[:3]
+		}
+		desc += " on " + strings.Join(days, ",")
+	}
+	return desc
+}
+
+func printGoalStatus(g stats.GoalStatus) {
+	mark := "·"
+	switch {
+	case g.Met:
+		mark = "✓"
+	case g.OnTrack:
+		mark = "→"
+	}
+	current := fmt.Sprintf("%d", int(g.Current))
+	if g.Metric == core.GoalTime {
+		current = formatDuration(g.Current)
+	}
+	fmt.Printf("    %s [%d] %-40s %s (%.0f%%)\n", mark, g.ID, describeGoal(g.Goal), current, g.Progress)
+}
+
+// goalHistory draws recent goal results, oldest first.
+func goalHistory(results []stats.GoalResult) string {
+	var b strings.Builder
+	for _, r := range results {
+		if r.Met {
+			b.WriteRune('●')
+		} else {
+			b.WriteRune('○')
+		}
+	}
+	return b.String()
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one block per value, scaled to the largest. Zero is a
+// space so that idle stretches stand out.
+func sparkline(values []float64) string {
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		if v <= 0 || peak == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparkBlocks[min(len(sparkBlocks)-1, int(v/peak*float64(len(sparkBlocks)-1)+0.5))])
+	}
+	return b.String()
+}
+
+// commandTotals folds a project's command rows into one row per category.
+func commandTotals(commands []stats.APICommandStats, project string) []stats.APICommandStats {
+	var totals []stats.APICommandStats
+	index := make(map[string]int)
+	for _, cmd := range commands {
+		if cmd.Project != project {
+			continue
+		}
+		i, ok := index[cmd.Category]
+		if !ok {
+			i = len(totals)
+			index[cmd.Category] = i
+			totals = append(totals, stats.APICommandStats{Project: project, Category: cmd.Category})
+		}
+		totals[i].Runs += cmd.Runs
+		totals[i].Failures += cmd.Failures
+		totals[i].Time += cmd.Time
+	}
+	for i := range totals {
+		totals[i].FailureRate = float64(totals[i].Failures) / float64(totals[i].Runs) * 100
+	}
+	return totals
+}
+
 func formatDuration(seconds float64) string {
 	if seconds < 60 {
 		return fmt.Sprintf("%.0fs", seconds)
@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+type Format int
+
+const (
+	// Prometheus is the classic text exposition format (version 0.0.4),
+	// also what node_exporter's textfile collector reads.
+	Prometheus Format = iota
+	OpenMetrics
+)
+
+const (
+	PrometheusContentType  = "text/plain; version=0.0.4; charset=utf-8"
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// NegotiateFormat picks OpenMetrics when the scraper asks for it.
+func NegotiateFormat(accept string) Format {
+	if strings.Contains(accept, "application/openmetrics-text") {
+		return OpenMetrics
+	}
+	return Prometheus
+}
+
+func (f Format) ContentType() string {
+	if f == OpenMetrics {
+		return OpenMetricsContentType
+	}
+	return PrometheusContentType
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string // "gauge" or "counter"
+	Samples []Sample
+}
+
+func gauge(name, help string, value float64) Metric {
+	return Metric{Name: name, Help: help, Type: "gauge", Samples: []Sample{{Value: value}}}
+}
+
+// Collect reads today's totals from the daily summary tables, and streaks and
+// sessions from the calculator (which caches them).
+func Collect(storage core.Storage, calc *stats.Calculator) ([]Metric, error) {
+	return collect(storage, calc, time.Time{})
+}
+
+// collect is Collect as of now; a zero now is the current time, which lets
+// the calculator answer from its cache.
+func collect(storage core.Storage, calc *stats.Calculator, now time.Time) ([]Metric, error) {
+	day := now
+	if day.IsZero() {
+		day = time.Now()
+	}
+
+	period, err := storage.GetPeriodSummary(day, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load today's summary: %w", err)
+	}
+	projects, err := storage.GetProjectSummary(day, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project summary: %w", err)
+	}
+	languages, err := storage.GetLanguageSummary(day, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load language summary: %w", err)
+	}
+	editors, err := storage.GetEditorSummary(day, day)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load editor summary: %w", err)
+	}
+	total, err := storage.GetActivityCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count activities: %w", err)
+	}
+	apiStats, err := calc.CalculateAPI(storage, stats.APIOptions{Now: now})
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate stats: %w", err)
+	}
+
+	projectSeconds := Metric{Name: "codeme_project_today_seconds", Help: "Seconds spent per project today.", Type: "gauge"}
+	for _, p := range projects {
+		projectSeconds.Samples = append(projectSeconds.Samples, Sample{Labels: []Label{{"project", p.Project}}, Value: p.TotalTime})
+	}
+	languageSeconds := Metric{Name: "codeme_language_today_seconds", Help: "Seconds spent per language today.", Type: "gauge"}
+	for _, l := range languages {
+		languageSeconds.Samples = append(languageSeconds.Samples, Sample{Labels: []Label{{"language", l.Language}}, Value: l.TotalTime})
+	}
+	editorSeconds := Metric{Name: "codeme_editor_today_seconds", Help: "Seconds spent per editor today.", Type: "gauge"}
+	for _, e := range editors {
+		editorSeconds.Samples = append(editorSeconds.Samples, Sample{Labels: []Label{{"editor", e.Editor}}, Value: e.TotalTime})
+	}
+
+	return []Metric{
+		gauge("codeme_today_seconds", "Seconds of coding activity today.", period.TotalTime),
+		gauge("codeme_today_lines", "Lines changed today.", float64(period.TotalLines)),
+		gauge("codeme_today_sessions", "Coding sessions today.", float64(apiStats.Today.SessionCount)),
+		projectSeconds,
+		languageSeconds,
+		editorSeconds,
+		gauge("codeme_streak_current_days", "Current streak of days with activity.", float64(apiStats.StreakInfo.Current)),
+		gauge("codeme_streak_longest_days", "Longest streak of days with activity.", float64(apiStats.StreakInfo.Longest)),
+		{Name: "codeme_activities", Help: "Activities recorded in total.", Type: "counter", Samples: []Sample{{Value: float64(total)}}},
+	}, nil
+}
+
+// Write renders metrics in the given exposition format. Counters get the
+// _total suffix on their samples, as both formats expect.
+func Write(w io.Writer, metrics []Metric, format Format) error {
+	bw := bufio.NewWriter(w)
+
+	for _, m := range metrics {
+		sampleName := m.Name
+		if m.Type == "counter" {
+			sampleName += "_total"
+		}
+		familyName := m.Name
+		if format == Prometheus {
+			familyName = sampleName
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", familyName, escapeHelp(m.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", familyName, m.Type)
+
+		samples := append([]Sample(nil), m.Samples...)
+		sort.SliceStable(samples, func(i, j int) bool {
+			return labelString(samples[i].Labels) < labelString(samples[j].Labels)
+		})
+		for _, s := range samples {
+			fmt.Fprintf(bw, "%s%s %s\n", sampleName, labelString(s.Labels), strconv.FormatFloat(s.Value, 'g', -1, 64))
+		}
+	}
+
+	if format == OpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+// WriteTextfile writes metrics for node_exporter's textfile collector. The
+// file is written next to its destination and renamed into place, so the
+// collector never reads a half-written file.
+func WriteTextfile(path string, metrics []Metric) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, metrics, Prometheus); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to chmod metrics file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move metrics file into place: %w", err)
+	}
+	return nil
+}
+
+func labelString(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, l.Name, escapeLabel(l.Value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(v string) string {
+	return helpEscaper.Replace(v)
+}
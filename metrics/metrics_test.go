@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+func TestWrite_Prometheus(t *testing.T) {
+	metrics := []Metric{
+		{Name: "codeme_project_today_seconds", Help: "Seconds per project.", Type: "gauge", Samples: []Sample{
+			{Labels: []Label{{"project", "zeta"}}, Value: 30},
+			{Labels: []Label{{"project", `say "hi"\n`}}, Value: 1.5},
+		}},
+		{Name: "codeme_activities", Help: "Activities.", Type: "counter", Samples: []Sample{{Value: 42}}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, metrics, Prometheus))
+	require.Equal(t, `# HELP codeme_project_today_seconds Seconds per project.
+# TYPE codeme_project_today_seconds gauge
+codeme_project_today_seconds{project="say \"hi\"\\n"} 1.5
+codeme_project_today_seconds{project="zeta"} 30
+# HELP codeme_activities_total Activities.
+# TYPE codeme_activities_total counter
+codeme_activities_total 42
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, metrics[1:], OpenMetrics))
+	require.Equal(t, `# HELP codeme_activities Activities.
+# TYPE codeme_activities counter
+codeme_activities_total 42
+# EOF
+`, buf.String())
+}
+
+func TestCollect(t *testing.T) {
+	storage, err := core.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	time.Local = time.UTC
+	now := time.Date(2026, 3, 10, 0, 2, 0, 0, time.UTC)
+	for i, a := range []core.Activity{
+		{Project: "codeme", Language: "go", Editor: "neovim", Lines: 10},
+		{Project: "codeme", Language: "go", Editor: "neovim", Lines: 5},
+		{Project: "blog", Language: "markdown", Editor: "vscode", Lines: 3},
+	} {
+		a.ID = core.GenerateID()
+		a.Timestamp = now.Add(time.Duration(i-2) * time.Minute)
+		a.File = "/work/" + a.Project + "/file"
+		a.IsWrite = true
+		require.NoError(t, storage.SaveActivity(a))
+	}
+
+	collected, err := collect(storage, stats.NewCalculator(time.UTC), now)
+	require.NoError(t, err)
+
+	byName := make(map[string]Metric)
+	for _, m := range collected {
+		byName[m.Name] = m
+	}
+
+	require.Equal(t, float64(18), byName["codeme_today_lines"].Samples[0].Value)
+	require.Equal(t, float64(3), byName["codeme_activities"].Samples[0].Value)
+	require.Equal(t, "counter", byName["codeme_activities"].Type)
+	require.Equal(t, float64(1), byName["codeme_streak_current_days"].Samples[0].Value)
+	require.Equal(t, float64(1), byName["codeme_today_sessions"].Samples[0].Value)
+	require.Len(t, byName["codeme_project_today_seconds"].Samples, 2)
+	require.Len(t, byName["codeme_editor_today_seconds"].Samples, 2)
+}
+
+func TestWriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "codeme.prom")
+	require.NoError(t, os.WriteFile(path, []byte("stale"), 0644))
+
+	require.NoError(t, WriteTextfile(path, []Metric{
+		{Name: "codeme_today_lines", Help: "Lines.", Type: "gauge", Samples: []Sample{{Value: 7}}},
+	}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "codeme_today_lines 7\n")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temp file should be renamed away")
+}
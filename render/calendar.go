@@ -0,0 +1,105 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tduyng/codeme/stats"
+	"github.com/tduyng/codeme/util"
+)
+
+const (
+	cellSize = 11
+	cellGap  = 3
+)
+
+// Calendar draws a contribution calendar from WeeklyHeatmap, one column per
+// week with Monday on top. The heatmap starts at the first week with
+// activity, so it is padded with empty weeks to fill the requested number.
+func Calendar(w io.Writer, days []stats.HeatmapDay, weeks int, opts Options) error {
+	days = PadWeeks(days, weeks)
+
+	var total float64
+	for _, d := range days {
+		total += float64(d.Time)
+	}
+
+	const left, top, bottom = 36, 44, 32
+	step := cellSize + cellGap
+	width := left + len(days)/7*step + 16
+	height := top + 7*step + bottom
+
+	title := fmt.Sprintf("%s of coding in the last %d weeks", util.FormatDuration(total), len(days)/7)
+	width = max(width, int(textWidth(title, 14))+32)
+	s := newSVG(w, width, height, title, opts)
+	s.text(16, 22, 14, opts.Theme.Text, "", "600", title)
+
+	for row, label := range []string{"Mon", "", "Wed", "", "Fri", "", ""} {
+		if label != "" {
+			s.text(16, float64(top+row*step+cellSize-2), 9, opts.Theme.Muted, "", "", label)
+		}
+	}
+
+	lastMonth := time.Month(0)
+	for i, day := range days {
+		week, row := i/7, i%7
+		x := left + week*step
+		y := top + row*step
+
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return fmt.Errorf("failed to parse heatmap date %q: %w", day.Date, err)
+		}
+		// Label a month on the first full week that starts in it.
+		if row == 0 && date.Month() != lastMonth {
+			lastMonth = date.Month()
+			if week < len(days)/7-1 {
+				s.text(float64(x), float64(top-6), 9, opts.Theme.Muted, "", "", date.Format("Jan"))
+			}
+		}
+
+		if day.Level < 0 {
+			continue
+		}
+		s.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %s, %d lines</title></rect>`+"\n",
+			x, y, cellSize, cellSize, opts.Theme.Levels[min(day.Level, 4)],
+			day.Date, util.FormatDuration(float64(day.Time)), day.Lines)
+	}
+
+	legendX := float64(width - 16 - 5*step)
+	legendY := top + 7*step + 8
+	s.text(legendX-6, float64(legendY+cellSize-2), 9, opts.Theme.Muted, "end", "", "Less")
+	for level, color := range opts.Theme.Levels {
+		s.printf(`<rect x="%s" y="%d" width="%d" height="%d" rx="2" fill="%s"/>`+"\n",
+			num(legendX+float64(level*step)), legendY, cellSize, cellSize, color)
+	}
+	s.text(legendX+float64(5*step)+2, float64(legendY+cellSize-2), 9, opts.Theme.Muted, "", "", "More")
+
+	return s.close()
+}
+
+// PadWeeks returns exactly weeks weeks of days, keeping the most recent ones
+// and adding empty days before the first when there are too few.
+func PadWeeks(days []stats.HeatmapDay, weeks int) []stats.HeatmapDay {
+	if weeks <= 0 || len(days) == 0 {
+		return days
+	}
+
+	want := weeks * 7
+	if len(days) >= want {
+		return days[len(days)-want:]
+	}
+
+	first, err := time.Parse("2006-01-02", days[0].Date)
+	if err != nil {
+		return days
+	}
+
+	missing := want - len(days)
+	padded := make([]stats.HeatmapDay, 0, want)
+	for i := missing; i > 0; i-- {
+		padded = append(padded, stats.HeatmapDay{Date: first.AddDate(0, 0, -i).Format("2006-01-02")})
+	}
+	return append(padded, days...)
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/tduyng/codeme/stats"
+)
+
+// Badge draws a shields.io style badge, e.g. "coding this week | 12h 30m".
+// The theme's accent colors the value; the label side is always grey so the
+// badge reads like the ones next to it.
+func Badge(w io.Writer, label, value string, opts Options) error {
+	const height, pad, size = 20, 6, 11
+	labelWidth := int(math.Ceil(textWidth(label, size))) + 2*pad
+	valueWidth := int(math.Ceil(textWidth(value, size))) + 2*pad
+	width := labelWidth + valueWidth
+
+	opts.Transparent = true
+	s := newSVG(w, width, height, label+": "+value, opts)
+	s.printf(`<clipPath id="round"><rect width="%d" height="%d" rx="3"/></clipPath>`+"\n", width, height)
+	s.printf(`<g clip-path="url(#round)">` + "\n")
+	s.printf(`<rect width="%d" height="%d" fill="#555"/>`+"\n", labelWidth, height)
+	s.printf(`<rect x="%d" width="%d" height="%d" fill="%s"/>`+"\n", labelWidth, valueWidth, height, opts.Theme.Accent)
+	s.printf("</g>\n")
+	s.text(float64(labelWidth)/2, 14, size, "#fff", "middle", "", label)
+	s.text(float64(labelWidth)+float64(valueWidth)/2, 14, size, "#fff", "middle", "600", value)
+	return s.close()
+}
+
+// Streak draws a card with the current and longest streak and the day of
+// the last activity.
+func Streak(w io.Writer, streak stats.StreakInfo, opts Options) error {
+	const width, height = 360, 120
+	column := float64(width) / 3
+
+	s := newSVG(w, width, height, fmt.Sprintf("%d day coding streak", streak.Current), opts)
+
+	currentLabel := "Current streak"
+	if !streak.IsActive {
+		currentLabel = "Streak (code today!)"
+	}
+	lastActive := "never"
+	if !streak.LastActivity.IsZero() {
+		lastActive = streak.LastActivity.Format("Jan 2")
+	}
+
+	columns := []struct {
+		value, unit, label, color string
+	}{
+		{fmt.Sprintf("%d", streak.Current), plural(streak.Current, "day"), currentLabel, opts.Theme.Accent},
+		{fmt.Sprintf("%d", streak.Longest), plural(streak.Longest, "day"), "Longest streak", opts.Theme.Text},
+		{lastActive, "", "Last active", opts.Theme.Text},
+	}
+	for i, c := range columns {
+		x := column*float64(i) + column/2
+		s.text(x, 54, 28, c.color, "middle", "700", c.value)
+		if c.unit != "" {
+			s.text(x, 72, 10, opts.Theme.Muted, "middle", "", c.unit)
+		}
+		s.text(x, 98, 11, opts.Theme.Muted, "middle", "", c.label)
+		if i > 0 {
+			s.printf(`<line x1="%s" y1="24" x2="%s" y2="104" stroke="%s"/>`+"\n", num(column*float64(i)), num(column*float64(i)), opts.Theme.Border)
+		}
+	}
+
+	return s.close()
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
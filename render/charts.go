@@ -0,0 +1,90 @@
+package render
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tduyng/codeme/stats"
+	"github.com/tduyng/codeme/util"
+)
+
+type barRow struct {
+	Name string
+	Time float64
+}
+
+// Languages draws a stacked bar of time per language with a legend below it.
+func Languages(w io.Writer, languages []stats.APILanguageStats, title string, opts Options) error {
+	rows := make([]barRow, 0, len(languages))
+	for _, l := range languages {
+		rows = append(rows, barRow{Name: l.Name, Time: l.Time})
+	}
+	return barChart(w, title, rows, opts)
+}
+
+// Projects draws time per project like Languages. With HideProjects the names
+// become "Project 1", "Project 2", ... in order of time spent.
+func Projects(w io.Writer, projects []stats.APIProjectStats, title string, opts Options) error {
+	rows := make([]barRow, 0, len(projects))
+	for i, p := range projects {
+		name := p.Name
+		if opts.HideProjects {
+			name = fmt.Sprintf("Project %d", i+1)
+		}
+		rows = append(rows, barRow{Name: name, Time: p.Time})
+	}
+	return barChart(w, title, rows, opts)
+}
+
+// barChart keeps the top rows by time (the stats API sorts them already)
+// and folds the rest into "Other".
+func barChart(w io.Writer, title string, rows []barRow, opts Options) error {
+	var shown []barRow
+	var other, total float64
+	for _, r := range rows {
+		if r.Time <= 0 {
+			continue
+		}
+		total += r.Time
+		if len(shown) < opts.limit() {
+			shown = append(shown, r)
+		} else {
+			other += r.Time
+		}
+	}
+	if other > 0 {
+		shown = append(shown, barRow{Name: "Other", Time: other})
+	}
+
+	const width, barX, barWidth, rowHeight = 360, 16, 328, 22
+	height := 66 + max(len(shown), 1)*rowHeight + 8
+
+	s := newSVG(w, width, height, title, opts)
+	s.text(16, 24, 14, opts.Theme.Text, "", "600", title)
+
+	if total == 0 {
+		s.printf(`<rect x="%d" y="38" width="%d" height="8" rx="4" fill="%s"/>`+"\n", barX, barWidth, opts.Theme.Levels[0])
+		s.text(16, 76, 12, opts.Theme.Muted, "", "", "Nothing tracked yet")
+		return s.close()
+	}
+
+	s.printf(`<clipPath id="bar"><rect x="%d" y="38" width="%d" height="8" rx="4"/></clipPath>`+"\n", barX, barWidth)
+	s.printf(`<g clip-path="url(#bar)">` + "\n")
+	x := float64(barX)
+	for i, r := range shown {
+		segment := r.Time / total * barWidth
+		s.printf(`<rect x="%s" y="38" width="%s" height="8" fill="%s"/>`+"\n", num(x), num(segment), opts.color(i))
+		x += segment
+	}
+	s.printf("</g>\n")
+
+	for i, r := range shown {
+		y := 66 + i*rowHeight
+		s.printf(`<circle cx="%d" cy="%d" r="5" fill="%s"/>`+"\n", barX+5, y-4, opts.color(i))
+		s.text(float64(barX+16), float64(y), 12, opts.Theme.Text, "", "", r.Name)
+		s.text(float64(width-16), float64(y), 12, opts.Theme.Muted, "end", "",
+			fmt.Sprintf("%s · %.1f%%", util.FormatDuration(r.Time), r.Time/total*100))
+	}
+
+	return s.close()
+}
@@ -0,0 +1,174 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Theme struct {
+	Background string
+	Border     string
+	Text       string
+	Muted      string
+	Accent     string
+	// Levels are the calendar colors for heatmap levels 0 (no activity)
+	// to 4.
+	Levels [5]string
+}
+
+var Themes = map[string]Theme{
+	"light": {
+		Background: "#ffffff",
+		Border:     "#d0d7de",
+		Text:       "#1f2328",
+		Muted:      "#656d76",
+		Accent:     "#1a7f37",
+		Levels:     [5]string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"},
+	},
+	"dark": {
+		Background: "#0d1117",
+		Border:     "#30363d",
+		Text:       "#e6edf3",
+		Muted:      "#8b949e",
+		Accent:     "#3fb950",
+		Levels:     [5]string{"#161b22", "#0e4429", "#006d32", "#26a641", "#39d353"},
+	},
+	"dracula": {
+		Background: "#282a36",
+		Border:     "#44475a",
+		Text:       "#f8f8f2",
+		Muted:      "#6272a4",
+		Accent:     "#ff79c6",
+		Levels:     [5]string{"#44475a", "#6c4a7e", "#9a5ba6", "#c86bc0", "#ff79c6"},
+	},
+	"nord": {
+		Background: "#2e3440",
+		Border:     "#4c566a",
+		Text:       "#eceff4",
+		Muted:      "#d8dee9",
+		Accent:     "#88c0d0",
+		Levels:     [5]string{"#3b4252", "#435c6b", "#5e81ac", "#81a1c1", "#88c0d0"},
+	},
+}
+
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type Options struct {
+	Theme Theme
+	// Transparent leaves out the background, for pages with their own.
+	Transparent bool
+	// HideProjects replaces project names with "Project 1", "Project 2", ...
+	// for cards published from private work.
+	HideProjects bool
+	// Limit caps the rows of bar charts. Zero means 8.
+	Limit int
+}
+
+func (o Options) limit() int {
+	if o.Limit <= 0 {
+		return 8
+	}
+	return o.Limit
+}
+
+// palette colors the language and project bars. The theme's accent is used
+// for the first entry.
+var palette = []string{"#58a6ff", "#d2a8ff", "#ffa657", "#ff7b72", "#79c0ff", "#f2cc60", "#56d4dd", "#8b949e"}
+
+func (o Options) color(i int) string {
+	if i == 0 {
+		return o.Theme.Accent
+	}
+	return palette[(i-1)%len(palette)]
+}
+
+// svgWriter collects the first write error, so the drawing code can stay a
+// flat list of elements.
+type svgWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newSVG(w io.Writer, width, height int, label string, opts Options) *svgWriter {
+	s := &svgWriter{w: bufio.NewWriter(w)}
+	s.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s">`+"\n",
+		width, height, width, height, escape(label))
+	s.printf("<title>%s</title>\n", escape(label))
+	s.printf(`<style>text{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif}</style>` + "\n")
+	if !opts.Transparent {
+		s.printf(`<rect x="0.5" y="0.5" width="%d" height="%d" rx="6" fill="%s" stroke="%s"/>`+"\n",
+			width-1, height-1, opts.Theme.Background, opts.Theme.Border)
+	}
+	return s
+}
+
+func (s *svgWriter) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
+
+func (s *svgWriter) text(x, y float64, size int, fill, anchor, weight, content string) {
+	s.printf(`<text x="%s" y="%s" font-size="%d" fill="%s"`, num(x), num(y), size, fill)
+	if anchor != "" {
+		s.printf(` text-anchor="%s"`, anchor)
+	}
+	if weight != "" {
+		s.printf(` font-weight="%s"`, weight)
+	}
+	s.printf(">%s</text>\n", escape(content))
+}
+
+func (s *svgWriter) close() error {
+	s.printf("</svg>\n")
+	if s.err != nil {
+		return s.err
+	}
+	return s.w.Flush()
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;")
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// num prints coordinates without trailing zeros.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// textWidth estimates rendered text width for layouts, since SVG has no
+// measurement without a browser. The factors fit Verdana and system sans
+// fonts closely enough for badges.
+func textWidth(s string, size int) float64 {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("il.,:;|!'", r):
+			width += 0.3
+		case strings.ContainsRune("mwMW", r):
+			width += 0.9
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			width += 0.65
+		case r > 0x2000:
+			width += 1.0
+		default:
+			width += 0.55
+		}
+	}
+	return width * float64(size)
+}
@@ -0,0 +1,147 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+func testStats() *stats.APIStats {
+	period := stats.APIPeriodStats{
+		TotalTime: 5400,
+		Languages: []stats.APILanguageStats{
+			{Name: "go", Time: 3600},
+			{Name: "typescript", Time: 1800},
+		},
+		Projects: []stats.APIProjectStats{
+			{Name: "secret-client", Time: 3600},
+			{Name: "<codeme & co>", Time: 1800},
+		},
+	}
+
+	var heatmap []stats.HeatmapDay
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC) // a Monday
+	for i := range 14 {
+		heatmap = append(heatmap, stats.HeatmapDay{
+			Date:  start.AddDate(0, 0, i).Format("2006-01-02"),
+			Level: i % 5,
+			Time:  int64(i * 600),
+		})
+	}
+
+	return &stats.APIStats{
+		ThisWeek:      period,
+		AllTime:       period,
+		WeeklyHeatmap: heatmap,
+		StreakInfo:    stats.StreakInfo{Current: 3, Longest: 12, IsActive: true, LastActivity: start},
+	}
+}
+
+func requireValidXML(t *testing.T, data []byte) {
+	t.Helper()
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err, string(data))
+	}
+}
+
+func TestStats_AllKindsAreValidSVG(t *testing.T) {
+	for name, theme := range Themes {
+		for _, kind := range Kinds {
+			var buf bytes.Buffer
+			require.NoError(t, Stats(&buf, kind, testStats(), "", 4, Options{Theme: theme}), "%s/%s", name, kind)
+			require.True(t, strings.HasPrefix(buf.String(), "<svg "), kind)
+			requireValidXML(t, buf.Bytes())
+		}
+	}
+}
+
+func TestStats_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	require.Error(t, Stats(&buf, "pie", testStats(), "", 4, Options{Theme: Themes["light"]}))
+	require.Error(t, Stats(&buf, "badge", testStats(), "next_week", 4, Options{Theme: Themes["light"]}))
+}
+
+func TestProjects_HideProjects(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Stats(&buf, "projects", testStats(), "", 0, Options{Theme: Themes["dark"]}))
+	require.Contains(t, buf.String(), "secret-client")
+	require.Contains(t, buf.String(), "&lt;codeme &amp; co&gt;")
+
+	buf.Reset()
+	require.NoError(t, Stats(&buf, "projects", testStats(), "", 0, Options{Theme: Themes["dark"], HideProjects: true}))
+	require.NotContains(t, buf.String(), "secret-client")
+	require.NotContains(t, buf.String(), "codeme &amp; co")
+	require.Contains(t, buf.String(), "Project 1")
+	require.Contains(t, buf.String(), "Project 2")
+}
+
+func TestCalendar_PadsToWeeks(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Calendar(&buf, testStats().WeeklyHeatmap, 10, Options{Theme: Themes["light"]}))
+
+	// 70 days plus the five legend squares.
+	require.Equal(t, 75, strings.Count(buf.String(), "<rect x=")-strings.Count(buf.String(), `<rect x="0.5"`))
+	require.Contains(t, buf.String(), "in the last 10 weeks")
+
+	days := PadWeeks(testStats().WeeklyHeatmap, 1)
+	require.Len(t, days, 7)
+	require.Equal(t, "2026-03-09", days[0].Date)
+}
+
+func TestBadge(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Stats(&buf, "badge", testStats(), "", 0, Options{Theme: Themes["light"]}))
+	require.Contains(t, buf.String(), "coding this week")
+	require.Contains(t, buf.String(), "1h 30m")
+	require.NotContains(t, buf.String(), `x="0.5"`, "badges have no card background")
+}
+
+func TestNum(t *testing.T) {
+	require.Equal(t, "0", num(0))
+	require.Equal(t, "12.5", num(12.5))
+	require.Equal(t, "3.33", num(10.0/3))
+}
+
+func TestTerminalCalendar(t *testing.T) {
+	days := stats.CalendarDays([]core.DailySummary{
+		{Date: "2025-01-06", TotalTime: 3600},
+		{Date: "2025-01-07", TotalTime: 600},
+	}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, TerminalCalendar(&buf, days, ASCII, Themes["dark"]))
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 9)
+	require.Equal(t, "    Jan Feb", lines[0])
+	require.Equal(t, "Mon  #.......", lines[1], "Dec 30 is outside the range")
+	require.Equal(t, "     :.......", lines[2])
+	require.True(t, strings.HasSuffix(lines[8], "Less .:oO# More"))
+
+	buf.Reset()
+	require.NoError(t, TerminalCalendar(&buf, days, Color256, Themes["dark"]))
+	require.Contains(t, buf.String(), "\x1b[38;5;")
+
+	buf.Reset()
+	require.NoError(t, TerminalCalendar(&buf, days, TrueColor, Themes["dark"]))
+	require.Contains(t, buf.String(), "\x1b[38;2;57;211;83m■")
+}
+
+func TestXterm256(t *testing.T) {
+	require.Equal(t, 16, xterm256(0, 0, 0))
+	require.Equal(t, 231, xterm256(255, 255, 255))
+	require.Equal(t, 196, xterm256(255, 0, 0))
+	require.Equal(t, 244, xterm256(128, 128, 128))
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tduyng/codeme/stats"
+	"github.com/tduyng/codeme/util"
+)
+
+var Kinds = []string{"calendar", "languages", "projects", "badge", "streak"}
+
+// DefaultPeriod is the period a kind shows when none is given: badges show
+// this week's time, charts all time.
+func DefaultPeriod(kind string) string {
+	if kind == "badge" {
+		return "this_week"
+	}
+	return "all_time"
+}
+
+// Stats renders one kind of image from APIStats. period selects the period
+// for languages, projects and badge; weeks sets the calendar's width.
+func Stats(w io.Writer, kind string, s *stats.APIStats, period string, weeks int, opts Options) error {
+	if period == "" {
+		period = DefaultPeriod(kind)
+	}
+	p, ok := s.Period(period)
+	if !ok {
+		return fmt.Errorf("unknown period: %s", period)
+	}
+	periodLabel := strings.ReplaceAll(period, "_", " ")
+
+	switch kind {
+	case "calendar":
+		return Calendar(w, s.WeeklyHeatmap, weeks, opts)
+	case "languages":
+		return Languages(w, p.Languages, "Languages · "+periodLabel, opts)
+	case "projects":
+		return Projects(w, p.Projects, "Projects · "+periodLabel, opts)
+	case "badge":
+		return Badge(w, "coding "+periodLabel, util.FormatDuration(p.TotalTime), opts)
+	case "streak":
+		return Streak(w, s.StreakInfo, opts)
+	}
+	return fmt.Errorf("unknown image: %s (want %s)", kind, strings.Join(Kinds, ", "))
+}
@@ -0,0 +1,180 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tduyng/codeme/stats"
+)
+
+type ColorMode int
+
+const (
+	ASCII ColorMode = iota
+	Color256
+	TrueColor
+)
+
+// DetectColorMode picks the richest mode the terminal advertises. NO_COLOR,
+// TERM=dumb and output that is not a terminal get ASCII.
+func DetectColorMode(f *os.File) ColorMode {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return ASCII
+	}
+	if info, err := f.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return ASCII
+	}
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	return Color256
+}
+
+// ParseColorMode accepts "auto", "truecolor", "256" and "ascii".
+func ParseColorMode(s string, f *os.File) (ColorMode, error) {
+	switch s {
+	case "", "auto":
+		return DetectColorMode(f), nil
+	case "truecolor", "24bit":
+		return TrueColor, nil
+	case "256":
+		return Color256, nil
+	case "ascii", "none":
+		return ASCII, nil
+	}
+	return ASCII, fmt.Errorf("unknown color mode: %s (want auto, truecolor, 256 or ascii)", s)
+}
+
+// asciiLevels stand in for colors when there are none.
+var asciiLevels = [5]string{".", ":", "o", "O", "#"}
+
+const cellGlyph = "■"
+
+// TerminalCalendar prints a contribution calendar with one column per week,
+// Monday on top, month labels above and a legend below. Days with level -1
+// (outside the range or in the future) are left blank. Level 0 uses the
+// theme's border color, since its background color would vanish on a
+// terminal of the same color.
+func TerminalCalendar(w io.Writer, days []stats.HeatmapDay, mode ColorMode, theme Theme) error {
+	bw := bufio.NewWriter(w)
+	weeks := len(days) / 7
+
+	levels := theme.Levels
+	levels[0] = theme.Border
+	cell := func(level int) string {
+		if level < 0 {
+			return " "
+		}
+		level = min(level, 4)
+		switch mode {
+		case TrueColor:
+			r, g, b := hexRGB(levels[level])
+			return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", r, g, b, cellGlyph)
+		case Color256:
+			r, g, b := hexRGB(levels[level])
+			return fmt.Sprintf("\x1b[38;5;%dm%s\x1b[0m", xterm256(r, g, b), cellGlyph)
+		}
+		return asciiLevels[level]
+	}
+
+	const gutter = "    "
+
+	// A month is labeled above the first week that ends in it. Labels are
+	// placed from the right so that, when two collide, the partial month at
+	// the start of the calendar is the one left out.
+	type monthLabel struct {
+		week int
+		name string
+	}
+	var labels []monthLabel
+	for week := range weeks {
+		sunday := days[week*7+6].Date
+		date, err := time.Parse("2006-01-02", sunday)
+		if err != nil {
+			return fmt.Errorf("failed to parse heatmap date %q: %w", sunday, err)
+		}
+		if len(labels) == 0 || labels[len(labels)-1].name != date.Format("Jan") {
+			labels = append(labels, monthLabel{week: week, name: date.Format("Jan")})
+		}
+	}
+
+	header := []byte(strings.Repeat(" ", weeks+3))
+	limit := weeks
+	for i := len(labels) - 1; i >= 0; i-- {
+		if l := labels[i]; l.week+len(l.name) <= limit {
+			copy(header[l.week:], l.name)
+			limit = l.week - 1
+		}
+	}
+	fmt.Fprintf(bw, "%s%s\n", gutter, strings.TrimRight(string(header), " "))
+
+	for row, label := range []string{"Mon", "", "Wed", "", "Fri", "", "Sun"} {
+		fmt.Fprintf(bw, "%-4s", label)
+		for week := range weeks {
+			bw.WriteString(cell(days[week*7+row].Level))
+		}
+		bw.WriteString("\n")
+	}
+
+	legend := make([]string, 5)
+	for level := range legend {
+		legend[level] = cell(level)
+	}
+	pad := max(0, weeks-len("Less ")-5-len(" More"))
+	fmt.Fprintf(bw, "%s%sLess %s More\n", gutter, strings.Repeat(" ", pad), strings.Join(legend, ""))
+
+	return bw.Flush()
+}
+
+func hexRGB(hex string) (r, g, b int) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return 0, 0, 0
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
+
+// xterm256 maps a color to the closest entry of the 6x6x6 color cube or the
+// grey ramp of the xterm 256-color palette.
+func xterm256(r, g, b int) int {
+	cubeLevels := []int{0, 95, 135, 175, 215, 255}
+	nearest := func(v int) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if abs(v-l) < abs(v-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	ri, gi, bi := nearest(r), nearest(g), nearest(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := sq(r-cubeLevels[ri]) + sq(g-cubeLevels[gi]) + sq(b-cubeLevels[bi])
+
+	grey := min(23, max(0, ((r+g+b)/3-8+5)/10))
+	greyValue := 8 + grey*10
+	greyDist := sq(r-greyValue) + sq(g-greyValue) + sq(b-greyValue)
+
+	if greyDist < cubeDist {
+		return 232 + grey
+	}
+	return cube
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sq(v int) int {
+	return v * v
+}
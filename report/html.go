@@ -0,0 +1,206 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/tduyng/codeme/render"
+	"github.com/tduyng/codeme/stats"
+	"github.com/tduyng/codeme/util"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": util.FormatDuration,
+	"lower":    strings.ToLower,
+	"day":      func(d Day) string { return d.Date.Format("Mon Jan 2") },
+	"items": func(title, column string, items []Item) map[string]any {
+		return map[string]any{"Title": title, "Column": column, "Items": items}
+	},
+	"changeClass": changeClass,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Report.Title}}</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;color:#1f2328;background:#f6f8fa;margin:0;padding:32px 16px}
+main{max-width:760px;margin:0 auto;background:#fff;border:1px solid #d0d7de;border-radius:8px;padding:24px 32px}
+h1{font-size:24px;margin:0 0 4px}
+h2{font-size:16px;margin:32px 0 12px;padding-bottom:6px;border-bottom:1px solid #d0d7de}
+.muted{color:#656d76;font-size:13px}
+table{border-collapse:collapse;width:100%;font-size:14px}
+th,td{padding:6px 8px;border-bottom:1px solid #eaeef2;text-align:right}
+th:first-child,td:first-child{text-align:left}
+th{color:#656d76;font-weight:600}
+.up{color:#1a7f37}.down{color:#cf222e}
+.charts{display:flex;flex-wrap:wrap;gap:16px}
+.charts svg{max-width:100%;height:auto}
+ul{padding-left:20px;line-height:1.7}
+footer{margin-top:32px}
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Report.Title}}</h1>
+<p class="muted">{{if .Report.Partial}}So far; the {{.Report.Period}} is not over yet. {{end}}Compared with {{.Report.Comparison}}.</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th></th><th>{{.Report.CurrentLabel}}</th><th>{{.Report.PreviousLabel}}</th><th>Change</th></tr>
+{{- range .Report.Summary}}
+<tr><td>{{.Label}}</td><td>{{.Current}}</td><td>{{.Previous}}</td><td class="{{changeClass .Change}}">{{.Change}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Daily Breakdown</h2>
+{{.Daily}}
+<table>
+<tr><th>Day</th><th>Time</th><th>Lines</th><th>Sessions</th></tr>
+{{- range .Report.Days}}
+<tr><td>{{day .}}</td><td>{{duration .Time}}</td><td>{{.Lines}}</td><td>{{.Sessions}}</td></tr>
+{{- end}}
+</table>
+
+{{- if or .Projects .Languages}}
+<div class="charts">{{.Projects}}{{.Languages}}</div>
+{{- end}}
+{{- template "items" (items "Top Projects" "Project" .Report.Projects)}}
+{{- template "items" (items "Top Languages" "Language" .Report.Languages)}}
+{{- template "items" (items "Top Files" "File" .Report.Files)}}
+
+<h2>Sessions</h2>
+{{- with .Sessions}}
+<ul>
+<li>{{.Count}} {{if eq .Count 1}}session{{else}}sessions{{end}} ({{.Change}} vs {{lower $.Report.PreviousLabel}}), averaging {{duration .Average}}</li>
+<li>Longest: {{duration .Longest.Duration}} on {{.Longest.StartTime.Format "Mon Jan 2"}}, {{.Longest.StartTime.Format "15:04"}} – {{.Longest.EndTime.Format "15:04"}}</li>
+<li>Focus score: {{$.Report.Current.FocusScore}} ({{.Focus}})</li>
+</ul>
+{{- else}}
+<p>No sessions.</p>
+{{- end}}
+
+<h2>Records</h2>
+<ul>
+{{- range .Report.RecordLines}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+
+<footer class="muted">Generated by codeme on {{.Report.GeneratedAt.Format "Jan 2, 2006 at 15:04"}}.</footer>
+</main>
+</body>
+</html>
+{{define "items"}}
+{{- if .Items}}
+<h2>{{.Title}}</h2>
+<table>
+<tr><th>{{.Column}}</th><th>Time</th><th>Share</th><th>Change</th></tr>
+{{- range .Items}}
+<tr><td>{{.Name}}</td><td>{{duration .Time}}</td><td>{{printf "%.1f" .Share}}%</td><td class="{{changeClass .Change}}">{{.Change}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+`))
+
+func changeClass(change string) string {
+	switch {
+	case strings.HasPrefix(change, "▲"), change == "new":
+		return "up"
+	case strings.HasPrefix(change, "▼"):
+		return "down"
+	}
+	return ""
+}
+
+type sessionView struct {
+	Count   int
+	Average float64
+	Longest *stats.APISession
+	Change  string
+	Focus   string
+}
+
+// HTML writes the report as a single self-contained page: inline CSS and
+// inline SVG charts, no scripts and nothing fetched.
+func HTML(w io.Writer, r *Report) error {
+	opts := render.Options{Theme: render.Themes["light"], Transparent: true, Limit: topItems}
+
+	var projects, languages bytes.Buffer
+	if len(r.Current.Projects) > 0 {
+		if err := render.Projects(&projects, r.Current.Projects, "Projects", opts); err != nil {
+			return fmt.Errorf("failed to render projects: %w", err)
+		}
+	}
+	if len(r.Current.Languages) > 0 {
+		if err := render.Languages(&languages, r.Current.Languages, "Languages", opts); err != nil {
+			return fmt.Errorf("failed to render languages: %w", err)
+		}
+	}
+
+	var sessions *sessionView
+	if count, average, longest := r.SessionSummary(); count > 0 {
+		sessions = &sessionView{
+			Count:   count,
+			Average: average,
+			Longest: longest,
+			Change:  PointChange(count, r.Previous.SessionCount),
+			Focus:   PointChange(r.Current.FocusScore, r.Previous.FocusScore),
+		}
+	}
+
+	data := map[string]any{
+		"Report":    r,
+		"Daily":     template.HTML(dailyChart(r.Days)),
+		"Projects":  template.HTML(projects.String()),
+		"Languages": template.HTML(languages.String()),
+		"Sessions":  sessions,
+	}
+	if err := htmlTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// dailyChart draws one bar per day of coding time. Day labels are the
+// weekday initial for weeks and the day of month for months.
+func dailyChart(days []Day) string {
+	const height, top, bottom = 160, 16, 24
+	width := 696
+	step := float64(width) / float64(max(len(days), 1))
+	barWidth := max(step*0.7, 2)
+
+	var peak float64
+	for _, d := range days {
+		peak = max(peak, d.Time)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="Coding time per day">`,
+		width, height, width, height)
+	b.WriteString(`<style>text{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif}</style>`)
+	fmt.Fprintf(&b, `<line x1="0" y1="%d" x2="%d" y2="%d" stroke="#d0d7de"/>`, height-bottom, width, height-bottom)
+
+	for i, d := range days {
+		x := float64(i)*step + (step-barWidth)/2
+		h := 0.0
+		if peak > 0 {
+			h = d.Time / peak * float64(height-top-bottom)
+		}
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="2" fill="#40c463"><title>%s: %s</title></rect>`,
+			x, float64(height-bottom)-h, barWidth, h, d.Date.Format("Mon Jan 2"), util.FormatDuration(d.Time))
+
+		label := d.Date.Format("Mon")[:1]
+		if len(days) > 7 {
+			label = d.Date.Format("2")
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="10" fill="#656d76" text-anchor="middle">%s</text>`,
+			float64(i)*step+step/2, height-8, label)
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
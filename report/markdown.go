@@ -0,0 +1,79 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tduyng/codeme/util"
+)
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`")
+
+func md(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func Markdown(w io.Writer, r *Report) error {
+	bw := bufio.NewWriter(w)
+	p := func(format string, args ...any) {
+		fmt.Fprintf(bw, format, args...)
+	}
+
+	p("# %s\n\n", r.Title())
+	if r.Partial {
+		p("_So far; the %s is not over yet._ ", r.Period)
+	}
+	p("_Compared with %s._\n\n", r.Comparison())
+
+	p("## Summary\n\n")
+	p("| | %s | %s | Change |\n", r.CurrentLabel(), r.PreviousLabel())
+	p("|---|---:|---:|---:|\n")
+	for _, row := range r.Summary() {
+		p("| %s | %s | %s | %s |\n", row.Label, row.Current, row.Previous, row.Change)
+	}
+
+	p("\n## Daily Breakdown\n\n")
+	p("| Day | Time | Lines | Sessions |\n")
+	p("|---|---:|---:|---:|\n")
+	for _, d := range r.Days {
+		p("| %s | %s | %d | %d |\n", d.Date.Format("Mon Jan 2"), util.FormatDuration(d.Time), d.Lines, d.Sessions)
+	}
+
+	items := func(title, column string, items []Item) {
+		if len(items) == 0 {
+			return
+		}
+		p("\n## %s\n\n", title)
+		p("| %s | Time | Share | Change |\n", column)
+		p("|---|---:|---:|---:|\n")
+		for _, it := range items {
+			p("| %s | %s | %.1f%% | %s |\n", md(it.Name), util.FormatDuration(it.Time), it.Share, it.Change)
+		}
+	}
+	items("Top Projects", "Project", r.Projects())
+	items("Top Languages", "Language", r.Languages())
+	items("Top Files", "File", r.Files())
+
+	p("\n## Sessions\n\n")
+	count, average, longest := r.SessionSummary()
+	if count == 0 {
+		p("No sessions.\n")
+	} else {
+		p("- %d %s (%s vs %s), averaging %s\n", count, plural(count, "session"), PointChange(count, r.Previous.SessionCount),
+			strings.ToLower(r.PreviousLabel()), util.FormatDuration(average))
+		p("- Longest: %s on %s, %s – %s\n", util.FormatDuration(longest.Duration),
+			longest.StartTime.Format("Mon Jan 2"), longest.StartTime.Format("15:04"), longest.EndTime.Format("15:04"))
+		p("- Focus score: %d (%s)\n", r.Current.FocusScore, PointChange(r.Current.FocusScore, r.Previous.FocusScore))
+	}
+
+	p("\n## Records\n\n")
+	for _, line := range r.RecordLines() {
+		p("- %s\n", md(line))
+	}
+
+	p("\n---\n_Generated by codeme on %s._\n", r.GeneratedAt.Format("Jan 2, 2006 at 15:04"))
+
+	return bw.Flush()
+}
@@ -0,0 +1,320 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tduyng/codeme/stats"
+	"github.com/tduyng/codeme/util"
+)
+
+// Periods are the report lengths; each is compared with the one before it.
+var Periods = []string{"week", "month"}
+
+// PeriodEnd returns the last moment of the week or month containing date,
+// capped at now so reports on the current period cover it so far. It is
+// meant for APIOptions.Now.
+func PeriodEnd(period string, date, now time.Time, tz *time.Location) (time.Time, error) {
+	var start time.Time
+	switch period {
+	case "week":
+		start = util.StartOfWeek(date, tz).AddDate(0, 0, 7)
+	case "month":
+		start = util.StartOfMonth(date, tz).AddDate(0, 1, 0)
+	default:
+		return time.Time{}, fmt.Errorf("unknown period: %s (want week or month)", period)
+	}
+
+	end := start.Add(-time.Nanosecond)
+	if end.After(now) {
+		return now, nil
+	}
+	return end, nil
+}
+
+type Report struct {
+	Period   string // "week" or "month"
+	Current  stats.APIPeriodStats
+	Previous stats.APIPeriodStats
+	// Partial is set while the reported period is still running.
+	Partial bool
+
+	Days        []Day
+	ActiveDays  int
+	PrevActive  int
+	Streak      stats.StreakInfo
+	Records     stats.Records
+	GeneratedAt time.Time
+}
+
+type Day struct {
+	Date     time.Time
+	Time     float64
+	Lines    int
+	Sessions int
+}
+
+// Build picks the period and the one before it out of APIStats, which must
+// have been calculated with Now set to the period's end (see PeriodEnd).
+func Build(s *stats.APIStats, period string, tz *time.Location) (*Report, error) {
+	r := &Report{
+		Period:      period,
+		Streak:      s.StreakInfo,
+		Records:     s.Records,
+		GeneratedAt: s.GeneratedAt,
+	}
+
+	var natural time.Time
+	switch period {
+	case "week":
+		r.Current, r.Previous = s.ThisWeek, s.LastWeek
+		natural = r.Current.StartDate.AddDate(0, 0, 7)
+	case "month":
+		r.Current, r.Previous = s.ThisMonth, s.LastMonth
+		natural = r.Current.StartDate.AddDate(0, 1, 0)
+	default:
+		return nil, fmt.Errorf("unknown period: %s (want week or month)", period)
+	}
+	r.Partial = r.Current.EndDate.Before(natural.Add(-time.Second))
+
+	for d := r.Current.StartDate; d.Before(natural) && !d.After(r.Current.EndDate); d = d.AddDate(0, 0, 1) {
+		ds := s.DailyActivity[util.DateString(d, tz)]
+		r.Days = append(r.Days, Day{Date: d, Time: float64(ds.Time), Lines: ds.Lines, Sessions: ds.SessionCount})
+		if ds.Time > 0 {
+			r.ActiveDays++
+		}
+	}
+	for d := r.Previous.StartDate; d.Before(r.Previous.EndDate); d = d.AddDate(0, 0, 1) {
+		if s.DailyActivity[util.DateString(d, tz)].Time > 0 {
+			r.PrevActive++
+		}
+	}
+
+	return r, nil
+}
+
+// Title is e.g. "Weekly Report: Oct 12 – Oct 18, 2026".
+func (r *Report) Title() string {
+	if r.Period == "month" {
+		return "Monthly Report: " + r.Current.StartDate.Format("January 2006")
+	}
+	return "Weekly Report: " + dateRange(r.Current.StartDate, r.lastDay())
+}
+
+// Comparison describes the previous period, e.g. "Oct 5 – Oct 11, 2026".
+func (r *Report) Comparison() string {
+	if r.Period == "month" {
+		return r.Previous.StartDate.Format("January 2006")
+	}
+	return dateRange(r.Previous.StartDate, r.Previous.EndDate.AddDate(0, 0, -1))
+}
+
+func (r *Report) lastDay() time.Time {
+	if len(r.Days) == 0 {
+		return r.Current.StartDate
+	}
+	return r.Days[len(r.Days)-1].Date
+}
+
+func (r *Report) CurrentLabel() string {
+	if r.Period == "month" {
+		return "This month"
+	}
+	return "This week"
+}
+
+func (r *Report) PreviousLabel() string {
+	if r.Period == "month" {
+		return "Last month"
+	}
+	return "Last week"
+}
+
+func dateRange(from, to time.Time) string {
+	if from.Year() != to.Year() {
+		return from.Format("Jan 2, 2006") + " – " + to.Format("Jan 2, 2006")
+	}
+	return from.Format("Jan 2") + " – " + to.Format("Jan 2, 2006")
+}
+
+// Row is one line of the summary table.
+type Row struct {
+	Label    string
+	Current  string
+	Previous string
+	Change   string
+}
+
+func (r *Report) Summary() []Row {
+	cur, prev := r.Current, r.Previous
+	return []Row{
+		{"Coding time", util.FormatDuration(cur.TotalTime), util.FormatDuration(prev.TotalTime), Change(cur.TotalTime, prev.TotalTime)},
+		{"Lines changed", fmt.Sprint(cur.TotalLines), fmt.Sprint(prev.TotalLines), Change(float64(cur.TotalLines), float64(prev.TotalLines))},
+		{"Files", fmt.Sprint(cur.TotalFiles), fmt.Sprint(prev.TotalFiles), Change(float64(cur.TotalFiles), float64(prev.TotalFiles))},
+		{"Sessions", fmt.Sprint(cur.SessionCount), fmt.Sprint(prev.SessionCount), Change(float64(cur.SessionCount), float64(prev.SessionCount))},
+		{"Focus score", fmt.Sprint(cur.FocusScore), fmt.Sprint(prev.FocusScore), PointChange(cur.FocusScore, prev.FocusScore)},
+		{"Active days", fmt.Sprint(r.ActiveDays), fmt.Sprint(r.PrevActive), PointChange(r.ActiveDays, r.PrevActive)},
+		{"Per active day", util.FormatDuration(perDay(cur.TotalTime, r.ActiveDays)), util.FormatDuration(perDay(prev.TotalTime, r.PrevActive)),
+			Change(perDay(cur.TotalTime, r.ActiveDays), perDay(prev.TotalTime, r.PrevActive))},
+	}
+}
+
+func perDay(total float64, days int) float64 {
+	if days == 0 {
+		return 0
+	}
+	return total / float64(days)
+}
+
+// Item is a project, language or file with its change since the previous
+// period.
+type Item struct {
+	Name   string
+	Time   float64
+	Lines  int
+	Share  float64
+	Change string
+}
+
+const topItems = 5
+
+func (r *Report) Projects() []Item {
+	prev := make(map[string]float64)
+	for _, p := range r.Previous.Projects {
+		prev[p.Name] = p.Time
+	}
+	var items []Item
+	for _, p := range r.Current.Projects[:min(topItems, len(r.Current.Projects))] {
+		items = append(items, Item{Name: p.Name, Time: p.Time, Lines: p.Lines, Share: p.PercentTotal, Change: Change(p.Time, prev[p.Name])})
+	}
+	return items
+}
+
+func (r *Report) Languages() []Item {
+	prev := make(map[string]float64)
+	for _, l := range r.Previous.Languages {
+		prev[l.Name] = l.Time
+	}
+	var items []Item
+	for _, l := range r.Current.Languages[:min(topItems, len(r.Current.Languages))] {
+		items = append(items, Item{Name: l.Name, Time: l.Time, Lines: l.Lines, Share: l.PercentTotal, Change: Change(l.Time, prev[l.Name])})
+	}
+	return items
+}
+
+// Files shows each file as its parent directory and name; full paths are
+// long and say more about your disk than about the work.
+func (r *Report) Files() []Item {
+	prev := make(map[string]float64)
+	for _, f := range r.Previous.Files {
+		prev[f.Name] = f.Time
+	}
+	var items []Item
+	for _, f := range r.Current.Files[:min(topItems, len(r.Current.Files))] {
+		name := filepath.Join(filepath.Base(filepath.Dir(f.Name)), filepath.Base(f.Name))
+		items = append(items, Item{Name: name, Time: f.Time, Lines: f.Lines, Share: f.PercentTotal, Change: Change(f.Time, prev[f.Name])})
+	}
+	return items
+}
+
+// SessionSummary returns the number of sessions, their average length and
+// the longest one.
+func (r *Report) SessionSummary() (count int, average float64, longest *stats.APISession) {
+	var total float64
+	for i, s := range r.Current.Sessions {
+		total += s.Duration
+		if longest == nil || s.Duration > longest.Duration {
+			longest = &r.Current.Sessions[i]
+		}
+	}
+	count = len(r.Current.Sessions)
+	if count > 0 {
+		average = total / float64(count)
+	}
+	return count, average, longest
+}
+
+// RecordLines lists the period's best days next to the previous period's,
+// then the streak and all-time records.
+func (r *Report) RecordLines() []string {
+	var lines []string
+	period := strings.ToLower(r.CurrentLabel())
+	previous := strings.ToLower(r.PreviousLabel())
+
+	if best := r.Current.MostProductiveDay; best != nil {
+		line := fmt.Sprintf("Best day %s: %s, %s", period, dayLabel(best.Date), util.FormatDuration(best.Time))
+		if prev := r.Previous.MostProductiveDay; prev != nil {
+			line += fmt.Sprintf(" (%s: %s, %s)", previous, dayLabel(prev.Date), util.FormatDuration(prev.Time))
+		}
+		lines = append(lines, line)
+	}
+	if best := r.Current.HighestDailyOutput; best != nil && best.Lines > 0 {
+		line := fmt.Sprintf("Most lines in a day: %d on %s", best.Lines, dayLabel(best.Date))
+		if prev := r.Previous.HighestDailyOutput; prev != nil && prev.Lines > 0 {
+			line += fmt.Sprintf(" (%s: %d)", previous, prev.Lines)
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, fmt.Sprintf("Streak: %d %s (longest %d)", r.Streak.Current, plural(r.Streak.Current, "day"), r.Streak.Longest))
+
+	if rec := r.Records.MostProductiveDay; rec.Date != "" {
+		lines = append(lines, fmt.Sprintf("All-time best day: %s, %s", dayLabel(rec.Date), util.FormatDuration(rec.Time)))
+	}
+	if rec := r.Records.LongestSession; rec.Duration > 0 {
+		lines = append(lines, fmt.Sprintf("All-time longest session: %s on %s", util.FormatDuration(rec.Duration), dayLabel(rec.Date)))
+	}
+	if rec := r.Records.BestStreak; rec.DayCount > 0 {
+		lines = append(lines, fmt.Sprintf("All-time best streak: %d days (%s – %s)", rec.DayCount, dayLabel(rec.StartDate), dayLabel(rec.EndDate)))
+	}
+	return lines
+}
+
+// dayLabel turns a YYYY-MM-DD date into "Mon Jan 2, 2006".
+func dayLabel(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return d.Format("Mon Jan 2, 2006")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// Change formats the relative change from prev to cur, e.g. "▲ 25%".
+func Change(cur, prev float64) string {
+	switch {
+	case prev == 0 && cur == 0:
+		return "–"
+	case prev == 0:
+		return "new"
+	}
+	pct := math.Round((cur - prev) / prev * 100)
+	switch {
+	case pct > 0:
+		return fmt.Sprintf("▲ %.0f%%", pct)
+	case pct < 0:
+		return fmt.Sprintf("▼ %.0f%%", -pct)
+	}
+	return "="
+}
+
+// PointChange formats an absolute change, for values that are already
+// scores or small counts.
+func PointChange(cur, prev int) string {
+	switch diff := cur - prev; {
+	case diff > 0:
+		return fmt.Sprintf("▲ %d", diff)
+	case diff < 0:
+		return fmt.Sprintf("▼ %d", -diff)
+	}
+	return "="
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+func TestChange(t *testing.T) {
+	require.Equal(t, "–", Change(0, 0))
+	require.Equal(t, "new", Change(5, 0))
+	require.Equal(t, "▲ 25%", Change(125, 100))
+	require.Equal(t, "▼ 50%", Change(50, 100))
+	require.Equal(t, "=", Change(100.2, 100))
+
+	require.Equal(t, "▲ 3", PointChange(5, 2))
+	require.Equal(t, "▼ 2", PointChange(0, 2))
+	require.Equal(t, "=", PointChange(4, 4))
+}
+
+func TestPeriodEnd(t *testing.T) {
+	now := time.Date(2026, 3, 11, 15, 0, 0, 0, time.UTC)
+
+	end, err := PeriodEnd("week", time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC), now, time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 8, 23, 59, 59, 999999999, time.UTC), end)
+
+	end, err = PeriodEnd("month", time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC), now, time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 2, 28, 23, 59, 59, 999999999, time.UTC), end)
+
+	end, err = PeriodEnd("week", now, now, time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, now, end, "the current period ends now")
+
+	_, err = PeriodEnd("year", now, now, time.UTC)
+	require.Error(t, err)
+}
+
+func TestBuild(t *testing.T) {
+	time.Local = time.UTC
+
+	storage, err := core.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	save := func(ts time.Time, project string, lines int) {
+		require.NoError(t, storage.SaveActivity(core.Activity{
+			ID:        core.GenerateID(),
+			Timestamp: ts,
+			Project:   project,
+			Language:  "go",
+			Editor:    "neovim",
+			File:      "/work/" + project + "/main.go",
+			Lines:     lines,
+			IsWrite:   true,
+		}))
+	}
+	// Tuesday of the reported week, and the Tuesday before it.
+	for i := range 4 {
+		save(time.Date(2026, 3, 10, 10, i*5, 0, 0, time.UTC), "codeme", 10)
+	}
+	for i := range 2 {
+		save(time.Date(2026, 3, 3, 10, i*5, 0, 0, time.UTC), "codeme", 5)
+	}
+	// After the reported week; must not count.
+	save(time.Date(2026, 3, 20, 10, 0, 0, 0, time.UTC), "later", 100)
+
+	end, err := PeriodEnd("week", time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), time.Now(), time.UTC)
+	require.NoError(t, err)
+
+	s, err := stats.NewCalculator(time.UTC).CalculateAPI(storage, stats.APIOptions{LoadRecentDays: 365, Now: end})
+	require.NoError(t, err)
+
+	r, err := Build(s, "week", time.UTC)
+	require.NoError(t, err)
+	require.False(t, r.Partial)
+	require.Len(t, r.Days, 7)
+	require.Equal(t, 1, r.ActiveDays)
+	require.Equal(t, 1, r.PrevActive)
+	require.Equal(t, 40, r.Current.TotalLines)
+	require.Equal(t, 10, r.Previous.TotalLines)
+	require.Equal(t, "Weekly Report: Mar 9 – Mar 15, 2026", r.Title())
+	require.Equal(t, "Mar 2 – Mar 8, 2026", r.Comparison())
+
+	projects := r.Projects()
+	require.Len(t, projects, 1)
+	require.Equal(t, "codeme", projects[0].Name)
+
+	var md bytes.Buffer
+	require.NoError(t, Markdown(&md, r))
+	require.Contains(t, md.String(), "# Weekly Report: Mar 9 – Mar 15, 2026")
+	require.Contains(t, md.String(), "| Lines changed | 40 | 10 | ▲ 300% |")
+	require.Contains(t, md.String(), "| Tue Mar 10 |")
+	require.NotContains(t, md.String(), "later")
+
+	var page bytes.Buffer
+	require.NoError(t, HTML(&page, r))
+	require.Contains(t, page.String(), "<title>Weekly Report: Mar 9 – Mar 15, 2026</title>")
+	require.Contains(t, page.String(), `aria-label="Coding time per day"`)
+	require.NotContains(t, page.String(), "<script")
+}
+
+func TestBuild_UnknownPeriod(t *testing.T) {
+	_, err := Build(&stats.APIStats{}, "year", time.UTC)
+	require.Error(t, err)
+}
@@ -0,0 +1,272 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// DefaultLoadRecentDays is used by stats and today when the client does not
+// ask for a window, so both methods share one cache entry.
+const DefaultLoadRecentDays = 365
+
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type TrackResult struct {
+	ID string `json:"id"`
+}
+
+type StatusResult struct {
+	Version       string  `json:"version"`
+	DBPath        string  `json:"db_path"`
+	Activities    int     `json:"activities"`
+	Tracked       int     `json:"tracked"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// Server answers newline-delimited JSON-RPC 2.0 requests. It keeps one
+// storage connection and one Calculator, so repeated stats calls are served
+// from the calculator's cache until a track call invalidates it.
+type Server struct {
+	storage core.Storage
+	tracker *core.Tracker
+	calc    *stats.Calculator
+	version string
+	dbPath  string
+	started time.Time
+	tracked int
+	onTrack func(core.Activity)
+
+	mu sync.Mutex
+}
+
+func NewServer(storage core.Storage, calc *stats.Calculator, version, dbPath string) *Server {
+	tracker := core.NewTracker(storage)
+	tracker.SetCategorizer(stats.InferCategory)
+
+	return &Server{
+		storage: storage,
+		tracker: tracker,
+		calc:    calc,
+		version: version,
+		dbPath:  dbPath,
+		started: time.Now(),
+	}
+}
+
+// OnTrack sets a function called after each tracked activity.
+func (s *Server) OnTrack(fn func(core.Activity)) {
+	s.onTrack = fn
+}
+
+// Serve reads requests from r until EOF and writes one response line per
+// request (or batch) to w. Notifications get no response.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	encoder := json.NewEncoder(w)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handleLine(line); resp != nil {
+				if encErr := encoder.Encode(resp); encErr != nil {
+					return fmt.Errorf("failed to write response: %w", encErr)
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read request: %w", err)
+		}
+	}
+}
+
+func (s *Server) handleLine(line []byte) any {
+	line = bytes.TrimSpace(line)
+
+	if line[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(line, &batch); err != nil {
+			return errorResponse(nil, CodeParseError, "parse error")
+		}
+		if len(batch) == 0 {
+			return errorResponse(nil, CodeInvalidRequest, "empty batch")
+		}
+
+		var responses []*Response
+		for _, raw := range batch {
+			if resp := s.handleMessage(raw); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return responses
+	}
+
+	if resp := s.handleMessage(line); resp != nil {
+		return resp
+	}
+	return nil
+}
+
+func (s *Server) handleMessage(raw json.RawMessage) *Response {
+	var req Request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return errorResponse(nil, CodeParseError, "parse error")
+		}
+		return errorResponse(nil, CodeInvalidRequest, "invalid request")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, CodeInvalidRequest, "invalid request")
+	}
+
+	result, err := s.Call(req.Method, req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		return &Response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// Call dispatches one method. Errors that are not *Error are reported to the
+// client as internal errors.
+func (s *Server) Call(method string, params json.RawMessage) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch method {
+	case "track":
+		return s.track(params)
+	case "stats":
+		return s.stats(params)
+	case "today":
+		return s.today()
+	case "status":
+		return s.status()
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", method)}
+	}
+}
+
+func (s *Server) track(params json.RawMessage) (any, error) {
+	var hb core.Heartbeat
+	if err := decodeParams(params, &hb); err != nil {
+		return nil, err
+	}
+	if hb.File == "" {
+		return nil, &Error{Code: CodeInvalidParams, Message: "file is required"}
+	}
+
+	activity := hb.Activity()
+	if err := s.tracker.TrackActivity(activity); err != nil {
+		return nil, err
+	}
+
+	s.tracked++
+	s.calc.Invalidate()
+	if s.onTrack != nil {
+		s.onTrack(activity)
+	}
+
+	return TrackResult{ID: activity.ID}, nil
+}
+
+func (s *Server) stats(params json.RawMessage) (any, error) {
+	var opts stats.APIOptions
+	if err := decodeParams(params, &opts); err != nil {
+		return nil, err
+	}
+	if opts.LoadRecentDays == 0 {
+		opts.LoadRecentDays = DefaultLoadRecentDays
+	}
+
+	return s.calc.CalculateAPI(s.storage, opts)
+}
+
+func (s *Server) today() (any, error) {
+	result, err := s.calc.CalculateAPI(s.storage, stats.APIOptions{LoadRecentDays: DefaultLoadRecentDays})
+	if err != nil {
+		return nil, err
+	}
+	return result.Today, nil
+}
+
+func (s *Server) status() (any, error) {
+	count, err := s.storage.GetActivityCount()
+	if err != nil {
+		return nil, err
+	}
+
+	return StatusResult{
+		Version:       s.version,
+		DBPath:        s.dbPath,
+		Activities:    count,
+		Tracked:       s.tracked,
+		UptimeSeconds: time.Since(s.started).Seconds(),
+	}, nil
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: "2.0", ID: id, Error: &Error{Code: code, Message: message}}
+}
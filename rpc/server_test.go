@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	storage, err := core.NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { storage.Close() })
+
+	return NewServer(storage, stats.NewCalculator(time.UTC), "test", dbPath)
+}
+
+func serve(t *testing.T, s *Server, lines ...string) []map[string]any {
+	t.Helper()
+
+	var out bytes.Buffer
+	require.NoError(t, s.Serve(strings.NewReader(strings.Join(lines, "\n")), &out))
+
+	var responses []map[string]any
+	scanner := bufio.NewScanner(&out)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &resp))
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServer_TrackThenToday(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"today"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"track","params":{"file":"/p/main.go","language":"go","lines":12}}`,
+		`{"jsonrpc":"2.0","method":"track","params":{"file":"/p/util.go","lines":3}}`,
+		`{"jsonrpc":"2.0","id":"s","method":"status"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"today"}`,
+	)
+
+	require.Len(t, responses, 4, "notifications get no response")
+
+	before := responses[0]["result"].(map[string]any)
+	require.Equal(t, 0.0, before["total_lines"])
+
+	require.NotEmpty(t, responses[1]["result"].(map[string]any)["id"])
+
+	status := responses[2]["result"].(map[string]any)
+	require.Equal(t, "s", responses[2]["id"])
+	require.Equal(t, 2.0, status["activities"])
+	require.Equal(t, 2.0, status["tracked"])
+
+	// The track calls invalidate the cached stats from the first call.
+	after := responses[3]["result"].(map[string]any)
+	require.Equal(t, 15.0, after["total_lines"])
+}
+
+func TestServer_StatsWithOptions(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"track","params":{"file":"/p/main.go","machine":"desk","lines":5}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"track","params":{"file":"/p/main.go","machine":"laptop","lines":7}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"stats","params":{"load_recent_days":30,"filter":{"machine":"laptop"}}}`,
+	)
+
+	require.Len(t, responses, 3)
+	result := responses[2]["result"].(map[string]any)
+	today := result["today"].(map[string]any)
+	require.Equal(t, 7.0, today["total_lines"])
+	require.Equal(t, "last_30_days", result["_meta"].(map[string]any)["data_window"])
+}
+
+func TestServer_Errors(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"nope"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"track","params":{"lines":1}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"stats","params":{"load_recent_days":"x"}}`,
+		`{not json`,
+		`{"id":4,"method":"status"}`,
+	)
+
+	require.Len(t, responses, 5)
+	codes := make([]float64, len(responses))
+	for i, resp := range responses {
+		codes[i] = resp["error"].(map[string]any)["code"].(float64)
+	}
+	require.Equal(t, []float64{CodeMethodNotFound, CodeInvalidParams, CodeInvalidParams, CodeParseError, CodeInvalidRequest}, codes)
+	require.Nil(t, responses[3]["id"])
+}
+
+func TestServer_Batch(t *testing.T) {
+	s := newTestServer(t)
+
+	var out bytes.Buffer
+	err := s.Serve(strings.NewReader(`[{"jsonrpc":"2.0","id":1,"method":"status"},{"jsonrpc":"2.0","method":"status"},{"jsonrpc":"2.0","id":2,"method":"status"}]`), &out)
+	require.NoError(t, err)
+
+	var batch []Response
+	require.NoError(t, json.Unmarshal(out.Bytes(), &batch))
+	require.Len(t, batch, 2)
+	require.JSONEq(t, "1", string(batch[0].ID))
+	require.JSONEq(t, "2", string(batch[1].ID))
+}
@@ -0,0 +1,401 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/dashboard"
+	"github.com/tduyng/codeme/metrics"
+	"github.com/tduyng/codeme/stats"
+)
+
+const DefaultAddr = "127.0.0.1:7317"
+
+type Config struct {
+	// Token, when set, must be sent as "Authorization: Bearer <token>".
+	Token string
+	// ReadOnly rejects heartbeats; the storage should be opened read-only too.
+	ReadOnly bool
+	// Dashboard serves the web dashboard at /.
+	Dashboard bool
+	// OnTrack is called after each tracked heartbeat.
+	OnTrack func(core.Activity)
+}
+
+// Server exposes Calculator.CalculateAPI over HTTP. Stats are computed one
+// request at a time and cached by the calculator; the cache is dropped
+// whenever the database changes, which is also what the ETags track.
+type Server struct {
+	storage  *core.SQLiteStorage
+	tracker  *core.Tracker
+	calc     *stats.Calculator
+	cfg      Config
+	timezone *time.Location
+	mux      *http.ServeMux
+
+	mu          sync.Mutex
+	dataVersion int64
+	writes      int64
+	wakaLines   map[string]wakaFile
+	wakaPruned  time.Time
+}
+
+func New(storage *core.SQLiteStorage, calc *stats.Calculator, cfg Config) *Server {
+	tracker := core.NewTracker(storage)
+	tracker.SetCategorizer(stats.InferCategory)
+
+	s := &Server{
+		storage:   storage,
+		tracker:   tracker,
+		calc:      calc,
+		cfg:       cfg,
+		timezone:  time.Local,
+		mux:       http.NewServeMux(),
+		wakaLines: make(map[string]wakaFile),
+	}
+
+	s.mux.HandleFunc("GET /api/stats", s.cached(s.handleStats))
+	s.mux.HandleFunc("GET /api/today", s.cached(s.handleToday))
+	s.mux.HandleFunc("GET /api/projects/{name}", s.cached(s.handleProject))
+	s.mux.HandleFunc("GET /api/languages", s.cached(s.handleLanguages))
+	s.mux.HandleFunc("GET /api/sessions", s.cached(s.handleSessions))
+	s.mux.HandleFunc("GET /api/heatmap", s.cached(s.handleHeatmap))
+	s.mux.HandleFunc("POST /api/heartbeat", s.handleHeartbeat)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	s.registerWakaTime()
+	if cfg.Dashboard {
+		s.mux.Handle("GET /", dashboard.Handler())
+	}
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	public := s.cfg.Dashboard && dashboard.IsAsset(r.URL.Path)
+	if s.cfg.Token != "" && !public && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="codeme"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized accepts the token as a bearer token, or the way WakaTime
+// plugins send their API key: HTTP Basic (key as user or password) or an
+// api_key query parameter.
+func (s *Server) authorized(r *http.Request) bool {
+	var candidates []string
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		candidates = append(candidates, token)
+	}
+	if encoded, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Basic "); ok {
+		if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			user, pass, _ := strings.Cut(string(decoded), ":")
+			candidates = append(candidates, user, pass)
+		}
+	}
+	if key := r.URL.Query().Get("api_key"); key != "" {
+		candidates = append(candidates, key)
+	}
+
+	for _, c := range candidates {
+		if subtle.ConstantTimeCompare([]byte(c), []byte(s.cfg.Token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// httpError carries a status code out of a handler.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...any) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// cached wraps a read handler with ETag handling. The tag combines the
+// database's data_version (bumped by writes from other processes), the
+// number of heartbeats this server wrote itself, and the date, since
+// "today" and streaks move at midnight without any write.
+func (s *Server) cached(fn func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		etag, err := s.etag()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "no-cache")
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		result, err := fn(r)
+		if err != nil {
+			var he *httpError
+			if errors.As(err, &he) {
+				writeError(w, he.status, he.message)
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func (s *Server) etag() (string, error) {
+	version, err := s.storage.DataVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to read data version: %w", err)
+	}
+	if version != s.dataVersion {
+		s.dataVersion = version
+		s.calc.Invalidate()
+	}
+	return fmt.Sprintf(`W/"%d-%d-%s"`, version, s.writes, time.Now().In(s.timezone).Format("20060102")), nil
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// queryFilter reads an activity filter from the machine, project, language,
+// editor, branch and file query parameters.
+func queryFilter(r *http.Request) core.ActivityFilter {
+	q := r.URL.Query()
+	return core.ActivityFilter{
+		Machine:  q.Get("machine"),
+		Project:  q.Get("project"),
+		Language: q.Get("language"),
+		Editor:   q.Get("editor"),
+		Branch:   q.Get("branch"),
+		File:     q.Get("file"),
+	}
+}
+
+func (s *Server) calculate(r *http.Request) (*stats.APIStats, error) {
+	opts := stats.APIOptions{Filter: queryFilter(r)}
+	if days := r.URL.Query().Get("days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return nil, badRequest("invalid days: %q", days)
+		}
+		opts.LoadRecentDays = n
+	}
+	if name := r.URL.Query().Get("period"); slices.Contains(stats.NamedPeriods, name) {
+		opts.Periods = []stats.PeriodRequest{{Name: name}}
+	}
+	return s.calc.CalculateAPI(s.storage, opts)
+}
+
+func (s *Server) handleStats(r *http.Request) (any, error) {
+	return s.calculate(r)
+}
+
+func (s *Server) handleToday(r *http.Request) (any, error) {
+	result, err := s.calculate(r)
+	if err != nil {
+		return nil, err
+	}
+	return result.Today, nil
+}
+
+type projectResponse struct {
+	Name      string                 `json:"name"`
+	Today     *stats.APIProjectStats `json:"today,omitempty"`
+	ThisWeek  *stats.APIProjectStats `json:"this_week,omitempty"`
+	ThisMonth *stats.APIProjectStats `json:"this_month,omitempty"`
+	AllTime   stats.APIProjectStats  `json:"all_time"`
+}
+
+func findProject(projects []stats.APIProjectStats, name string) *stats.APIProjectStats {
+	for i := range projects {
+		if projects[i].Name == name {
+			return &projects[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleProject(r *http.Request) (any, error) {
+	result, err := s.calculate(r)
+	if err != nil {
+		return nil, err
+	}
+
+	name := r.PathValue("name")
+	allTime := findProject(result.AllTime.Projects, name)
+	if allTime == nil {
+		return nil, &httpError{status: http.StatusNotFound, message: fmt.Sprintf("project not found: %s", name)}
+	}
+
+	return projectResponse{
+		Name:      name,
+		Today:     findProject(result.Today.Projects, name),
+		ThisWeek:  findProject(result.ThisWeek.Projects, name),
+		ThisMonth: findProject(result.ThisMonth.Projects, name),
+		AllTime:   *allTime,
+	}, nil
+}
+
+func (s *Server) handleLanguages(r *http.Request) (any, error) {
+	result, err := s.calculate(r)
+	if err != nil {
+		return nil, err
+	}
+
+	name := r.URL.Query().Get("period")
+	period, ok := result.Period(name)
+	if !ok {
+		return nil, badRequest("unknown period: %q", name)
+	}
+	return period.Languages, nil
+}
+
+func (s *Server) handleHeatmap(r *http.Request) (any, error) {
+	result, err := s.calculate(r)
+	if err != nil {
+		return nil, err
+	}
+	return result.WeeklyHeatmap, nil
+}
+
+// handleSessions returns the sessions that started between from and to
+// (YYYY-MM-DD, inclusive). It defaults to the last seven days.
+func (s *Server) handleSessions(r *http.Request) (any, error) {
+	now := time.Now().In(s.timezone)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.timezone)
+	from := to.AddDate(0, 0, -6)
+
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, s.timezone); err != nil {
+			return nil, badRequest("invalid from: %q", v)
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, s.timezone); err != nil {
+			return nil, badRequest("invalid to: %q", v)
+		}
+	}
+	if to.Before(from) {
+		return nil, badRequest("to is before from")
+	}
+	end := to.AddDate(0, 0, 1)
+
+	storage := s.storage.WithFilter(queryFilter(r))
+	activities, err := storage.GetActivitiesSince(from)
+	if err != nil {
+		return nil, err
+	}
+
+	_, sessions := stats.NewSessionManager(0, 0).GroupAndCalculate(activities)
+
+	var inRange []core.Session
+	for _, sess := range sessions {
+		if sess.StartTime.Before(end) {
+			inRange = append(inRange, sess)
+		}
+	}
+	return stats.ConvertSessionsToAPI(inRange), nil
+}
+
+// handleMetrics serves Prometheus text, or OpenMetrics when the scraper's
+// Accept header asks for it. It is not ETag-cached; scrapers do not send
+// If-None-Match.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.etag(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	collected, err := metrics.Collect(s.storage, s.calc)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	format := metrics.NegotiateFormat(r.Header.Get("Accept"))
+	w.Header().Set("Content-Type", format.ContentType())
+	metrics.Write(w, collected, format)
+}
+
+type heartbeatResponse struct {
+	ID string `json:"id"`
+}
+
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.ReadOnly {
+		writeError(w, http.StatusForbidden, "server is read-only")
+		return
+	}
+
+	var hb core.Heartbeat
+	if err := json.NewDecoder(r.Body).Decode(&hb); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid heartbeat: %v", err))
+		return
+	}
+	if hb.File == "" {
+		writeError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	activity := hb.Activity()
+	if err := s.tracker.TrackActivity(activity); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.writes++
+	s.calc.Invalidate()
+	if s.cfg.OnTrack != nil {
+		s.cfg.OnTrack(activity)
+	}
+
+	writeJSON(w, http.StatusCreated, heartbeatResponse{ID: activity.ID})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+func newTestServer(t *testing.T, cfg Config) (*Server, *core.SQLiteStorage, string) {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	storage, err := core.NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { storage.Close() })
+
+	return New(storage, stats.NewCalculator(time.Local), cfg), storage, dbPath
+}
+
+func do(t *testing.T, h http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &v))
+	return v
+}
+
+func TestServer_HeartbeatAndStats(t *testing.T) {
+	s, _, _ := newTestServer(t, Config{})
+
+	rec := do(t, s, "POST", "/api/heartbeat", `{"file":"/work/codeme/main.go","language":"go","lines":10}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NotEmpty(t, decode[map[string]string](t, rec)["id"])
+
+	rec = do(t, s, "POST", "/api/heartbeat", `{"file":"/work/codeme/api.go","language":"go","lines":5}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = do(t, s, "GET", "/api/today", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	today := decode[stats.APIPeriodStats](t, rec)
+	require.Equal(t, 15, today.TotalLines)
+
+	rec = do(t, s, "GET", "/api/languages?period=today", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	languages := decode[[]stats.APILanguageStats](t, rec)
+	require.Len(t, languages, 1)
+	require.Equal(t, "go", languages[0].Name)
+
+	rec = do(t, s, "GET", "/api/languages?period=last_7_days", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, decode[[]stats.APILanguageStats](t, rec), 1)
+
+	rec = do(t, s, "GET", "/api/projects/codeme", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	project := decode[map[string]any](t, rec)
+	require.Equal(t, "codeme", project["name"])
+	require.NotNil(t, project["today"])
+
+	rec = do(t, s, "GET", "/api/projects/missing", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(t, s, "GET", "/api/sessions?from=2000-01-01", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, s, "GET", "/api/heatmap", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, s, "GET", "/api/stats?days=30", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "last_30_days", decode[stats.APIStats](t, rec).Meta.DataWindow)
+}
+
+func TestServer_BadRequests(t *testing.T) {
+	s, _, _ := newTestServer(t, Config{})
+
+	require.Equal(t, http.StatusBadRequest, do(t, s, "GET", "/api/stats?days=abc", "").Code)
+	require.Equal(t, http.StatusBadRequest, do(t, s, "GET", "/api/languages?period=decade", "").Code)
+	require.Equal(t, http.StatusBadRequest, do(t, s, "GET", "/api/sessions?from=yesterday", "").Code)
+	require.Equal(t, http.StatusBadRequest, do(t, s, "GET", "/api/sessions?from=2025-02-01&to=2025-01-01", "").Code)
+	require.Equal(t, http.StatusBadRequest, do(t, s, "POST", "/api/heartbeat", `{"lines":1}`).Code)
+	require.Equal(t, http.StatusBadRequest, do(t, s, "POST", "/api/heartbeat", `not json`).Code)
+	require.Equal(t, http.StatusMethodNotAllowed, do(t, s, "GET", "/api/heartbeat", "").Code)
+}
+
+func TestServer_ETag(t *testing.T) {
+	s, _, dbPath := newTestServer(t, Config{})
+
+	rec := do(t, s, "GET", "/api/today", "")
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	rec = do(t, s, "GET", "/api/today", "", "If-None-Match", etag)
+	require.Equal(t, http.StatusNotModified, rec.Code)
+	require.Empty(t, rec.Body.String())
+
+	// Writes through the server change the tag.
+	do(t, s, "POST", "/api/heartbeat", `{"file":"/p/a.go"}`)
+	rec = do(t, s, "GET", "/api/today", "", "If-None-Match", etag)
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag = rec.Header().Get("ETag")
+
+	// So do writes from another process, e.g. a shell hook.
+	other, err := core.NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, core.NewTracker(other).TrackFileActivity("/p/b.go", "go", "vim", 3, true))
+	require.NoError(t, other.Close())
+
+	rec = do(t, s, "GET", "/api/today", "", "If-None-Match", etag)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotEqual(t, etag, rec.Header().Get("ETag"))
+	require.Equal(t, 3, decode[stats.APIPeriodStats](t, rec).TotalLines, "cached stats are dropped on external writes")
+}
+
+func TestServer_TokenAndReadOnly(t *testing.T) {
+	s, _, _ := newTestServer(t, Config{Token: "secret", ReadOnly: true})
+
+	rec := do(t, s, "GET", "/api/today", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+
+	require.Equal(t, http.StatusUnauthorized, do(t, s, "GET", "/api/today", "", "Authorization", "Bearer wrong").Code)
+	require.Equal(t, http.StatusOK, do(t, s, "GET", "/api/today", "", "Authorization", "Bearer secret").Code)
+
+	rec = do(t, s, "POST", "/api/heartbeat", `{"file":"/p/a.go"}`, "Authorization", "Bearer secret")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s, _, _ := newTestServer(t, Config{})
+
+	rec := do(t, s, "POST", "/api/heartbeat", `{"file":"/work/codeme/main.go","language":"go","lines":10}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = do(t, s, "GET", "/metrics", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	require.Contains(t, rec.Body.String(), "codeme_today_lines 10\n")
+	require.Contains(t, rec.Body.String(), `codeme_language_today_seconds{language="go"}`)
+	require.Contains(t, rec.Body.String(), "codeme_activities_total 1\n")
+
+	rec = do(t, s, "GET", "/metrics", "", "Accept", "application/openmetrics-text; version=1.0.0")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Header().Get("Content-Type"), "application/openmetrics-text")
+	require.Contains(t, rec.Body.String(), "# TYPE codeme_activities counter\n")
+	require.True(t, strings.HasSuffix(rec.Body.String(), "# EOF\n"))
+}
+
+func TestServer_Dashboard(t *testing.T) {
+	s, _, _ := newTestServer(t, Config{})
+	rec := do(t, s, "GET", "/", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	s, _, _ = newTestServer(t, Config{Token: "secret", ReadOnly: true, Dashboard: true})
+
+	rec = do(t, s, "GET", "/", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "codeme")
+
+	rec = do(t, s, "GET", "/app.js", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, s, "GET", "/api/stats", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = do(t, s, "GET", "/api/stats?api_key=secret", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+}
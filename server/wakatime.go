@@ -0,0 +1,362 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/stats"
+)
+
+// WakaTime plugins append /users/current/... to their configured api_url,
+// which normally ends in /api/v1. The bare paths are served too.
+var wakaPrefixes = []string{"/api/v1", ""}
+
+func (s *Server) registerWakaTime() {
+	for _, prefix := range wakaPrefixes {
+		base := prefix + "/users/current"
+		s.mux.HandleFunc("POST "+base+"/heartbeats", s.handleWakaHeartbeat)
+		s.mux.HandleFunc("POST "+base+"/heartbeats.bulk", s.handleWakaHeartbeatBulk)
+		s.mux.HandleFunc("GET "+base+"/statusbar/today", s.cached(s.handleWakaStatusBar))
+		s.mux.HandleFunc("GET "+base+"/status_bar/today", s.cached(s.handleWakaStatusBar))
+	}
+}
+
+// wakaHeartbeat is the subset of the WakaTime heartbeat fields codeme uses.
+type wakaHeartbeat struct {
+	Entity        string  `json:"entity"`
+	Type          string  `json:"type"`
+	Category      string  `json:"category"`
+	Time          float64 `json:"time"`
+	Project       string  `json:"project"`
+	Branch        string  `json:"branch"`
+	Language      string  `json:"language"`
+	Lines         *int    `json:"lines"`
+	LineAdditions *int    `json:"line_additions"`
+	LineDeletions *int    `json:"line_deletions"`
+	IsWrite       bool    `json:"is_write"`
+	UserAgent     string  `json:"user_agent"`
+}
+
+type wakaHeartbeatData struct {
+	ID     string  `json:"id"`
+	Entity string  `json:"entity"`
+	Type   string  `json:"type"`
+	Time   float64 `json:"time"`
+}
+
+var wakaCategories = map[string]string{
+	"coding":         core.CategoryCoding,
+	"debugging":      core.CategoryDebugging,
+	"building":       core.CategoryBuilding,
+	"code reviewing": core.CategoryReviewing,
+	"writing tests":  core.CategoryTesting,
+	"running tests":  core.CategoryTesting,
+	"manual testing": core.CategoryTesting,
+	"writing docs":   core.CategoryDocs,
+	"meeting":        core.CategoryMeeting,
+}
+
+// parseWakaUserAgent extracts the editor from a wakatime-cli user agent such
+// as "wakatime/v1.90.0 (linux-6.5-x86_64) go1.21.5 vscode/1.85.1
+// vscode-wakatime/24.4.0". The editor is the product right before the
+// "-wakatime" plugin, or the plugin name itself when it stands alone.
+func parseWakaUserAgent(ua string) string {
+	var products []string
+	for _, field := range strings.Fields(ua) {
+		name, _, ok := strings.Cut(field, "/")
+		if !ok || strings.HasPrefix(field, "(") || name == "wakatime" {
+			continue
+		}
+		products = append(products, strings.ToLower(name))
+	}
+
+	for i, name := range products {
+		plugin, ok := strings.CutSuffix(name, "-wakatime")
+		if !ok {
+			continue
+		}
+		if i > 0 {
+			return products[i-1]
+		}
+		return plugin
+	}
+	if len(products) > 0 {
+		return products[len(products)-1]
+	}
+	return "wakatime"
+}
+
+// activity converts a heartbeat. Lines changed come from line_additions and
+// line_deletions when the plugin sends them; older plugins only send the
+// file's total line count, so the change is the difference to the previous
+// heartbeat for the same file.
+func (s *Server) wakaActivity(hb wakaHeartbeat, r *http.Request) core.Activity {
+	ua := hb.UserAgent
+	if ua == "" {
+		ua = r.Header.Get("User-Agent")
+	}
+
+	activity := core.Activity{
+		ID:       core.GenerateID(),
+		Project:  hb.Project,
+		Branch:   hb.Branch,
+		Language: strings.ToLower(hb.Language),
+		Editor:   parseWakaUserAgent(ua),
+		Machine:  r.Header.Get("X-Machine-Name"),
+		IsWrite:  hb.IsWrite,
+	}
+
+	if hb.Time > 0 {
+		sec, frac := math.Modf(hb.Time)
+		activity.Timestamp = time.Unix(int64(sec), int64(frac*1e9))
+	}
+
+	category := strings.ToLower(strings.TrimSpace(hb.Category))
+	if mapped, ok := wakaCategories[category]; ok {
+		activity.Category = mapped
+	} else {
+		activity.Category = category
+	}
+
+	// Domains and apps are not files on this machine; keep them out of file
+	// and project detection.
+	if hb.Type == "" || hb.Type == "file" {
+		activity.File = hb.Entity
+	} else {
+		if activity.Project == "" {
+			activity.Project = "unknown"
+		}
+		if activity.Language == "" {
+			activity.Language = "unknown"
+		}
+	}
+
+	switch {
+	case hb.LineAdditions != nil || hb.LineDeletions != nil:
+		if hb.LineAdditions != nil {
+			activity.Lines += *hb.LineAdditions
+		}
+		if hb.LineDeletions != nil {
+			activity.Lines += *hb.LineDeletions
+		}
+	case hb.Lines != nil && activity.File != "":
+		activity.Lines = s.lineDelta(activity.File, *hb.Lines, time.Now())
+	}
+
+	return activity
+}
+
+// wakaLinesIdle is how long the line count of a file no heartbeat mentions
+// is kept.
+const wakaLinesIdle = 12 * time.Hour
+
+// wakaFile is the last line count reported for a file.
+type wakaFile struct {
+	lines int
+	seen  time.Time
+}
+
+// lineDelta records the line count of file and returns how much it changed
+// since the last one, 0 for a file not seen yet. Files idle for
+// wakaLinesIdle are forgotten, looking for them at most that often.
+func (s *Server) lineDelta(file string, lines int, now time.Time) int {
+	if now.Sub(s.wakaPruned) >= wakaLinesIdle {
+		for f, wf := range s.wakaLines {
+			if now.Sub(wf.seen) >= wakaLinesIdle {
+				delete(s.wakaLines, f)
+			}
+		}
+		s.wakaPruned = now
+	}
+
+	prev, ok := s.wakaLines[file]
+	s.wakaLines[file] = wakaFile{lines: lines, seen: now}
+	if !ok {
+		return 0
+	}
+	delta := lines - prev.lines
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta
+}
+
+func (s *Server) trackWaka(hb wakaHeartbeat, r *http.Request) (wakaHeartbeatData, error) {
+	if hb.Entity == "" {
+		return wakaHeartbeatData{}, badRequest("entity is required")
+	}
+
+	activity := s.wakaActivity(hb, r)
+	if err := s.tracker.TrackActivity(activity); err != nil {
+		return wakaHeartbeatData{}, err
+	}
+	s.writes++
+	s.calc.Invalidate()
+	if s.cfg.OnTrack != nil {
+		s.cfg.OnTrack(activity)
+	}
+
+	return wakaHeartbeatData{ID: activity.ID, Entity: hb.Entity, Type: hb.Type, Time: hb.Time}, nil
+}
+
+func (s *Server) handleWakaHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.ReadOnly {
+		writeError(w, http.StatusForbidden, "server is read-only")
+		return
+	}
+
+	var hb wakaHeartbeat
+	if err := json.NewDecoder(r.Body).Decode(&hb); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid heartbeat: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.trackWaka(hb, r)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if he, ok := err.(*httpError); ok {
+			status = he.status
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"data": data})
+}
+
+// handleWakaHeartbeatBulk answers like WakaTime: 202 with one
+// [body, status] pair per heartbeat, so one bad heartbeat does not make the
+// plugin resend the whole batch.
+func (s *Server) handleWakaHeartbeatBulk(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.ReadOnly {
+		writeError(w, http.StatusForbidden, "server is read-only")
+		return
+	}
+
+	var heartbeats []wakaHeartbeat
+	if err := json.NewDecoder(r.Body).Decode(&heartbeats); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid heartbeats: %v", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	responses := make([][]any, 0, len(heartbeats))
+	for _, hb := range heartbeats {
+		data, err := s.trackWaka(hb, r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if he, ok := err.(*httpError); ok {
+				status = he.status
+			}
+			responses = append(responses, []any{map[string]string{"error": err.Error()}, status})
+			continue
+		}
+		responses = append(responses, []any{map[string]any{"data": data}, http.StatusCreated})
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"responses": responses})
+}
+
+type wakaDuration struct {
+	Name         string  `json:"name,omitempty"`
+	TotalSeconds float64 `json:"total_seconds"`
+	Percent      float64 `json:"percent,omitempty"`
+	Digital      string  `json:"digital"`
+	Text         string  `json:"text"`
+	Hours        int     `json:"hours"`
+	Minutes      int     `json:"minutes"`
+	Decimal      string  `json:"decimal,omitempty"`
+}
+
+func newWakaDuration(name string, seconds, percent float64) wakaDuration {
+	total := int(seconds)
+	hours, minutes := total/3600, (total%3600)/60
+
+	var text string
+	switch {
+	case hours > 0 && minutes > 0:
+		text = fmt.Sprintf("%d hr%s %d min%s", hours, plural(hours), minutes, plural(minutes))
+	case hours > 0:
+		text = fmt.Sprintf("%d hr%s", hours, plural(hours))
+	case minutes > 0:
+		text = fmt.Sprintf("%d min%s", minutes, plural(minutes))
+	default:
+		text = fmt.Sprintf("%d sec%s", total, plural(total))
+	}
+
+	return wakaDuration{
+		Name:         name,
+		TotalSeconds: seconds,
+		Percent:      math.Round(percent*100) / 100,
+		Digital:      fmt.Sprintf("%d:%02d", hours, minutes),
+		Text:         text,
+		Hours:        hours,
+		Minutes:      minutes,
+	}
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+func (s *Server) handleWakaStatusBar(r *http.Request) (any, error) {
+	result, err := s.calc.CalculateAPI(s.storage, stats.APIOptions{})
+	if err != nil {
+		return nil, err
+	}
+	today := result.Today
+
+	grandTotal := newWakaDuration("", today.TotalTime, 0)
+	grandTotal.Decimal = fmt.Sprintf("%.2f", today.TotalTime/3600)
+
+	languages := make([]wakaDuration, 0, len(today.Languages))
+	for _, l := range today.Languages {
+		languages = append(languages, newWakaDuration(l.Name, l.Time, l.PercentTotal))
+	}
+	projects := make([]wakaDuration, 0, len(today.Projects))
+	for _, p := range today.Projects {
+		projects = append(projects, newWakaDuration(p.Name, p.Time, p.PercentTotal))
+	}
+	editors := make([]wakaDuration, 0, len(today.Editors))
+	for _, e := range today.Editors {
+		editors = append(editors, newWakaDuration(e.Name, e.Time, e.PercentTotal))
+	}
+	categories := make([]wakaDuration, 0, len(today.Categories))
+	for _, c := range today.Categories {
+		categories = append(categories, newWakaDuration(c.Name, c.Time, c.PercentTotal))
+	}
+	machines := make([]wakaDuration, 0, len(today.Machines))
+	for _, m := range today.Machines {
+		machines = append(machines, newWakaDuration(m.Name, m.Time, m.PercentTotal))
+	}
+
+	now := time.Now().In(s.timezone)
+	return map[string]any{
+		"data": map[string]any{
+			"grand_total": grandTotal,
+			"languages":   languages,
+			"projects":    projects,
+			"editors":     editors,
+			"categories":  categories,
+			"machines":    machines,
+			"range": map[string]string{
+				"date":     now.Format("2006-01-02"),
+				"text":     "Today",
+				"timezone": now.Location().String(),
+			},
+		},
+	}, nil
+}
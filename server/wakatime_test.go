@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestParseWakaUserAgent(t *testing.T) {
+	tests := []struct {
+		ua       string
+		expected string
+	}{
+		{"wakatime/v1.90.0 (linux-6.5.0-x86_64) go1.21.5 vscode/1.85.1 vscode-wakatime/24.4.0", "vscode"},
+		{"wakatime/v1.86.0 (darwin-23.1.0-arm64) go1.21.1 IntelliJ/2023.3 IntelliJ-wakatime/14.3.1", "intellij"},
+		{"wakatime/v1.86.0 (windows-10.0.22621-x86_64) go1.21.1 sublime_text/4169 sublime-wakatime/10.0.1", "sublime_text"},
+		{"chrome-wakatime/3.0.17", "chrome"},
+		{"curl/8.4.0", "curl"},
+		{"", "wakatime"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			require.Equal(t, tt.expected, parseWakaUserAgent(tt.ua))
+		})
+	}
+}
+
+func TestServer_WakaHeartbeats(t *testing.T) {
+	s, storage, _ := newTestServer(t, Config{})
+
+	now := float64(time.Now().Unix())
+	ua := "wakatime/v1.90.0 (linux) go1.21.5 vscode/1.85.1 vscode-wakatime/24.4.0"
+
+	rec := do(t, s, "POST", "/api/v1/users/current/heartbeats",
+		fmt.Sprintf(`{"entity":"/work/app/main.go","type":"file","time":%f,"project":"app","branch":"main","language":"Go","lines":100,"is_write":true,"user_agent":%q}`, now-60, ua),
+		"X-Machine-Name", "Desk.local")
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = do(t, s, "POST", "/users/current/heartbeats.bulk", fmt.Sprintf(`[
+		{"entity":"/work/app/main.go","type":"file","time":%f,"project":"app","language":"Go","lines":112,"category":"writing tests","user_agent":%q},
+		{"entity":"/work/app/api.go","type":"file","time":%f,"project":"app","language":"Go","line_additions":4,"line_deletions":2,"category":"code reviewing","user_agent":%q},
+		{"entity":"github.com","type":"domain","time":%f,"category":"browsing","user_agent":"chrome-wakatime/3.0.17"},
+		{"type":"file"}
+	]`, now-30, ua, now-20, ua, now-10), "X-Machine-Name", "desk")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	bulk := decode[map[string][][]any](t, rec)["responses"]
+	require.Len(t, bulk, 4)
+	require.Equal(t, float64(http.StatusCreated), bulk[0][1])
+	require.Equal(t, float64(http.StatusBadRequest), bulk[3][1])
+
+	activities, err := storage.GetActivitiesSince(time.Unix(int64(now)-3600, 0))
+	require.NoError(t, err)
+	require.Len(t, activities, 4)
+
+	first := activities[0]
+	require.Equal(t, "/work/app/main.go", first.File)
+	require.Equal(t, "go", first.Language)
+	require.Equal(t, "app", first.Project)
+	require.Equal(t, "main", first.Branch)
+	require.Equal(t, "vscode", first.Editor)
+	require.Equal(t, "desk", first.Machine)
+	require.Equal(t, 0, first.Lines, "no previous line count to diff against")
+	require.True(t, first.IsWrite)
+
+	require.Equal(t, 12, activities[1].Lines)
+	require.Equal(t, core.CategoryTesting, activities[1].Category)
+
+	require.Equal(t, 6, activities[2].Lines)
+	require.Equal(t, core.CategoryReviewing, activities[2].Category)
+
+	domain := activities[3]
+	require.Empty(t, domain.File)
+	require.Equal(t, "unknown", domain.Language)
+	require.Equal(t, "unknown", domain.Project)
+	require.Equal(t, "chrome", domain.Editor)
+	require.Equal(t, "browsing", domain.Category)
+}
+
+func TestServer_WakaStatusBar(t *testing.T) {
+	s, _, _ := newTestServer(t, Config{Token: "waka_key"})
+
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("waka_key"))
+	rec := do(t, s, "POST", "/api/v1/users/current/heartbeats",
+		`{"entity":"/work/app/main.go","type":"file","project":"app","language":"Go"}`, "Authorization", auth)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	require.Equal(t, http.StatusUnauthorized, do(t, s, "GET", "/api/v1/users/current/statusbar/today", "").Code)
+
+	rec = do(t, s, "GET", "/api/v1/users/current/statusbar/today?api_key=waka_key", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := decode[struct {
+		Data struct {
+			GrandTotal wakaDuration   `json:"grand_total"`
+			Projects   []wakaDuration `json:"projects"`
+		} `json:"data"`
+	}](t, rec)
+	require.Equal(t, 120.0, body.Data.GrandTotal.TotalSeconds)
+	require.Equal(t, "0:02", body.Data.GrandTotal.Digital)
+	require.Equal(t, "2 mins", body.Data.GrandTotal.Text)
+	require.Len(t, body.Data.Projects, 1)
+	require.Equal(t, "app", body.Data.Projects[0].Name)
+}
+
+func TestNewWakaDuration(t *testing.T) {
+	require.Equal(t, "1 hr 1 min", newWakaDuration("", 3660, 0).Text)
+	require.Equal(t, "2 hrs", newWakaDuration("", 7200, 0).Text)
+	require.Equal(t, "45 secs", newWakaDuration("", 45, 0).Text)
+	require.Equal(t, "1:01", newWakaDuration("", 3660, 0).Digital)
+}
+
+func TestServer_LineDeltaForgetsIdleFiles(t *testing.T) {
+	s, _, _ := newTestServer(t, Config{})
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 0, s.lineDelta("/a.go", 100, now))
+	require.Equal(t, 0, s.lineDelta("/b.go", 50, now))
+	require.Equal(t, 5, s.lineDelta("/a.go", 95, now.Add(time.Hour)))
+
+	later := now.Add(wakaLinesIdle + 30*time.Minute)
+	require.Equal(t, 10, s.lineDelta("/a.go", 105, later))
+	require.NotContains(t, s.wakaLines, "/b.go", "idle files are dropped")
+	require.Equal(t, 0, s.lineDelta("/b.go", 60, later))
+	require.Len(t, s.wakaLines, 2)
+}
@@ -0,0 +1,131 @@
+package shell
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var Supported = []string{"bash", "zsh", "fish"}
+
+// Init returns the hook script for a shell. The script calls binary with
+// the shell-track subcommand after every command, in the background so the
+// prompt never waits on the database.
+func Init(shell, binary string, withArgs bool) (string, error) {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashHook
+	case "zsh":
+		script = zshHook
+	case "fish":
+		script = fishHook
+	default:
+		return "", fmt.Errorf("unsupported shell %q (supported: %s)", shell, strings.Join(Supported, ", "))
+	}
+
+	track := quote(binary) + " shell-track"
+	if withArgs {
+		track += " --args"
+	}
+	return strings.ReplaceAll(script, "{{track}}", track), nil
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Prefixes that run another command; the program after them is the one that
+// gets recorded. Each lists its options that take a value, so "nice -n 10"
+// or "sudo -u root" are skipped whole.
+var wrappers = map[string][]string{
+	"sudo": {"-u", "-g", "-h", "-p", "-C", "-D", "-r", "-t", "-T", "-U"},
+	"doas": {"-u", "-C"}, "env": {"-u", "-C", "-S"}, "time": {"-f", "-o"},
+	"nice": {"-n"}, "nohup": nil, "command": nil, "builtin": nil,
+	"exec": {"-a"}, "noglob": nil, "nocorrect": nil,
+}
+
+// ParseCommand splits a command line into the program and its arguments,
+// skipping leading VAR=value assignments and wrappers such as sudo.
+func ParseCommand(line string) (string, []string) {
+	fields := strings.Fields(line)
+	var options []string
+	wrapped := false
+	for len(fields) > 0 {
+		f := fields[0]
+		switch opts, ok := wrappers[f]; {
+		case ok:
+			options, wrapped = opts, true
+		case wrapped && strings.HasPrefix(f, "-") && len(fields) > 1:
+			if slices.Contains(options, f) {
+				fields = fields[1:]
+			}
+		case strings.Contains(f, "=") && !strings.HasPrefix(f, "="):
+		default:
+			return filepath.Base(f), fields[1:]
+		}
+		fields = fields[1:]
+	}
+	return "", nil
+}
+
+// Full-screen programs stay open while the user works elsewhere (editors
+// report their own activity), so their run time is not counted.
+var interactive = map[string]bool{
+	"vim": true, "nvim": true, "vi": true, "emacs": true, "nano": true,
+	"hx": true, "helix": true, "code": true, "less": true, "more": true,
+	"man": true, "tmux": true, "screen": true, "zellij": true, "top": true,
+	"htop": true, "btop": true, "watch": true,
+}
+
+func IsInteractive(program string) bool {
+	return interactive[program]
+}
+
+const bashHook = `# codeme shell integration (bash)
+_codeme_ready=0
+_codeme_preexec() {
+    [ "$_codeme_ready" = 1 ] || return
+    [ -n "$COMP_LINE" ] && return
+    _codeme_ready=0
+    _codeme_cmd=$BASH_COMMAND
+    _codeme_start=$SECONDS
+}
+_codeme_precmd() {
+    if [ -n "$_codeme_cmd" ]; then
+        ({{track}} --exit "$_codeme_status" --duration "$((SECONDS - _codeme_start))" --cwd "$PWD" -- "$_codeme_cmd" >/dev/null 2>&1 &)
+    fi
+    _codeme_cmd=
+    _codeme_ready=1
+}
+trap '_codeme_preexec' DEBUG
+PROMPT_COMMAND="_codeme_status=\$?${PROMPT_COMMAND:+; $PROMPT_COMMAND}; _codeme_precmd"
+`
+
+const zshHook = `# codeme shell integration (zsh)
+zmodload zsh/datetime
+_codeme_preexec() {
+    _codeme_cmd=$1
+    _codeme_start=$EPOCHREALTIME
+}
+_codeme_precmd() {
+    local exit_code=$?
+    [[ -z $_codeme_cmd ]] && return
+    local duration=$(( EPOCHREALTIME - _codeme_start ))
+    ({{track}} --exit $exit_code --duration $duration --cwd "$PWD" -- "$_codeme_cmd" >/dev/null 2>&1 &)
+    _codeme_cmd=
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec _codeme_preexec
+add-zsh-hook precmd _codeme_precmd
+`
+
+const fishHook = `# codeme shell integration (fish)
+function __codeme_postexec --on-event fish_postexec
+    set -l exit_code $status
+    test -n "$argv[1]"; or return
+    {{track}} --exit $exit_code --duration (math "$CMD_DURATION / 1000") --cwd "$PWD" -- "$argv[1]" >/dev/null 2>&1 &
+    disown 2>/dev/null
+end
+`
@@ -0,0 +1,54 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit(t *testing.T) {
+	for _, sh := range Supported {
+		t.Run(sh, func(t *testing.T) {
+			script, err := Init(sh, "/opt/my tools/codeme", false)
+			require.NoError(t, err)
+			require.Contains(t, script, "'/opt/my tools/codeme' shell-track --exit")
+			require.NotContains(t, script, "{{track}}")
+			require.NotContains(t, script, "--args")
+		})
+	}
+
+	script, err := Init("zsh", "codeme", true)
+	require.NoError(t, err)
+	require.Contains(t, script, "'codeme' shell-track --args")
+
+	_, err = Init("tcsh", "codeme", false)
+	require.Error(t, err)
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line    string
+		program string
+		args    []string
+	}{
+		{"git status", "git", []string{"status"}},
+		{"  kubectl   get pods ", "kubectl", []string{"get", "pods"}},
+		{"GOOS=linux go build ./...", "go", []string{"build", "./..."}},
+		{"sudo -E docker compose up", "docker", []string{"compose", "up"}},
+		{"time /usr/local/bin/cargo test", "cargo", []string{"test"}},
+		{"nice -n 10 make -j4", "make", []string{"-j4"}},
+		{"sudo -u deploy -E ./deploy.sh", "deploy.sh", []string{}},
+		{"env -u HOME FOO=1 npm test", "npm", []string{"test"}},
+		{"-x", "-x", []string{}},
+		{"", "", nil},
+		{"FOO=1", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			program, args := ParseCommand(tt.line)
+			require.Equal(t, tt.program, program)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
@@ -1,104 +1,452 @@
 package stats
 
 import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/util"
 )
 
+// AchievementConfig defines an achievement by a rule, see rules.go. With
+// Tiers it defines a ladder of achievements instead, one per target.
 type AchievementConfig struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	Type        string `json:"type"`
-	Threshold   int    `json:"threshold,omitempty"`
 	Icon        string `json:"icon"`
-	Hours       []int  `json:"hours,omitempty"`
-	MinSession  int    `json:"min_session,omitempty"`
+	// Rule is a condition like `max_day(projects) >= 3`, or with Tiers the
+	// value compared with each target, like `lines`.
+	Rule  string            `json:"rule"`
+	Tiers []AchievementTier `json:"tiers,omitempty"`
+	// Context describes an unlock, with {value} replaced by the rule's
+	// value, e.g. "{value} lines".
+	Context string `json:"context,omitempty"`
+	// Hidden achievements show as secret until unlocked.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// AchievementTier is one step of a tiered achievement. Empty fields are
+// taken from the achievement.
+type AchievementTier struct {
+	ID          string     `json:"id,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Icon        string     `json:"icon,omitempty"`
+	Target      RuleTarget `json:"target"`
+}
+
+// RuleTarget is a tier's target: a number, or a rule like "10h" or "5k".
+type RuleTarget string
+
+func (t *RuleTarget) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*t = RuleTarget(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("target must be a number or a string like \"10h\": %w", err)
+	}
+	*t = RuleTarget(s)
+	return nil
 }
 
 var AchievementConfigs = []AchievementConfig{
-	{ID: "streak_5", Name: "5-Day Fire", Description: "Code for 5 days in a row", Type: "streak", Threshold: 5, Icon: "🔥"},
-	{ID: "streak_30", Name: "30-Day Streak", Description: "Code consistently for 30 days", Type: "streak", Threshold: 30, Icon: "🧨"},
-	{ID: "streak_90", Name: "90-Day Inferno", Description: "Maintain a 90-day coding streak", Type: "streak", Threshold: 90, Icon: "💥"},
-	{ID: "streak_180", Name: "180-Day Blaze", Description: "Code for 180 consecutive days", Type: "streak", Threshold: 180, Icon: "🌋"},
-	{ID: "streak_365", Name: "365-Day Eternal Flame", Description: "Maintain a full year coding streak", Type: "streak", Threshold: 365, Icon: "🌞"},
-	{ID: "lines_1000", Name: "1K Line Wave", Description: "Write 1,000 lines of code", Type: "lines", Threshold: 1000, Icon: "🌧️"},
-	{ID: "lines_10000", Name: "10K Line Surge", Description: "Write 10,000 lines of code", Type: "lines", Threshold: 10000, Icon: "⚡"},
-	{ID: "lines_50000", Name: "50K Line Flood", Description: "Write 50,000 lines of code", Type: "lines", Threshold: 50000, Icon: "⛈️"},
-	{ID: "lines_100000", Name: "100K Line Ocean", Description: "Write 100,000 lines of code", Type: "lines", Threshold: 100000, Icon: "🌊"},
-	{ID: "hours_50", Name: "50h Spark", Description: "Code for 50 hours total", Type: "hours", Threshold: 180000, Icon: "⚡"},
-	{ID: "hours_1000", Name: "1K h Lightning", Description: "Code for 1000 hours total", Type: "hours", Threshold: 3600000, Icon: "🌩️"},
-	{ID: "hours_5000", Name: "5K h Thunder", Description: "Code for 5000 hours total", Type: "hours", Threshold: 18000000, Icon: "⛈️"},
-	{ID: "hours_10000", Name: "10K h Mastery", Description: "Code for 10000 hours total", Type: "hours", Threshold: 36000000, Icon: "🌀"},
-	{ID: "hours_20000", Name: "20K h Grandmaster", Description: "Code for 20000 hours total", Type: "hours", Threshold: 720000000, Icon: "💡"},
-	{ID: "polyglot_2", Name: "Bilingual", Description: "Code in 2 different languages", Type: "languages", Threshold: 2, Icon: "🚀"},
-	{ID: "polyglot_5", Name: "Polyglot", Description: "Code in 5 different languages", Type: "languages", Threshold: 5, Icon: "🌍"},
-	{ID: "polyglot_10", Name: "Polyglot Master", Description: "Code in 10 different languages", Type: "languages", Threshold: 10, Icon: "🧠"},
-	{ID: "polyglot_15", Name: "Code Polymath", Description: "Code in 15 different languages", Type: "languages", Threshold: 15, Icon: "🎓"},
-	{ID: "early_bird", Name: "Dawn Coder", Description: "Code before 6 AM", Type: "habit", Hours: []int{4, 5}, Icon: "🌅"},
-	{ID: "night_owl", Name: "Night Coder", Description: "Code after midnight", Type: "habit", Hours: []int{0, 1, 2}, Icon: "🌌"},
-	{ID: "session_2h", Name: "2h Warm Up", Description: "Code for 2+ hours in a single session", Type: "session", MinSession: 7200, Icon: "☕"},
-	{ID: "session_4h", Name: "4h Focus", Description: "Code for 4+ hours in a single session", Type: "session", MinSession: 14400, Icon: "🎯"},
-	{ID: "session_6h", Name: "6h Flow State", Description: "Code for 6+ hours in a single session", Type: "session", MinSession: 21600, Icon: "🌊"},
-	{ID: "session_8h", Name: "8h Deep Work", Description: "Code for 8+ hours in a single session", Type: "session", MinSession: 28800, Icon: "🧠"},
-	{ID: "session_10h", Name: "10h Monk Mode", Description: "Code for 10+ hours in a single session", Type: "session", MinSession: 36000, Icon: "🧘‍♂️"},
-	{ID: "session_12h", Name: "12h Legendary", Description: "Code for 12+ hours in a single session", Type: "session", MinSession: 43200, Icon: "👑"},
+	{ID: "streak", Rule: "longest_streak", Context: "{value}-day streak", Tiers: []AchievementTier{
+		{ID: "streak_5", Name: "5-Day Fire", Description: "Code for 5 days in a row", Target: "5", Icon: "🔥"},
+		{ID: "streak_30", Name: "30-Day Streak", Description: "Code consistently for 30 days", Target: "30", Icon: "🧨"},
+		{ID: "streak_90", Name: "90-Day Inferno", Description: "Maintain a 90-day coding streak", Target: "90", Icon: "💥"},
+		{ID: "streak_180", Name: "180-Day Blaze", Description: "Code for 180 consecutive days", Target: "180", Icon: "🌋"},
+		{ID: "streak_365", Name: "365-Day Eternal Flame", Description: "Maintain a full year coding streak", Target: "365", Icon: "🌞"},
+	}},
+	{ID: "lines", Rule: "lines", Context: "{value} lines", Tiers: []AchievementTier{
+		{ID: "lines_1000", Name: "1K Line Wave", Description: "Write 1,000 lines of code", Target: "1k", Icon: "🌧️"},
+		{ID: "lines_10000", Name: "10K Line Surge", Description: "Write 10,000 lines of code", Target: "10k", Icon: "⚡"},
+		{ID: "lines_50000", Name: "50K Line Flood", Description: "Write 50,000 lines of code", Target: "50k", Icon: "⛈️"},
+		{ID: "lines_100000", Name: "100K Line Ocean", Description: "Write 100,000 lines of code", Target: "100k", Icon: "🌊"},
+	}},
+	{ID: "hours", Rule: "time", Context: "{value} coded", Tiers: []AchievementTier{
+		{ID: "hours_50", Name: "50h Spark", Description: "Code for 50 hours total", Target: "50h", Icon: "⚡"},
+		{ID: "hours_1000", Name: "1K h Lightning", Description: "Code for 1000 hours total", Target: "1000h", Icon: "🌩️"},
+		{ID: "hours_5000", Name: "5K h Thunder", Description: "Code for 5000 hours total", Target: "5000h", Icon: "⛈️"},
+		{ID: "hours_10000", Name: "10K h Mastery", Description: "Code for 10000 hours total", Target: "10000h", Icon: "🌀"},
+		{ID: "hours_20000", Name: "20K h Grandmaster", Description: "Code for 20000 hours total", Target: "20000h", Icon: "💡"},
+	}},
+	{ID: "polyglot", Rule: "languages", Context: "{value} languages", Tiers: []AchievementTier{
+		{ID: "polyglot_2", Name: "Bilingual", Description: "Code in 2 different languages", Target: "2", Icon: "🚀"},
+		{ID: "polyglot_5", Name: "Polyglot", Description: "Code in 5 different languages", Target: "5", Icon: "🌍"},
+		{ID: "polyglot_10", Name: "Polyglot Master", Description: "Code in 10 different languages", Target: "10", Icon: "🧠"},
+		{ID: "polyglot_15", Name: "Code Polymath", Description: "Code in 15 different languages", Target: "15", Icon: "🎓"},
+	}},
+	{ID: "early_bird", Name: "Dawn Coder", Description: "Code before 6 AM", Rule: "hour_time(4, 5) > 0", Context: "{value} before 6 AM", Icon: "🌅"},
+	{ID: "night_owl", Name: "Night Coder", Description: "Code after midnight", Rule: "hour_time(0, 1, 2) > 0", Context: "{value} after midnight", Icon: "🌌"},
+	{ID: "session", Rule: "longest_session", Context: "{value} session", Tiers: []AchievementTier{
+		{ID: "session_2h", Name: "2h Warm Up", Description: "Code for 2+ hours in a single session", Target: "2h", Icon: "☕"},
+		{ID: "session_4h", Name: "4h Focus", Description: "Code for 4+ hours in a single session", Target: "4h", Icon: "🎯"},
+		{ID: "session_6h", Name: "6h Flow State", Description: "Code for 6+ hours in a single session", Target: "6h", Icon: "🌊"},
+		{ID: "session_8h", Name: "8h Deep Work", Description: "Code for 8+ hours in a single session", Target: "8h", Icon: "🧠"},
+		{ID: "session_10h", Name: "10h Monk Mode", Description: "Code for 10+ hours in a single session", Target: "10h", Icon: "🧘‍♂️"},
+		{ID: "session_12h", Name: "12h Legendary", Description: "Code for 12+ hours in a single session", Target: "12h", Icon: "👑"},
+	}},
 }
 
-func CalculateAchievements(allTime APIPeriodStats, activities []core.Activity, streakInfo StreakInfo) []Achievement {
-	var achievements []Achievement
+// newAchievementWindow is how long an unlock counts as new.
+const newAchievementWindow = 24 * time.Hour
+
+const (
+	// paceWindow is the recent span whose progress estimates when locked
+	// achievements unlock.
+	paceWindow = 14 * 24 * time.Hour
+	// maxEstimate drops estimates too far away to mean anything.
+	maxEstimate = 10 * 365 * 24 * time.Hour
+)
+
+// achievementDef is a compiled achievement: unlocked when cond holds. When
+// cond compares value with target, value describes the unlock.
+type achievementDef struct {
+	Achievement
+	cond    ruleNode
+	value   ruleNode
+	target  ruleNode
+	context string
+}
+
+// defaultAchievements compiles AchievementConfigs on first use, once the
+// rule functions are registered.
+var defaultAchievements = sync.OnceValue(func() []achievementDef {
+	defs, err := compileAchievements(AchievementConfigs)
+	if err != nil {
+		panic(err)
+	}
+	return defs
+})
+
+// compileAchievements parses the rules of configs and checks them against
+// no activity, so that unknown metrics are reported up front.
+func compileAchievements(configs []AchievementConfig) ([]achievementDef, error) {
+	var defs []achievementDef
+	seen := make(map[string]bool)
+	empty := newAchievementEnv(APIPeriodStats{}, nil, StreakInfo{}, time.UTC)
+
+	for _, cfg := range configs {
+		if cfg.ID == "" {
+			return nil, fmt.Errorf("achievement without id")
+		}
+		rule, err := parseRule(cfg.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("achievement %s: %w", cfg.ID, err)
+		}
+
+		base := achievementDef{
+			Achievement: Achievement{ID: cfg.ID, Name: cfg.Name, Description: cfg.Description, Icon: cfg.Icon, Hidden: cfg.Hidden},
+			context:     cfg.Context,
+		}
+		var group []achievementDef
+		if len(cfg.Tiers) == 0 {
+			def := base
+			def.cond = rule
+			if c, ok := rule.(binaryNode); ok && (c.op == ">=" || c.op == ">" || c.op == "==") {
+				def.value, def.target = c.l, c.r
+			}
+			group = append(group, def)
+		}
+		for i, tier := range cfg.Tiers {
+			target, err := parseRule(string(tier.Target))
+			if err != nil {
+				return nil, fmt.Errorf("achievement %s tier %d: %w", cfg.ID, i+1, err)
+			}
+			def := base
+			def.Tier = i + 1
+			def.ID = cmp.Or(tier.ID, cfg.ID+"_"+strconv.Itoa(i+1))
+			def.Name = cmp.Or(tier.Name, fmt.Sprintf("%s %d", cfg.Name, i+1))
+			def.Description = cmp.Or(tier.Description, cfg.Description)
+			def.Icon = cmp.Or(tier.Icon, cfg.Icon)
+			def.value, def.target = rule, target
+			def.cond = binaryNode{op: ">=", l: rule, r: target}
+			group = append(group, def)
+		}
+
+		for _, def := range group {
+			if seen[def.ID] {
+				return nil, fmt.Errorf("duplicate achievement id: %s", def.ID)
+			}
+			seen[def.ID] = true
+			if _, err := def.cond.eval(empty); err != nil {
+				return nil, fmt.Errorf("achievement %s: %w", def.ID, err)
+			}
+			defs = append(defs, def)
+		}
+	}
+	return defs, nil
+}
 
-	langCount := 0
-	for _, lang := range allTime.Languages {
-		if IsCodeLanguage(lang.Name) {
-			langCount++
+// SetAchievements adds achievements to the built-in ones. One with the ID
+// of a built-in achievement replaces it.
+func (c *Calculator) SetAchievements(configs []AchievementConfig) error {
+	merged := append([]AchievementConfig(nil), AchievementConfigs...)
+	for _, cfg := range configs {
+		i := slices.IndexFunc(merged, func(m AchievementConfig) bool { return m.ID == cfg.ID })
+		if i >= 0 {
+			merged[i] = cfg
+		} else {
+			merged = append(merged, cfg)
 		}
 	}
+	defs, err := compileAchievements(merged)
+	if err != nil {
+		return err
+	}
+	c.achievements = defs
+	c.cache.Invalidate()
+	return nil
+}
 
-	hourlyActivity := AggregateByHour(activities, time.Local)
+func CalculateAchievements(allTime APIPeriodStats, activities []core.Activity, streakInfo StreakInfo) []Achievement {
+	return evaluateAchievements(defaultAchievements(), newAchievementEnv(allTime, activities, streakInfo, time.Local))
+}
 
-	for _, cfg := range AchievementConfigs {
-		unlocked := false
+// calculateAchievements evaluates the calculator's achievements and
+// estimates when the locked ones unlock, from the progress made since
+// paceWindow before now. days are the daily summaries, for past streaks.
+func (c *Calculator) calculateAchievements(allTime APIPeriodStats, activities []core.Activity, days []core.DailySummary, streakInfo StreakInfo, now time.Time) []Achievement {
+	achievements := evaluateAchievements(c.achievements, newAchievementEnv(allTime, activities, streakInfo, c.timezone))
 
-		switch cfg.Type {
-		case "streak":
-			unlocked = streakInfo.Current >= cfg.Threshold || streakInfo.Longest >= cfg.Threshold
+	since := now.Add(-paceWindow)
+	older := util.Filter(activities, func(a core.Activity) bool { return a.Timestamp.Before(since) })
+	recent := util.Filter(activities, func(a core.Activity) bool { return !a.Timestamp.Before(since) })
+	pastDays := util.Filter(days, func(d core.DailySummary) bool {
+		return util.DateFrom(d.Date, c.timezone).Before(util.StartOfDay(since, c.timezone))
+	})
+	pastStreak := NewStreakCalculator(c.timezone).CalculateDays(pastDays, since)
+	past := newAchievementEnv(allTimeBefore(allTime, recent, since), older, pastStreak, c.timezone)
 
-		case "lines":
-			unlocked = allTime.TotalLines >= cfg.Threshold
+	for i, def := range c.achievements {
+		a := &achievements[i]
+		if a.Unlocked || def.value == nil || a.Current >= a.Target {
+			continue
+		}
+		before, err := def.value.eval(past)
+		if err != nil {
+			continue
+		}
+		perSecond := (a.Current - before) / paceWindow.Seconds()
+		if perSecond <= 0 {
+			continue
+		}
+		remaining := (a.Target - a.Current) / perSecond
+		if remaining > maxEstimate.Seconds() {
+			continue
+		}
+		eta := now.Add(time.Duration(remaining * float64(time.Second))).Truncate(time.Second)
+		a.EstimatedAt = &eta
+	}
+	return achievements
+}
 
-		case "hours":
-			unlocked = allTime.TotalTime >= float64(cfg.Threshold)
+// allTimeBefore takes the recent activities out of allTime's totals, for
+// the totals as they were at since.
+func allTimeBefore(allTime APIPeriodStats, recent []core.Activity, since time.Time) APIPeriodStats {
+	past := allTime
+	langTime := make(map[string]float64)
+	langLines := make(map[string]int)
+	projectTime := make(map[string]float64)
+	for _, a := range recent {
+		past.TotalTime -= a.Duration
+		past.TotalLines -= a.Lines
+		langTime[a.Language] += a.Duration
+		langLines[a.Language] += a.Lines
+		projectTime[a.Project] += a.Duration
+	}
 
-		case "languages":
-			unlocked = langCount >= cfg.Threshold
+	past.Languages = nil
+	for _, l := range allTime.Languages {
+		l.Time -= langTime[l.Name]
+		l.Lines -= langLines[l.Name]
+		if _, touched := langTime[l.Name]; touched && l.Time < 1 && l.Lines <= 0 {
+			continue
+		}
+		past.Languages = append(past.Languages, l)
+	}
+	past.Projects = nil
+	for _, p := range allTime.Projects {
+		p.Time -= projectTime[p.Name]
+		if _, touched := projectTime[p.Name]; touched && p.Time < 1 {
+			continue
+		}
+		past.Projects = append(past.Projects, p)
+	}
+	past.Sessions = util.Filter(allTime.Sessions, func(s APISession) bool { return s.StartTime.Before(since) })
+	past.SessionCount -= len(allTime.Sessions) - len(past.Sessions)
+	return past
+}
 
-		case "habit":
-			for _, h := range cfg.Hours {
-				if h < len(hourlyActivity) && hourlyActivity[h].Duration > 0 {
-					unlocked = true
-					break
-				}
+func evaluateAchievements(defs []achievementDef, env *ruleEnv) []Achievement {
+	achievements := make([]Achievement, 0, len(defs))
+	for _, def := range defs {
+		a := def.Achievement
+		ok, err := def.cond.eval(env)
+		a.Unlocked = err == nil && ok != 0
+		if def.value != nil {
+			a.Current, _ = def.value.eval(env)
+			a.Target, _ = def.target.eval(env)
+			if def.value.isTime() {
+				a.Unit = "seconds"
 			}
-
-		case "session":
-			for _, s := range allTime.Sessions {
-				if s.Duration >= float64(cfg.MinSession) {
-					unlocked = true
-					break
-				}
+			if a.Unlocked {
+				a.Context = strings.ReplaceAll(cmp.Or(def.context, "{value}"), "{value}", formatRuleValue(a.Current, def.value.isTime()))
 			}
 		}
+		switch {
+		case a.Unlocked:
+			a.Progress = 100
+		case a.Target > 0:
+			a.Progress = min(100, a.Current/a.Target*100)
+		}
+		achievements = append(achievements, a)
+	}
+	return achievements
+}
+
+func formatRuleValue(v float64, isTime bool) string {
+	if isTime {
+		return util.FormatDuration(v)
+	}
+	if v == float64(int64(v)) {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+// newAchievementEnv builds what rules see: all-time totals, with each day
+// of activities for the functions that look at days and weeks.
+func newAchievementEnv(allTime APIPeriodStats, activities []core.Activity, streakInfo StreakInfo, tz *time.Location) *ruleEnv {
+	byDate := make(map[string]*ruleScope)
+	var days []*ruleScope
+	dayOf := func(t time.Time) *ruleScope {
+		date := util.DateString(t, tz)
+		d := byDate[date]
+		if d == nil {
+			d = newRuleScope(util.StartOfDay(t, tz))
+			d.activeDays = 1
+			byDate[date] = d
+			days = append(days, d)
+		}
+		return d
+	}
+
+	hours := [24]float64{}
+	for _, a := range activities {
+		d := dayOf(a.Timestamp)
+		d.time += a.Duration
+		d.lines += float64(a.Lines)
+		if a.File != "" {
+			d.files[a.File] = true
+		}
+		d.projects[a.Project] += a.Duration
+		if a.Language != "" {
+			l := d.language(a.Language)
+			l.time += a.Duration
+			l.lines += float64(a.Lines)
+		}
+		h := a.Timestamp.In(tz).Hour()
+		d.hours[h] += a.Duration
+		hours[h] += a.Duration
+	}
+	for _, s := range allTime.Sessions {
+		if s.StartTime.IsZero() {
+			continue
+		}
+		d := dayOf(s.StartTime)
+		d.sessions++
+		d.longestSession = max(d.longestSession, s.Duration)
+	}
+	slices.SortFunc(days, func(a, b *ruleScope) int { return a.start.Compare(b.start) })
+
+	all := newRuleScope(time.Time{})
+	all.time = allTime.TotalTime
+	all.lines = float64(allTime.TotalLines)
+	all.hours = hours
+	all.activeDays = len(days)
+	all.sessions = max(allTime.SessionCount, len(allTime.Sessions))
+	for _, l := range allTime.Languages {
+		lang := all.language(l.Name)
+		lang.time += l.Time
+		lang.lines += float64(l.Lines)
+	}
+	for _, p := range allTime.Projects {
+		all.projects[p.Name] += p.Time
+	}
+	for _, s := range allTime.Sessions {
+		all.longestSession = max(all.longestSession, s.Duration)
+	}
+	all.totalFiles = allTime.TotalFiles
+
+	return &ruleEnv{scope: all, days: days, streak: streakInfo, tz: tz}
+}
+
+// RecordAchievements makes unlocks sticky: achievements unlocked by now in
+// storage stay unlocked, and new unlocks are saved with now as their time.
+// Storages opened read-only can't save; their new unlocks are reported
+// without UnlockedAt until a writable storage saves them. firstActivity
+// tells whether the first unlocks saved were earned earlier, before
+// anything was recorded.
+func RecordAchievements(storage core.Storage, achievements []Achievement, firstActivity, now time.Time) []Achievement {
+	store, ok := storage.(core.AchievementStorage)
+	if !ok {
+		return achievements
+	}
+	saved, err := store.GetUnlockedAchievements()
+	if err != nil {
+		return achievements
+	}
+	unlocked := make(map[string]core.UnlockedAchievement, len(saved))
+	for _, u := range saved {
+		unlocked[u.ID] = u
+	}
+	backfill := len(saved) == 0 && now.Sub(firstActivity) >= newAchievementWindow
 
-		achievements = append(achievements, Achievement{
-			ID:          cfg.ID,
-			Name:        cfg.Name,
-			Description: cfg.Description,
-			Icon:        cfg.Icon,
-			Unlocked:    unlocked,
-		})
+	result := make([]Achievement, len(achievements))
+	for i, a := range achievements {
+		u, ok := unlocked[a.ID]
+		switch {
+		case ok && !u.UnlockedAt.After(now):
+			a.Unlocked = true
+			a.Context = u.Context
+			a.Progress = 100
+			a.EstimatedAt = nil
+		case a.Unlocked:
+			u = core.UnlockedAchievement{ID: a.ID, UnlockedAt: now.Truncate(time.Second), Context: a.Context, Backfilled: backfill}
+			if saved, err := store.UnlockAchievement(u); err != nil || !saved {
+				// Read-only, or saved meanwhile by another process: the
+				// time of the unlock is unknown.
+				result[i] = a
+				continue
+			}
+		default:
+			result[i] = a
+			continue
+		}
+		a.UnlockedAt = &u.UnlockedAt
+		a.IsNew = !u.Backfilled && now.Sub(u.UnlockedAt) < newAchievementWindow
+		result[i] = a
 	}
+	return result
+}
 
+// hideSecrets masks hidden achievements that are still locked.
+func hideSecrets(achievements []Achievement) []Achievement {
+	for i, a := range achievements {
+		if a.Hidden && !a.Unlocked {
+			achievements[i] = Achievement{ID: a.ID, Name: "???", Description: "Secret achievement", Icon: "❓", Tier: a.Tier, Hidden: true}
+		}
+	}
 	return achievements
 }
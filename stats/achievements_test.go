@@ -1,7 +1,10 @@
 package stats
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -106,7 +109,7 @@ func TestCalculateAchievements(t *testing.T) {
 			activities: []core.Activity{},
 			streakInfo: StreakInfo{Current: 0, Longest: 0},
 			checkFunc: func(t *testing.T, achievements []Achievement) {
-				polyglotAchievements := filterAchievements(achievements, "languages")
+				polyglotAchievements := filterAchievements(achievements, "polyglot")
 				require.True(t, findAchievement(polyglotAchievements, "polyglot_2").Unlocked)
 				require.True(t, findAchievement(polyglotAchievements, "polyglot_5").Unlocked)
 				require.False(t, findAchievement(polyglotAchievements, "polyglot_10").Unlocked)
@@ -213,31 +216,163 @@ func TestAchievementConfigs(t *testing.T) {
 	// Verify all achievement configs are valid
 	require.NotEmpty(t, AchievementConfigs)
 
-	seenIDs := make(map[string]bool)
-	for _, cfg := range AchievementConfigs {
-		// No duplicate IDs
-		require.False(t, seenIDs[cfg.ID], "duplicate achievement ID: %s", cfg.ID)
-		seenIDs[cfg.ID] = true
+	defs, err := compileAchievements(AchievementConfigs)
+	require.NoError(t, err)
 
+	for _, def := range defs {
 		// Required fields
-		require.NotEmpty(t, cfg.ID)
-		require.NotEmpty(t, cfg.Name)
-		require.NotEmpty(t, cfg.Description)
-		require.NotEmpty(t, cfg.Type)
-		require.NotEmpty(t, cfg.Icon)
-
-		// Type-specific validation
-		switch cfg.Type {
-		case "streak", "lines", "hours", "languages":
-			require.Greater(t, cfg.Threshold, 0, "achievement %s missing threshold", cfg.ID)
-		case "habit":
-			require.NotEmpty(t, cfg.Hours, "achievement %s missing hours", cfg.ID)
-		case "session":
-			require.Greater(t, cfg.MinSession, 0, "achievement %s missing min_session", cfg.ID)
-		default:
-			t.Fatalf("unknown achievement type: %s", cfg.Type)
+		require.NotEmpty(t, def.ID)
+		require.NotEmpty(t, def.Name)
+		require.NotEmpty(t, def.Description)
+		require.NotEmpty(t, def.Icon)
+		require.NotNil(t, def.value, "achievement %s has no value to show", def.ID)
+	}
+}
+
+func TestCompileAchievements_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config AchievementConfig
+		err    string
+	}{
+		{"syntax", AchievementConfig{ID: "a", Rule: "lines >="}, "unexpected"},
+		{"unknown metric", AchievementConfig{ID: "a", Rule: "commits > 3"}, "unknown metric: commits"},
+		{"unknown function", AchievementConfig{ID: "a", Rule: "max_year(time) > 3"}, "unknown function: max_year"},
+		{"bad argument", AchievementConfig{ID: "a", Rule: "max_week(time(rust)) > 3"}, "quoted name"},
+		{"bad tier", AchievementConfig{ID: "a", Rule: "lines", Tiers: []AchievementTier{{Target: "10x"}}}, "tier 1"},
+		{"duplicate", AchievementConfig{ID: "lines_1000", Rule: "lines > 0"}, "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileAchievements(append(slices.Clone(AchievementConfigs), tt.config))
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestUserAchievements(t *testing.T) {
+	day := func(d, hour int, lang, project string, duration float64, lines int) core.Activity {
+		return core.Activity{
+			Timestamp: time.Date(2025, 3, d, hour, 0, 0, 0, time.UTC),
+			Language:  lang, Project: project, Duration: duration, Lines: lines,
 		}
 	}
+	// Sat 1 and Sun 2 March, a week of Rust from Monday 3, and Sat 8.
+	activities := []core.Activity{
+		day(1, 10, "go", "a", 600, 10),
+		day(2, 10, "go", "b", 600, 10),
+		day(3, 9, "rust", "a", 4*3600, 100),
+		day(3, 14, "go", "b", 600, 10),
+		day(3, 16, "python", "c", 600, 10),
+		day(5, 9, "rust", "a", 4*3600, 100),
+		day(7, 9, "rust", "a", 3*3600, 100),
+		day(8, 9, "rust", "a", 600, 100),
+	}
+	configs := append(slices.Clone(AchievementConfigs),
+		AchievementConfig{ID: "rust_week", Name: "Rustacean", Description: "10h of Rust in one week", Icon: "🦀",
+			Rule: `max_week(time("rust")) >= 10h`},
+		AchievementConfig{ID: "weekender", Name: "Weekender", Description: "Code on 3 weekends",
+			Rule: "count_weekends(lines > 0) >= 3"},
+		AchievementConfig{ID: "juggler", Name: "Juggler", Description: "3 projects in a day", Icon: "🤹",
+			Rule: "max_day(projects) >= 3", Context: "{value} projects"},
+		AchievementConfig{ID: "busy", Name: "Busy Day", Icon: "📈", Rule: "max_day(time)", Tiers: []AchievementTier{
+			{Target: "4h"}, {Target: "8h", Icon: "🚀"},
+		}},
+		AchievementConfig{ID: "secret", Name: "Secret", Description: "Code on a Sunday", Rule: "count_days(weekday == 0) > 0", Hidden: true},
+		AchievementConfig{ID: "secret_locked", Name: "Locked", Description: "Hidden", Rule: "days > 100", Hidden: true},
+	)
+	defs, err := compileAchievements(configs)
+	require.NoError(t, err)
+
+	achievements := evaluateAchievements(defs, newAchievementEnv(APIPeriodStats{}, activities, StreakInfo{}, time.UTC))
+	achievements = hideSecrets(achievements)
+
+	rust := findAchievement(achievements, "rust_week")
+	require.True(t, rust.Unlocked)
+	require.Equal(t, "11h 10m", rust.Context)
+	require.False(t, findAchievement(achievements, "weekender").Unlocked, "two weekends only")
+
+	juggler := findAchievement(achievements, "juggler")
+	require.True(t, juggler.Unlocked)
+	require.Equal(t, "3 projects", juggler.Context)
+
+	busy1 := findAchievement(achievements, "busy_1")
+	require.True(t, busy1.Unlocked)
+	require.Equal(t, 1, busy1.Tier)
+	require.Equal(t, "Busy Day 1", busy1.Name)
+	require.Equal(t, "📈", busy1.Icon)
+	busy2 := findAchievement(achievements, "busy_2")
+	require.False(t, busy2.Unlocked)
+	require.Equal(t, "🚀", busy2.Icon)
+
+	require.Equal(t, "Secret", findAchievement(achievements, "secret").Name, "unlocked secrets show")
+	locked := findAchievement(achievements, "secret_locked")
+	require.Equal(t, "???", locked.Name)
+	require.Equal(t, "Secret achievement", locked.Description)
+}
+
+func TestCalculator_SetAchievements(t *testing.T) {
+	calc := NewCalculator(time.UTC)
+	require.Error(t, calc.SetAchievements([]AchievementConfig{{ID: "x", Rule: "nope"}}))
+
+	require.NoError(t, calc.SetAchievements([]AchievementConfig{
+		{ID: "early_bird", Name: "Early", Description: "Code at 7", Icon: "🐦", Rule: "hour_time(7) > 0"},
+		{ID: "extra", Name: "Extra", Description: "One line", Icon: "➕", Rule: "lines >= 1"},
+	}))
+	require.Len(t, calc.achievements, len(defaultAchievements())+1)
+	i := slices.IndexFunc(calc.achievements, func(d achievementDef) bool { return d.ID == "early_bird" })
+	require.Equal(t, "Early", calc.achievements[i].Name)
+}
+
+func TestCalculator_AchievementProgress(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	var activities []core.Activity
+	// 100 lines a day for the last two weeks, on a streak of 14 days.
+	for d := range 14 {
+		activities = append(activities, core.Activity{
+			Timestamp: now.AddDate(0, 0, -d).Add(-time.Hour), Language: "go", Duration: 600, Lines: 100,
+		})
+	}
+	allTime := APIPeriodStats{TotalLines: 7400, TotalTime: 8400, Languages: []APILanguageStats{{Name: "go", Time: 8400, Lines: 7400}}}
+	var days []core.DailySummary
+	for _, a := range slices.Backward(activities) {
+		days = append(days, core.DailySummary{Date: a.Timestamp.Format("2006-01-02"), TotalTime: a.Duration, TotalLines: a.Lines})
+	}
+	streak := NewStreakCalculator(time.UTC).CalculateDays(days, now)
+
+	calc := NewCalculator(time.UTC)
+	achievements := calc.calculateAchievements(allTime, activities, days, streak, now)
+
+	surge := findAchievement(achievements, "lines_10000")
+	require.False(t, surge.Unlocked)
+	require.Equal(t, 7400.0, surge.Current)
+	require.Equal(t, 10000.0, surge.Target)
+	require.InDelta(t, 74, surge.Progress, 0.01)
+	require.NotNil(t, surge.EstimatedAt)
+	require.WithinDuration(t, now.AddDate(0, 0, 26), *surge.EstimatedAt, time.Second)
+
+	streak30 := findAchievement(achievements, "streak_30")
+	require.Equal(t, 14.0, streak30.Current)
+	require.WithinDuration(t, now.AddDate(0, 0, 16), *streak30.EstimatedAt, time.Second)
+
+	wave := findAchievement(achievements, "lines_1000")
+	require.True(t, wave.Unlocked)
+	require.Equal(t, 100.0, wave.Progress)
+	require.Nil(t, wave.EstimatedAt)
+
+	hours := findAchievement(achievements, "hours_50")
+	require.Equal(t, "seconds", hours.Unit)
+
+	// No progress lately, no estimate.
+	stale := calc.calculateAchievements(allTime, activities, days, streak, now.AddDate(0, 1, 0))
+	require.Nil(t, findAchievement(stale, "lines_10000").EstimatedAt)
+}
+
+func TestRuleTargetJSON(t *testing.T) {
+	var tiers []AchievementTier
+	require.NoError(t, json.Unmarshal([]byte(`[{"target": 1000}, {"target": "10h"}]`), &tiers))
+	require.Equal(t, RuleTarget("1000"), tiers[0].Target)
+	require.Equal(t, RuleTarget("10h"), tiers[1].Target)
 }
 
 func TestAchievements_EdgeCases(t *testing.T) {
@@ -274,13 +409,86 @@ func TestAchievements_EdgeCases(t *testing.T) {
 	})
 }
 
+func TestRecordAchievements(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	unlocked := CalculateAchievements(APIPeriodStats{TotalLines: 1500}, nil, StreakInfo{})
+
+	// A year of history: what is unlocked now was earned earlier.
+	first := RecordAchievements(storage, unlocked, now.AddDate(-1, 0, 0), now)
+	lines := findAchievement(first, "lines_1000")
+	require.True(t, lines.Unlocked)
+	require.WithinDuration(t, now, *lines.UnlockedAt, 0)
+	require.Equal(t, "1500 lines", lines.Context)
+	require.False(t, lines.IsNew, "backfilled unlocks are not new")
+	require.Nil(t, findAchievement(first, "lines_10000").UnlockedAt)
+
+	// Later the lines fall out of view, and 10K is reached.
+	later := now.Add(2 * time.Hour)
+	achievements := CalculateAchievements(APIPeriodStats{TotalLines: 10000}, nil, StreakInfo{})
+	achievements[slices.IndexFunc(achievements, func(a Achievement) bool { return a.ID == "lines_1000" })].Unlocked = false
+	second := RecordAchievements(storage, achievements, now.AddDate(-1, 0, 0), later)
+
+	lines = findAchievement(second, "lines_1000")
+	require.True(t, lines.Unlocked, "unlocks are sticky")
+	require.WithinDuration(t, now, *lines.UnlockedAt, 0)
+	require.False(t, lines.IsNew)
+
+	surge := findAchievement(second, "lines_10000")
+	require.WithinDuration(t, later, *surge.UnlockedAt, 0)
+	require.True(t, surge.IsNew)
+
+	// A day later it is no longer new, and earlier views don't see it.
+	third := RecordAchievements(storage, achievements, now.AddDate(-1, 0, 0), later.Add(25*time.Hour))
+	require.False(t, findAchievement(third, "lines_10000").IsNew)
+	past := RecordAchievements(storage, CalculateAchievements(APIPeriodStats{}, nil, StreakInfo{}), now.AddDate(-1, 0, 0), now)
+	require.False(t, findAchievement(past, "lines_10000").Unlocked)
+}
+
+func TestRecordAchievements_ReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "codeme.db")
+	writable, err := core.NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	defer writable.Close()
+	readOnly, err := core.OpenReadOnlyStorage(dbPath)
+	require.NoError(t, err)
+	defer readOnly.Close()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	calc := func() []Achievement {
+		return CalculateAchievements(APIPeriodStats{TotalLines: 1500}, nil, StreakInfo{})
+	}
+
+	lines := findAchievement(RecordAchievements(readOnly, calc(), now.Add(-time.Hour), now), "lines_1000")
+	require.True(t, lines.Unlocked)
+	require.Nil(t, lines.UnlockedAt, "not saved, so no time")
+	require.False(t, lines.IsNew)
+
+	RecordAchievements(writable, calc(), now.Add(-time.Hour), now)
+	lines = findAchievement(RecordAchievements(readOnly, calc(), now.Add(-time.Hour), now.Add(time.Hour)), "lines_1000")
+	require.WithinDuration(t, now, *lines.UnlockedAt, 0, "the saved time, not now")
+	require.True(t, lines.IsNew)
+}
+
+func TestRecordAchievements_FirstUnlockIsNew(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	achievements := CalculateAchievements(APIPeriodStats{TotalLines: 1500}, nil, StreakInfo{})
+	achievements = RecordAchievements(storage, achievements, now.Add(-time.Hour), now)
+	require.True(t, findAchievement(achievements, "lines_1000").IsNew, "started tracking today")
+}
+
 // Helper functions
-func filterAchievements(achievements []Achievement, achievementType string) []Achievement {
+func filterAchievements(achievements []Achievement, group string) []Achievement {
 	var result []Achievement
 	for _, ach := range achievements {
 		// Find in configs
 		for _, cfg := range AchievementConfigs {
-			if cfg.ID == ach.ID && cfg.Type == achievementType {
+			if cfg.ID == group && slices.ContainsFunc(cfg.Tiers, func(t AchievementTier) bool { return t.ID == ach.ID }) {
 				result = append(result, ach)
 				break
 			}
@@ -10,8 +10,10 @@ import (
 )
 
 type Calculator struct {
-	timezone *time.Location
-	cache    *StatsCache
+	timezone     *time.Location
+	cache        *StatsCache
+	achievements []achievementDef
+	streakRules  []StreakRule
 }
 
 func NewCalculator(timezone *time.Location) *Calculator {
@@ -19,17 +21,64 @@ func NewCalculator(timezone *time.Location) *Calculator {
 		timezone = time.UTC
 	}
 	return &Calculator{
-		timezone: timezone,
-		cache:    NewStatsCache(30 * time.Second),
+		timezone:     timezone,
+		cache:        NewStatsCache(30 * time.Second),
+		achievements: defaultAchievements(),
 	}
 }
 
+// Invalidate drops cached stats, e.g. after tracking a new activity.
+func (c *Calculator) Invalidate() {
+	c.cache.Invalidate()
+}
+
+// Period returns a period by its JSON name, e.g. "this_week". An empty name
+// means all_time.
+func (s *APIStats) Period(name string) (APIPeriodStats, bool) {
+	switch name {
+	case "today":
+		return s.Today, true
+	case "yesterday":
+		return s.Yesterday, true
+	case "this_week":
+		return s.ThisWeek, true
+	case "last_week":
+		return s.LastWeek, true
+	case "this_month":
+		return s.ThisMonth, true
+	case "last_month":
+		return s.LastMonth, true
+	case "all_time", "":
+		return s.AllTime, true
+	}
+	for _, p := range s.Periods {
+		if p.Period == name {
+			return p, true
+		}
+	}
+	return APIPeriodStats{}, false
+}
+
 func (c *Calculator) CalculateAPI(storage core.Storage, opts APIOptions) (*APIStats, error) {
 	startTime := time.Now()
 
 	if opts.LoadRecentDays == 0 {
 		opts.LoadRecentDays = 365
 	}
+	if opts.HeatmapWeeks == 0 {
+		opts.HeatmapWeeks = 12
+	}
+
+	// Goals and saved achievements are not filtered, so they read the
+	// unfiltered storage.
+	base := storage
+	if !opts.Filter.IsEmpty() {
+		fs, ok := storage.(core.FilterableStorage)
+		if !ok {
+			return nil, fmt.Errorf("storage does not support filtering")
+		}
+		storage = fs.WithFilter(opts.Filter)
+	}
 
 	if cached, ok := c.cache.Get(opts); ok {
 		cached.GeneratedAt = time.Now()
@@ -38,6 +87,9 @@ func (c *Calculator) CalculateAPI(storage core.Storage, opts APIOptions) (*APISt
 	}
 
 	now := time.Now().In(c.timezone)
+	if !opts.Now.IsZero() {
+		now = opts.Now.In(c.timezone)
+	}
 
 	todayStart := util.StartOfDay(now, c.timezone)
 	yesterdayStart := util.StartOfDay(now.AddDate(0, 0, -1), c.timezone)
@@ -46,76 +98,105 @@ func (c *Calculator) CalculateAPI(storage core.Storage, opts APIOptions) (*APISt
 	thisMonthStart := util.StartOfMonth(now, c.timezone)
 	lastMonthStart := util.StartOfMonth(now.AddDate(0, -1, 0), c.timezone)
 
-	todaySummary, _ := storage.GetPeriodSummary(todayStart, now)
-	yesterdaySummary, _ := storage.GetPeriodSummary(yesterdayStart, todayStart)
-	thisWeekSummary, _ := storage.GetPeriodSummary(thisWeekStart, now)
-	lastWeekSummary, _ := storage.GetPeriodSummary(lastWeekStart, thisWeekStart)
-	thisMonthSummary, _ := storage.GetPeriodSummary(thisMonthStart, now)
-	lastMonthSummary, _ := storage.GetPeriodSummary(lastMonthStart, thisMonthStart)
-	allTimeSummary, _ := storage.GetPeriodSummary(time.Time{}, now)
-
-	todayLangs, _ := storage.GetLanguageSummary(todayStart, now)
-	yesterdayLangs, _ := storage.GetLanguageSummary(yesterdayStart, todayStart)
-	thisWeekLangs, _ := storage.GetLanguageSummary(thisWeekStart, now)
-	lastWeekLangs, _ := storage.GetLanguageSummary(lastWeekStart, thisWeekStart)
-	thisMonthLangs, _ := storage.GetLanguageSummary(thisMonthStart, now)
-	lastMonthLangs, _ := storage.GetLanguageSummary(lastMonthStart, thisMonthStart)
-	allTimeLangs, _ := storage.GetLanguageSummary(time.Time{}, now)
-
-	todayProjs, _ := storage.GetProjectSummary(todayStart, now)
-	yesterdayProjs, _ := storage.GetProjectSummary(yesterdayStart, todayStart)
-	thisWeekProjs, _ := storage.GetProjectSummary(thisWeekStart, now)
-	lastWeekProjs, _ := storage.GetProjectSummary(lastWeekStart, thisWeekStart)
-	thisMonthProjs, _ := storage.GetProjectSummary(thisMonthStart, now)
-	lastMonthProjs, _ := storage.GetProjectSummary(lastMonthStart, thisMonthStart)
-	allTimeProjs, _ := storage.GetProjectSummary(time.Time{}, now)
-
-	todayEditors, _ := storage.GetEditorSummary(todayStart, now)
-	yesterdayEditors, _ := storage.GetEditorSummary(yesterdayStart, todayStart)
-	thisWeekEditors, _ := storage.GetEditorSummary(thisWeekStart, now)
-	lastWeekEditors, _ := storage.GetEditorSummary(lastWeekStart, thisWeekStart)
-	thisMonthEditors, _ := storage.GetEditorSummary(thisMonthStart, now)
-	lastMonthEditors, _ := storage.GetEditorSummary(lastMonthStart, thisMonthStart)
-	allTimeEditors, _ := storage.GetEditorSummary(time.Time{}, now)
+	// Summary tables match whole dates, both ends included, so previous
+	// periods stop just before the current one starts.
+	todayData := loadPeriodData(storage, todayStart, now)
+	yesterdayData := loadPeriodData(storage, yesterdayStart, todayStart.Add(-time.Nanosecond))
+	thisWeekData := loadPeriodData(storage, thisWeekStart, now)
+	lastWeekData := loadPeriodData(storage, lastWeekStart, thisWeekStart.Add(-time.Nanosecond))
+	thisMonthData := loadPeriodData(storage, thisMonthStart, now)
+	lastMonthData := loadPeriodData(storage, lastMonthStart, thisMonthStart.Add(-time.Nanosecond))
+	allTimeData := loadPeriodData(storage, time.Time{}, now)
 
 	lifetimeHours := make(map[string]float64)
-	for _, lr := range allTimeLangs {
+	for _, lr := range allTimeData.languages {
 		lifetimeHours[lr.Language] = lr.TotalTime / 3600
 	}
 
-	projectLangs := make(map[string]map[string]float64)
-	for _, pr := range allTimeProjs {
-		pl := make(map[string]float64)
-		for _, lr := range allTimeLangs {
-			pl[lr.Language] = lr.TotalTime / 3600
+	type periodBounds struct{ start, end time.Time }
+	extraBounds := make([]periodBounds, len(opts.Periods))
+	extraData := make([]periodData, len(opts.Periods))
+	window := now.AddDate(0, 0, -opts.LoadRecentDays)
+	cutoff := window
+	for i, p := range opts.Periods {
+		start, end, err := p.Resolve(now, c.timezone)
+		if err != nil {
+			return nil, err
+		}
+		extraBounds[i] = periodBounds{start, end}
+		extraData[i] = loadPeriodData(storage, start, end.Add(-time.Nanosecond))
+		// Sessions and hourly detail need the period's activities.
+		if start.Before(cutoff) {
+			cutoff = start
 		}
-		projectLangs[pr.Project] = pl
 	}
-
-	cutoff := time.Now().AddDate(0, 0, -opts.LoadRecentDays)
-	activities, err := storage.GetActivitiesSince(cutoff)
+	loaded, err := storage.GetActivitiesSince(cutoff)
 	if err != nil {
 		return nil, err
 	}
+	if !opts.Now.IsZero() {
+		loaded = util.Filter(loaded, func(a core.Activity) bool {
+			return !a.Timestamp.After(now)
+		})
+	}
 
 	totalCount, _ := storage.GetActivityCount()
 
+	// Everything but the requested periods sticks to the data window, even
+	// when a period reaches further back.
+	activities := util.Filter(loaded, func(a core.Activity) bool {
+		return !a.Timestamp.Before(window)
+	})
 	sessionMgr := NewSessionManager(0, 0)
 	activities, sessions := sessionMgr.GroupAndCalculate(activities)
 	sessionsByDay := c.indexSessionsByDay(sessions)
 
-	today := c.buildPeriodFromSummary("today", todaySummary, todayLangs, todayProjs, todayEditors, sessions, sessionsByDay, lifetimeHours, projectLangs, todayStart, now, activities)
-	yesterday := c.buildPeriodFromSummary("yesterday", yesterdaySummary, yesterdayLangs, yesterdayProjs, yesterdayEditors, sessions, sessionsByDay, lifetimeHours, projectLangs, yesterdayStart, todayStart, activities)
-	thisWeek := c.buildPeriodFromSummary("this_week", thisWeekSummary, thisWeekLangs, thisWeekProjs, thisWeekEditors, sessions, sessionsByDay, lifetimeHours, projectLangs, thisWeekStart, now, activities)
-	lastWeek := c.buildPeriodFromSummary("last_week", lastWeekSummary, lastWeekLangs, lastWeekProjs, lastWeekEditors, sessions, sessionsByDay, lifetimeHours, projectLangs, lastWeekStart, thisWeekStart, activities)
-	thisMonth := c.buildPeriodFromSummary("this_month", thisMonthSummary, thisMonthLangs, thisMonthProjs, thisMonthEditors, sessions, sessionsByDay, lifetimeHours, projectLangs, thisMonthStart, now, activities)
-	lastMonth := c.buildPeriodFromSummary("last_month", lastMonthSummary, lastMonthLangs, lastMonthProjs, lastMonthEditors, sessions, sessionsByDay, lifetimeHours, projectLangs, lastMonthStart, thisMonthStart, activities)
-	allTime := c.buildPeriodFromSummary("all_time", allTimeSummary, allTimeLangs, allTimeProjs, allTimeEditors, sessions, sessionsByDay, lifetimeHours, projectLangs, time.Time{}, now, activities)
+	periodActivities, periodSessions, periodSessionsByDay := activities, sessions, sessionsByDay
+	if cutoff.Before(window) {
+		periodActivities, periodSessions = sessionMgr.GroupAndCalculate(loaded)
+		periodSessionsByDay = c.indexSessionsByDay(periodSessions)
+	}
+
+	today := c.buildPeriodFromSummary("today", todayData, sessions, sessionsByDay, lifetimeHours, todayStart, now, activities)
+	yesterday := c.buildPeriodFromSummary("yesterday", yesterdayData, sessions, sessionsByDay, lifetimeHours, yesterdayStart, todayStart, activities)
+	thisWeek := c.buildPeriodFromSummary("this_week", thisWeekData, sessions, sessionsByDay, lifetimeHours, thisWeekStart, now, activities)
+	lastWeek := c.buildPeriodFromSummary("last_week", lastWeekData, sessions, sessionsByDay, lifetimeHours, lastWeekStart, thisWeekStart, activities)
+	thisMonth := c.buildPeriodFromSummary("this_month", thisMonthData, sessions, sessionsByDay, lifetimeHours, thisMonthStart, now, activities)
+	lastMonth := c.buildPeriodFromSummary("last_month", lastMonthData, sessions, sessionsByDay, lifetimeHours, lastMonthStart, thisMonthStart, activities)
+	allTime := c.buildPeriodFromSummary("all_time", allTimeData, sessions, sessionsByDay, lifetimeHours, time.Time{}, now, activities)
+
+	var extraPeriods []APIPeriodStats
+	for i, p := range opts.Periods {
+		b := extraBounds[i]
+		extraPeriods = append(extraPeriods, c.buildPeriodFromSummary(p.name(), extraData[i], periodSessions, periodSessionsByDay, lifetimeHours, b.start, b.end, periodActivities))
+	}
 
+	// Streaks cover all history, beyond the loaded activities.
+	summaryDays, err := storage.GetDailySummaries(time.Time{}, now)
+	if err != nil {
+		return nil, err
+	}
 	streakCalc := NewStreakCalculator(c.timezone)
-	streakInfo := streakCalc.Calculate(activities)
+	streakInfo := streakCalc.CalculateDays(summaryDays, now)
+	if n := len(activities); n > 0 && util.DateString(activities[n-1].Timestamp, c.timezone) == util.DateString(streakInfo.LastActivity, c.timezone) {
+		streakInfo.LastActivity = activities[n-1].Timestamp
+	}
 
-	achievements := CalculateAchievements(allTime, activities, streakInfo)
+	achievements := c.calculateAchievements(allTime, activities, summaryDays, streakInfo, now)
+	if opts.Filter.IsEmpty() {
+		firstActivity := now
+		if len(activities) > 0 {
+			firstActivity = activities[0].Timestamp
+		}
+		achievements = RecordAchievements(base, achievements, firstActivity, now)
+	}
+	achievements = hideSecrets(achievements)
+
+	goals := c.EvaluateGoals(base, opts.Filter, now)
+	streaks := c.calculateStreakRules(summaryDays, now)
+	if timeGoal, linesGoal, ok := dailyTargets(goals, now.Weekday()); ok {
+		today.DailyGoals = c.calculateDailyGoals(today, timeGoal, linesGoal)
+	}
 
 	dayAgg := AggregateByDay(activities, c.timezone)
 	for date, day := range dayAgg {
@@ -136,8 +217,9 @@ func (c *Calculator) CalculateAPI(storage core.Storage, opts APIOptions) (*APISt
 		}
 	}
 
-	heatmap := c.generateWeeklyHeatmap(dailyActivity, 12)
+	heatmap := c.generateWeeklyHeatmap(dailyActivity, opts.HeatmapWeeks, now)
 	records := c.calculateRecords(activities, dayAgg, sessions, sessionsByDay)
+	records.BestStreak = streakCalc.BestStreak(summaryDays)
 
 	queryTime := time.Since(startTime).Seconds() * 1000
 
@@ -149,12 +231,21 @@ func (c *Calculator) CalculateAPI(storage core.Storage, opts APIOptions) (*APISt
 		ThisMonth:     thisMonth,
 		LastMonth:     lastMonth,
 		AllTime:       allTime,
+		Periods:       extraPeriods,
 		StreakInfo:    streakInfo,
 		Achievements:  achievements,
+		Goals:         goals,
+		Streaks:       streaks,
 		Records:       records,
 		DailyActivity: dailyActivity,
 		WeeklyHeatmap: heatmap,
-		GeneratedAt:   time.Now(),
+		Trends: Trends{
+			DayOverDay:     compare(today, yesterday),
+			WeekOverWeek:   compare(thisWeek, lastWeek),
+			MonthOverMonth: compare(thisMonth, lastMonth),
+			MovingAverages: movingAverages(summaryDays, now, c.timezone),
+		},
+		GeneratedAt: time.Now(),
 		Meta: APIMeta{
 			LoadedActivities: len(activities),
 			TotalActivities:  totalCount,
@@ -163,21 +254,43 @@ func (c *Calculator) CalculateAPI(storage core.Storage, opts APIOptions) (*APISt
 		},
 	}
 
-	c.cache.Set(result)
+	c.cache.Set(opts, result)
 
 	return result, nil
 }
 
+// periodData holds the summary-table rows for one period.
+type periodData struct {
+	summary   core.PeriodSummary
+	languages []core.LanguageRow
+	projects  []core.ProjectRow
+	// projectLanguages breaks projects down by language.
+	projectLanguages []core.ProjectLanguageRow
+	editors          []core.EditorRow
+	categories       []core.CategoryRow
+	machines         []core.MachineRow
+	commands         []core.CommandRow
+}
+
+func loadPeriodData(storage core.Storage, from, to time.Time) periodData {
+	var pd periodData
+	pd.summary, _ = storage.GetPeriodSummary(from, to)
+	pd.languages, _ = storage.GetLanguageSummary(from, to)
+	pd.projects, _ = storage.GetProjectSummary(from, to)
+	pd.projectLanguages, _ = storage.GetProjectLanguageSummary(from, to)
+	pd.editors, _ = storage.GetEditorSummary(from, to)
+	pd.categories, _ = storage.GetCategorySummary(from, to)
+	pd.machines, _ = storage.GetMachineSummary(from, to)
+	pd.commands, _ = storage.GetCommandSummary(from, to)
+	return pd
+}
+
 func (c *Calculator) buildPeriodFromSummary(
 	period string,
-	summary core.PeriodSummary,
-	langRows []core.LanguageRow,
-	projRows []core.ProjectRow,
-	editorRows []core.EditorRow,
+	data periodData,
 	allSessions []core.Session,
 	sessionsByDay map[string][]core.Session,
 	lifetimeHours map[string]float64,
-	projectLangs map[string]map[string]float64,
 	start, end time.Time,
 	activities []core.Activity,
 ) APIPeriodStats {
@@ -210,9 +323,13 @@ func (c *Calculator) buildPeriodFromSummary(
 		}
 	}
 
-	languages := c.convertLanguageRows(langRows, lifetimeHours, summary.TotalTime)
-	projects := c.convertProjectRows(projRows, projectLangs, summary.TotalTime)
-	editors := c.convertEditorRows(editorRows, summary.TotalTime)
+	summary := data.summary
+	languages := c.convertLanguageRows(data.languages, lifetimeHours, summary.TotalTime)
+	projects := c.convertProjectRows(data.projects, data.projectLanguages, summary.TotalTime)
+	editors := c.convertEditorRows(data.editors, summary.TotalTime)
+	categories := c.convertCategoryRows(data.categories, summary.TotalTime)
+	machines := c.convertMachineRows(data.machines, summary.TotalTime)
+	commands := c.convertCommandRows(data.commands)
 
 	hourAgg := AggregateByHour(periodActivities, c.timezone)
 	hourlyActivity := c.buildHourlyActivity(hourAgg, summary.TotalTime)
@@ -234,6 +351,9 @@ func (c *Calculator) buildPeriodFromSummary(
 		Languages:      languages,
 		Projects:       projects,
 		Editors:        editors,
+		Categories:     categories,
+		Machines:       machines,
+		Commands:       commands,
 		Files:          topFiles,
 		HourlyActivity: hourlyActivity,
 		PeakHour:       peakHour,
@@ -307,38 +427,55 @@ func (c *Calculator) convertLanguageRows(rows []core.LanguageRow, lifetimeHours
 	return result
 }
 
-func (c *Calculator) convertProjectRows(rows []core.ProjectRow, projectLangs map[string]map[string]float64, total float64) []APIProjectStats {
+// convertProjectRows attaches each project's languages. The main language
+// is the project's top code language; projects with only prose, config and
+// the like are "Mixed".
+func (c *Calculator) convertProjectRows(rows []core.ProjectRow, projectLanguages []core.ProjectLanguageRow, total float64) []APIProjectStats {
+	byProject := make(map[string][]core.ProjectLanguageRow)
+	for _, pl := range projectLanguages {
+		byProject[pl.Project] = append(byProject[pl.Project], pl)
+	}
+
 	result := make([]APIProjectStats, 0, len(rows))
 	for _, r := range rows {
-		mainLang := r.MainLanguage
-
-		// If mainLang is not a code language, find the first code language from projectLangs
-		if mainLang == "" || !IsCodeLanguage(mainLang) {
-			if langs, ok := projectLangs[r.Project]; ok {
-				for lang := range langs {
-					if IsCodeLanguage(lang) {
-						mainLang = lang
-						break
-					}
-				}
-			}
-		}
-
-		if mainLang == "" || !IsCodeLanguage(mainLang) {
-			mainLang = "Mixed"
-		}
-
 		pct := 0.0
 		if total > 0 {
 			pct = (r.TotalTime / total) * 100
 		}
 
+		// Storages return each project's languages with the most time first.
+		langs := byProject[r.Project]
+		mainLang := ""
+		languages := make([]APIProjectLanguageStats, 0, len(langs))
+		for _, l := range langs {
+			if mainLang == "" && IsCodeLanguage(l.Language) {
+				mainLang = l.Language
+			}
+			langPct := 0.0
+			if r.TotalTime > 0 {
+				langPct = (l.TotalTime / r.TotalTime) * 100
+			}
+			languages = append(languages, APIProjectLanguageStats{
+				Name:         l.Language,
+				Time:         l.TotalTime,
+				Lines:        l.TotalLines,
+				PercentTotal: langPct,
+			})
+		}
+		if mainLang == "" && IsCodeLanguage(r.MainLanguage) {
+			mainLang = r.MainLanguage
+		}
+		if mainLang == "" {
+			mainLang = "Mixed"
+		}
+
 		result = append(result, APIProjectStats{
 			Name:         r.Project,
 			Time:         r.TotalTime,
 			Lines:        r.TotalLines,
 			PercentTotal: pct,
 			MainLanguage: mainLang,
+			Languages:    languages,
 		})
 	}
 	return result
@@ -361,6 +498,63 @@ func (c *Calculator) convertEditorRows(rows []core.EditorRow, total float64) []A
 	return result
 }
 
+func (c *Calculator) convertCategoryRows(rows []core.CategoryRow, total float64) []APICategoryStats {
+	result := make([]APICategoryStats, 0, len(rows))
+	for _, r := range rows {
+		pct := 0.0
+		if total > 0 {
+			pct = (r.TotalTime / total) * 100
+		}
+
+		result = append(result, APICategoryStats{
+			Name:         r.Category,
+			Time:         r.TotalTime,
+			Lines:        r.TotalLines,
+			PercentTotal: pct,
+		})
+	}
+	return result
+}
+
+func (c *Calculator) convertMachineRows(rows []core.MachineRow, total float64) []APIMachineStats {
+	result := make([]APIMachineStats, 0, len(rows))
+	for _, r := range rows {
+		pct := 0.0
+		if total > 0 {
+			pct = (r.TotalTime / total) * 100
+		}
+
+		result = append(result, APIMachineStats{
+			Name:         r.Machine,
+			Time:         r.TotalTime,
+			Lines:        r.TotalLines,
+			PercentTotal: pct,
+		})
+	}
+	return result
+}
+
+func (c *Calculator) convertCommandRows(rows []core.CommandRow) []APICommandStats {
+	result := make([]APICommandStats, 0, len(rows))
+	for _, r := range rows {
+		rate := 0.0
+		if r.Runs > 0 {
+			rate = float64(r.Failures) / float64(r.Runs) * 100
+		}
+
+		result = append(result, APICommandStats{
+			Project:     r.Project,
+			Category:    r.Category,
+			Command:     r.Command,
+			Runs:        r.Runs,
+			Failures:    r.Failures,
+			FailureRate: rate,
+			Time:        r.TotalTime,
+		})
+	}
+	return result
+}
+
 func (c *Calculator) indexSessionsByDay(sessions []core.Session) map[string][]core.Session {
 	index := make(map[string][]core.Session)
 	for _, s := range sessions {
@@ -524,7 +718,9 @@ func (c *Calculator) calculateDailyGoals(period APIPeriodStats, timeGoal float64
 		}
 	}
 
-	onTrack := timeProgress >= 50 || linesProgress >= 50
+	// On track when either keeps pace with the day, as GoalStatus.OnTrack.
+	onTrack := (timeGoal > 0 && onPace(timeProgress, period.StartDate, 24*time.Hour, period.EndDate)) ||
+		(linesGoal > 0 && onPace(linesProgress, period.StartDate, 24*time.Hour, period.EndDate))
 
 	return DailyGoals{
 		TimeGoal:      timeGoal,
@@ -535,7 +731,7 @@ func (c *Calculator) calculateDailyGoals(period APIPeriodStats, timeGoal float64
 	}
 }
 
-func (c *Calculator) generateWeeklyHeatmap(daily map[string]DailyStat, weeks int) []HeatmapDay {
+func (c *Calculator) generateWeeklyHeatmap(daily map[string]DailyStat, weeks int, now time.Time) []HeatmapDay {
 	maxDuration := 0.0
 	var firstActivityDate time.Time
 	hasActivity := false
@@ -557,8 +753,6 @@ func (c *Calculator) generateWeeklyHeatmap(daily map[string]DailyStat, weeks int
 		}
 	}
 
-	now := time.Now()
-
 	// Find the START of the current week (Monday)
 	weekday := int(now.Weekday())
 	if weekday == 0 {
@@ -615,20 +809,7 @@ func (c *Calculator) generateWeeklyHeatmap(daily map[string]DailyStat, weeks int
 		date := d.Format("2006-01-02")
 		ds := daily[date]
 
-		level := 0
-		duration := float64(ds.Time)
-		if maxDuration > 0 && duration > 0 {
-			ratio := duration / maxDuration
-			if ratio > 0.75 {
-				level = 4
-			} else if ratio > 0.5 {
-				level = 3
-			} else if ratio > 0.25 {
-				level = 2
-			} else {
-				level = 1
-			}
-		}
+		level := heatmapLevel(float64(ds.Time), maxDuration)
 
 		isFuture := d.After(now)
 
@@ -468,7 +468,7 @@ func TestCalculator_WeeklyHeatmap_SmartRange(t *testing.T) {
 			}
 
 			calc := &Calculator{timezone: time.UTC}
-			heatmap := calc.generateWeeklyHeatmap(daily, 12)
+			heatmap := calc.generateWeeklyHeatmap(daily, 12, time.Now())
 
 			// Verify heatmap is not empty
 			require.Greater(t, len(heatmap), 0, "heatmap should not be empty")
@@ -530,3 +530,249 @@ func TestCalculator_WeeklyHeatmap_SmartRange(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculator_CalculateAPI_Categories(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	baseTime := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+
+	activities := []core.Activity{
+		{ID: "1", Timestamp: baseTime, Lines: 10, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/a.go", Category: core.CategoryCoding},
+		{ID: "2", Timestamp: baseTime.Add(1 * time.Minute), Lines: 5, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/a_test.go", Category: core.CategoryTesting},
+		{ID: "3", Timestamp: baseTime.Add(2 * time.Minute), Lines: 5, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/b_test.go", Category: core.CategoryTesting},
+		{ID: "4", Timestamp: baseTime.Add(3 * time.Minute), Lines: 1, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/c.go"},
+	}
+
+	for _, a := range activities {
+		insertActivity(t, storage, a)
+	}
+
+	calc := NewCalculator(time.UTC)
+	stats, err := calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 30})
+	require.NoError(t, err)
+
+	require.Len(t, stats.Today.Categories, 2)
+	byName := make(map[string]APICategoryStats)
+	for _, c := range stats.Today.Categories {
+		byName[c.Name] = c
+	}
+	require.Equal(t, 11, byName[core.CategoryCoding].Lines)
+	require.Equal(t, 10, byName[core.CategoryTesting].Lines)
+	require.InDelta(t, 100.0, byName[core.CategoryCoding].PercentTotal+byName[core.CategoryTesting].PercentTotal, 0.01)
+}
+
+func TestCalculator_CalculateAPI_MachineFilter(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	baseTime := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+
+	activities := []core.Activity{
+		{ID: "1", Timestamp: baseTime, Lines: 10, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/a.go", Machine: "desktop"},
+		{ID: "2", Timestamp: baseTime.Add(1 * time.Minute), Lines: 5, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/b.go", Machine: "desktop"},
+		{ID: "3", Timestamp: baseTime.Add(2 * time.Minute), Lines: 7, Language: "rust", Project: "p2", Editor: "neovim", File: "/p2/main.rs", Machine: "laptop"},
+	}
+
+	for _, a := range activities {
+		insertActivity(t, storage, a)
+	}
+
+	calc := NewCalculator(time.UTC)
+	all, err := calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 30})
+	require.NoError(t, err)
+	require.Equal(t, 22, all.Today.TotalLines)
+	require.Len(t, all.Today.Machines, 2)
+
+	filtered, err := calc.CalculateAPI(storage, APIOptions{
+		LoadRecentDays: 30,
+		Filter:         core.ActivityFilter{Machine: "Laptop"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 7, filtered.Today.TotalLines)
+	require.Len(t, filtered.Today.Machines, 1)
+	require.Equal(t, "laptop", filtered.Today.Machines[0].Name)
+	require.Len(t, filtered.Today.Languages, 1)
+	require.Equal(t, "rust", filtered.Today.Languages[0].Name)
+	require.Len(t, filtered.Today.Projects, 1)
+	require.Equal(t, "p2", filtered.Today.Projects[0].Name)
+	require.Equal(t, 1, filtered.Meta.LoadedActivities)
+}
+
+func TestCalculator_CalculateAPI_DimensionFilters(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	baseTime := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+
+	activities := []core.Activity{
+		{ID: "1", Timestamp: baseTime, Lines: 10, Language: "go", Project: "p1", Editor: "neovim", Branch: "main", File: "/src/p1/cmd/main.go"},
+		{ID: "2", Timestamp: baseTime.Add(1 * time.Minute), Lines: 5, Language: "go", Project: "p1", Editor: "vscode", Branch: "feature", File: "/src/p1/cmd/main_test.go"},
+		{ID: "3", Timestamp: baseTime.AddDate(0, 0, -1), Lines: 7, Language: "go", Project: "p1", Editor: "neovim", Branch: "main", File: "/src/p1/util/util_test.go"},
+	}
+	for _, a := range activities {
+		insertActivity(t, storage, a)
+	}
+
+	calc := NewCalculator(time.UTC)
+	calculate := func(filter core.ActivityFilter) *APIStats {
+		s, err := calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 30, Filter: filter})
+		require.NoError(t, err)
+		return s
+	}
+
+	byEditor := calculate(core.ActivityFilter{Editor: "NeoVim"})
+	require.Equal(t, 10, byEditor.Today.TotalLines)
+	require.Equal(t, 17, byEditor.AllTime.TotalLines)
+	require.Equal(t, 2, byEditor.StreakInfo.Current)
+
+	byBranch := calculate(core.ActivityFilter{Branch: "feature"})
+	require.Equal(t, 5, byBranch.AllTime.TotalLines)
+	require.Equal(t, 1, byBranch.StreakInfo.Current)
+
+	tests := calculate(core.ActivityFilter{File: "*_test.go"})
+	require.Equal(t, 12, tests.AllTime.TotalLines)
+	require.Equal(t, 7, tests.Records.HighestDailyOutput.Lines)
+
+	relative := calculate(core.ActivityFilter{File: "cmd/*"})
+	require.Equal(t, 15, relative.AllTime.TotalLines)
+
+	absolute := calculate(core.ActivityFilter{File: "/src/p1/util/*"})
+	require.Equal(t, 7, absolute.AllTime.TotalLines)
+
+	combined := calculate(core.ActivityFilter{Editor: "neovim", File: "*_test.go"})
+	require.Equal(t, 7, combined.AllTime.TotalLines)
+	require.Equal(t, 0, combined.Today.TotalLines)
+}
+
+func TestCalculator_CalculateAPI_Commands(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	baseTime := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+
+	activities := []core.Activity{
+		{ID: "1", Timestamp: baseTime, Lines: 10, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/a.go"},
+		{ID: "2", Timestamp: baseTime.Add(1 * time.Minute), Duration: 300, Language: "go", Project: "p1", Editor: "terminal", Category: core.CategoryTesting, Command: "go", ExitCode: 1},
+		{ID: "3", Timestamp: baseTime.Add(10 * time.Minute), Duration: 200, Language: "go", Project: "p1", Editor: "terminal", Category: core.CategoryTesting, Command: "go"},
+		{ID: "4", Timestamp: baseTime.Add(20 * time.Minute), Duration: 60, Language: "go", Project: "p1", Editor: "terminal", Category: core.CategoryBuilding, Command: "go"},
+	}
+
+	for _, a := range activities {
+		insertActivity(t, storage, a)
+	}
+
+	calc := NewCalculator(time.UTC)
+	stats, err := calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 30})
+	require.NoError(t, err)
+
+	require.Len(t, stats.Today.Commands, 2)
+	runs := stats.Today.Commands[0]
+	require.Equal(t, core.CategoryTesting, runs.Category)
+	require.Equal(t, 2, runs.Runs)
+	require.Equal(t, 1, runs.Failures)
+	require.InDelta(t, 50.0, runs.FailureRate, 0.01)
+	require.InDelta(t, 500.0, runs.Time, 0.01)
+
+	// Command run time counts towards the day instead of the 2 minute gap cap.
+	require.InDelta(t, 120.0+300+200+60, stats.Today.TotalTime, 0.01)
+}
+
+func TestCalculator_CalculateAPI_ProjectLanguages(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	baseTime := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, time.UTC)
+
+	// p1 is mostly Go with some Lua; p2 is docs only. Markdown is the
+	// busiest language overall, which must not leak into p1.
+	activities := []core.Activity{
+		{Language: "markdown", Project: "p2", File: "/p2/a.md"},
+		{Language: "markdown", Project: "p2", File: "/p2/b.md"},
+		{Language: "markdown", Project: "p2", File: "/p2/c.md"},
+		{Language: "markdown", Project: "p2", File: "/p2/d.md"},
+		{Language: "lua", Project: "p1", File: "/p1/init.lua"},
+		{Language: "go", Project: "p1", File: "/p1/a.go"},
+		{Language: "go", Project: "p1", File: "/p1/b.go"},
+	}
+	for i, a := range activities {
+		a.ID = core.GenerateID()
+		a.Timestamp = baseTime.Add(time.Duration(i) * time.Minute)
+		a.Editor = "neovim"
+		a.Lines = 1
+		a.Duration = 60
+		insertActivity(t, storage, a)
+	}
+
+	check := func(s *APIStats) {
+		t.Helper()
+		projects := make(map[string]APIProjectStats)
+		for _, p := range s.AllTime.Projects {
+			projects[p.Name] = p
+		}
+
+		p1 := projects["p1"]
+		require.Equal(t, "go", p1.MainLanguage)
+		require.Len(t, p1.Languages, 2)
+		require.Equal(t, "go", p1.Languages[0].Name)
+		require.Equal(t, 120.0, p1.Languages[0].Time)
+		require.InDelta(t, 66.67, p1.Languages[0].PercentTotal, 0.01)
+		require.Equal(t, "lua", p1.Languages[1].Name)
+
+		p2 := projects["p2"]
+		require.Equal(t, "Mixed", p2.MainLanguage)
+		require.Len(t, p2.Languages, 1)
+		require.Equal(t, "markdown", p2.Languages[0].Name)
+	}
+
+	calc := NewCalculator(time.UTC)
+	s, err := calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 30})
+	require.NoError(t, err)
+	check(s)
+
+	s, err = calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 30, Filter: core.ActivityFilter{Editor: "neovim"}})
+	require.NoError(t, err)
+	check(s)
+
+	var mainLang string
+	require.NoError(t, storage.GetDB().QueryRow(
+		`SELECT main_language FROM daily_project_summary WHERE project = 'p1'`).Scan(&mainLang))
+	require.Equal(t, "go", mainLang)
+
+	require.NoError(t, storage.RebuildSummaries())
+	require.NoError(t, storage.GetDB().QueryRow(
+		`SELECT main_language FROM daily_project_summary WHERE project = 'p1'`).Scan(&mainLang))
+	require.Equal(t, "go", mainLang, "rebuild must not pick the last language seen")
+}
+
+func TestSQLiteStorage_BackfillsProjectLanguages(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	storage, err := core.NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+
+	now := time.Now()
+	insertActivity(t, storage, core.Activity{ID: "1", Timestamp: now.Add(-time.Minute), Lines: 4, Language: "go", Project: "p1", File: "/p1/a.go"})
+	insertActivity(t, storage, core.Activity{ID: "2", Timestamp: now, Lines: 1, Language: "lua", Project: "p1", File: "/p1/a.lua"})
+
+	saved, err := storage.GetProjectLanguageSummary(now.AddDate(0, 0, -1), now)
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+
+	// Databases from before the table existed have it empty.
+	_, err = storage.GetDB().Exec(`DELETE FROM daily_project_language_summary`)
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+
+	storage, err = core.NewSQLiteStorage(dbPath)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	rows, err := storage.GetProjectLanguageSummary(now.AddDate(0, 0, -1), now)
+	require.NoError(t, err)
+	require.Equal(t, saved, rows)
+}
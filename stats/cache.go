@@ -8,6 +8,7 @@ import (
 type StatsCache struct {
 	mu        sync.RWMutex
 	stats     *APIStats
+	opts      APIOptions
 	generated time.Time
 	ttl       time.Duration
 }
@@ -27,6 +28,10 @@ func (c *StatsCache) Get(opts APIOptions) (*APIStats, bool) {
 		return nil, false
 	}
 
+	if !c.opts.equal(opts) {
+		return nil, false
+	}
+
 	if time.Since(c.generated) > c.ttl {
 		return nil, false
 	}
@@ -34,11 +39,12 @@ func (c *StatsCache) Get(opts APIOptions) (*APIStats, bool) {
 	return c.stats, true
 }
 
-func (c *StatsCache) Set(stats *APIStats) {
+func (c *StatsCache) Set(opts APIOptions, stats *APIStats) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.stats = stats
+	c.opts = opts
 	c.generated = time.Now()
 }
 
@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/util"
+)
+
+// heatmapLevel buckets a day's duration into levels 1-4 by its share of the
+// busiest day, like GitHub's contribution graph. Days without activity are 0.
+func heatmapLevel(duration, maxDuration float64) int {
+	if maxDuration <= 0 || duration <= 0 {
+		return 0
+	}
+	ratio := duration / maxDuration
+	switch {
+	case ratio > 0.75:
+		return 4
+	case ratio > 0.5:
+		return 3
+	case ratio > 0.25:
+		return 2
+	}
+	return 1
+}
+
+// CalendarDays builds heatmap days for the dates from..to from daily
+// summaries. The result is padded to whole weeks from Monday to Sunday; days
+// outside the range or in the future have level -1, as in WeeklyHeatmap.
+func CalendarDays(daily []core.DailySummary, from, to time.Time, tz *time.Location) []HeatmapDay {
+	tz = util.DefaultLocation(tz)
+	from = util.StartOfDay(from, tz)
+	to = util.StartOfDay(to, tz)
+	today := util.StartOfDay(time.Now(), tz)
+
+	byDate := make(map[string]core.DailySummary, len(daily))
+	maxDuration := 0.0
+	for _, d := range daily {
+		byDate[d.Date] = d
+		maxDuration = max(maxDuration, d.TotalTime)
+	}
+
+	start := util.StartOfWeek(from, tz)
+	end := util.StartOfWeek(to, tz).AddDate(0, 0, 6)
+
+	var days []HeatmapDay
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		ds := byDate[date]
+
+		day := HeatmapDay{
+			Date:  date,
+			Level: heatmapLevel(ds.TotalTime, maxDuration),
+			Lines: ds.TotalLines,
+			Time:  int64(ds.TotalTime),
+		}
+		if d.Before(from) || d.After(to) || d.After(today) {
+			day.Level = -1
+		}
+		days = append(days, day)
+	}
+	return days
+}
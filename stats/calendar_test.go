@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestCalendarDays_FromDailySummaries(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	day1 := time.Date(2025, 3, 4, 10, 0, 0, 0, time.Local) // a Tuesday
+	day2 := time.Date(2025, 3, 6, 10, 0, 0, 0, time.Local)
+
+	activities := []core.Activity{
+		{ID: "1", Timestamp: day1, Lines: 10, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/a.go"},
+		{ID: "2", Timestamp: day1.Add(2 * time.Minute), Lines: 5, Language: "go", Project: "p1", Editor: "neovim", File: "/p1/b.go"},
+		{ID: "3", Timestamp: day2, Lines: 7, Language: "rust", Project: "p2", Editor: "neovim", File: "/p2/main.rs"},
+		{ID: "4", Timestamp: day2.Add(1 * time.Minute), Lines: 1, Language: "rust", Project: "p2", Editor: "neovim", File: "/p2/lib.rs"},
+	}
+	for _, a := range activities {
+		insertActivity(t, storage, a)
+	}
+
+	from := time.Date(2025, 3, 5, 0, 0, 0, 0, time.Local)
+	to := time.Date(2025, 3, 12, 0, 0, 0, 0, time.Local)
+
+	daily, err := storage.GetDailySummaries(day1, to)
+	require.NoError(t, err)
+	require.Len(t, daily, 2)
+	require.Equal(t, "2025-03-04", daily[0].Date)
+	require.Equal(t, 15, daily[0].TotalLines)
+
+	filtered, err := storage.WithFilter(core.ActivityFilter{Language: "Rust"}).GetDailySummaries(day1, to)
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	require.Equal(t, "2025-03-06", filtered[0].Date)
+	require.Equal(t, 8, filtered[0].TotalLines)
+
+	days := CalendarDays(daily, from, to, time.Local)
+	require.Len(t, days, 14, "padded to whole weeks")
+	require.Equal(t, "2025-03-03", days[0].Date, "starts on a Monday")
+	require.Equal(t, "2025-03-16", days[13].Date)
+
+	byDate := make(map[string]HeatmapDay)
+	for _, d := range days {
+		byDate[d.Date] = d
+	}
+	require.Equal(t, -1, byDate["2025-03-04"].Level, "before the range")
+	require.Positive(t, byDate["2025-03-06"].Level)
+	require.Equal(t, 0, byDate["2025-03-07"].Level)
+	require.Equal(t, -1, byDate["2025-03-13"].Level, "after the range")
+}
+
+func TestHeatmapLevel(t *testing.T) {
+	require.Equal(t, 0, heatmapLevel(0, 100))
+	require.Equal(t, 0, heatmapLevel(10, 0))
+	require.Equal(t, 1, heatmapLevel(10, 100))
+	require.Equal(t, 2, heatmapLevel(30, 100))
+	require.Equal(t, 3, heatmapLevel(60, 100))
+	require.Equal(t, 4, heatmapLevel(100, 100))
+}
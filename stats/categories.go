@@ -0,0 +1,71 @@
+package stats
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/tduyng/codeme/core"
+)
+
+var testFilePatterns = []string{
+	"*_test.*", "test_*.py", "*.test.*", "*.spec.*", "*_spec.rb",
+}
+
+// Matched case-sensitively so that e.g. Latest.java is not a test.
+var classTestPatterns = []string{
+	"*Test.java", "*Tests.java", "*Test.kt", "*Tests.swift", "*Test.php", "*Tests.cs",
+}
+
+var testDirs = map[string]bool{
+	"test": true, "tests": true, "__tests__": true, "spec": true, "testdata": true,
+}
+
+var buildFiles = map[string]bool{
+	"makefile": true, "justfile": true, "dockerfile": true, "cmakelists.txt": true,
+	"build.gradle": true, "build.gradle.kts": true, "pom.xml": true, "meson.build": true,
+	"build.zig": true, "build.rs": true, "taskfile.yml": true, "docker-compose.yml": true,
+	"docker-compose.yaml": true, "compose.yml": true, "compose.yaml": true,
+	".goreleaser.yaml": true, ".goreleaser.yml": true,
+}
+
+// InferCategory guesses what kind of work a file edit was when the editor did
+// not say. Test files win over everything else, then build files, then the
+// language class (docs vs code).
+func InferCategory(filePath, language string) string {
+	if filePath == "" {
+		return core.CategoryCoding
+	}
+
+	base := filepath.Base(filePath)
+	name := strings.ToLower(base)
+	for _, pattern := range testFilePatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return core.CategoryTesting
+		}
+	}
+	for _, pattern := range classTestPatterns {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return core.CategoryTesting
+		}
+	}
+
+	slashed := filepath.ToSlash(strings.ToLower(filePath))
+	for _, dir := range strings.Split(filepath.Dir(slashed), "/") {
+		if testDirs[dir] {
+			return core.CategoryTesting
+		}
+	}
+
+	if buildFiles[name] || strings.Contains(slashed, ".github/workflows/") {
+		return core.CategoryBuilding
+	}
+
+	switch GetLanguageClass(language) {
+	case "doc":
+		return core.CategoryDocs
+	case "meta":
+		return core.CategoryBuilding
+	}
+
+	return core.CategoryCoding
+}
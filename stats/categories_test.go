@@ -0,0 +1,41 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestInferCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		language string
+		expected string
+	}{
+		{"go source", "/p/stats/api.go", "go", core.CategoryCoding},
+		{"go test", "/p/stats/api_test.go", "go", core.CategoryTesting},
+		{"python test prefix", "/p/test_models.py", "python", core.CategoryTesting},
+		{"ts spec", "/p/src/app.spec.ts", "typescript", core.CategoryTesting},
+		{"js test", "/p/src/app.test.js", "javascript", core.CategoryTesting},
+		{"tests dir", "/p/tests/helpers.rs", "rust", core.CategoryTesting},
+		{"jest dir", "/p/src/__tests__/util.ts", "typescript", core.CategoryTesting},
+		{"java test class", "/p/src/UserServiceTest.java", "java", core.CategoryTesting},
+		{"java non-test class", "/p/src/Latest.java", "java", core.CategoryCoding},
+		{"makefile", "/p/Makefile", "", core.CategoryBuilding},
+		{"dockerfile", "/p/Dockerfile", "", core.CategoryBuilding},
+		{"workflow", "/p/.github/workflows/ci.yml", "yaml", core.CategoryBuilding},
+		{"cmake by class", "/p/cmake/deps.cmake", "cmake", core.CategoryBuilding},
+		{"markdown", "/p/README.md", "markdown", core.CategoryDocs},
+		{"rst", "/p/docs/index.rst", "rst", core.CategoryDocs},
+		{"yaml config", "/p/config.yaml", "yaml", core.CategoryCoding},
+		{"no file", "", "go", core.CategoryCoding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, InferCategory(tt.file, tt.language))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/tduyng/codeme/core"
+)
+
+var testCommands = map[string]bool{
+	"pytest": true, "jest": true, "vitest": true, "mocha": true, "rspec": true,
+	"phpunit": true, "ctest": true, "tox": true, "nox": true, "playwright": true,
+	"cypress": true, "bats": true, "gotestsum": true,
+}
+
+var buildCommands = map[string]bool{
+	"make": true, "just": true, "cmake": true, "ninja": true, "meson": true,
+	"bazel": true, "gradle": true, "gradlew": true, "mvn": true, "msbuild": true,
+	"tsc": true, "webpack": true, "vite": true, "esbuild": true, "rollup": true,
+	"docker": true, "podman": true, "gcc": true, "g++": true, "clang": true,
+	"clang++": true, "rustc": true, "javac": true, "zig": true, "goreleaser": true,
+}
+
+var runCommands = map[string]bool{
+	"node": true, "deno": true, "bun": true, "python": true, "python3": true,
+	"ruby": true, "php": true, "java": true,
+}
+
+var testSubcommands = map[string]bool{
+	"test": true, "tests": true, "check": true, "spec": true, "bench": true,
+	"nextest": true, "e2e": true,
+}
+
+var buildSubcommands = map[string]bool{
+	"build": true, "compile": true, "install": true, "package": true,
+	"bundle": true, "release": true, "publish": true, "generate": true,
+}
+
+var runSubcommands = map[string]bool{
+	"run": true, "start": true, "serve": true, "dev": true, "up": true,
+	"exec": true, "watch": true, "preview": true,
+}
+
+var scriptRunners = map[string]bool{
+	"npm": true, "pnpm": true, "yarn": true, "bun": true,
+}
+
+var commandLanguages = map[string]string{
+	"go": "go", "gotestsum": "go", "goreleaser": "go",
+	"cargo": "rust", "rustc": "rust",
+	"npm": "javascript", "npx": "javascript", "pnpm": "javascript", "yarn": "javascript",
+	"node": "javascript", "bun": "javascript", "jest": "javascript", "vitest": "javascript",
+	"deno": "typescript", "tsc": "typescript",
+	"python": "python", "python3": "python", "pytest": "python", "pip": "python",
+	"poetry": "python", "uv": "python", "tox": "python",
+	"ruby": "ruby", "bundle": "ruby", "rake": "ruby", "rspec": "ruby",
+	"mvn": "java", "gradle": "java", "gradlew": "java", "javac": "java",
+	"dotnet": "c#", "mix": "elixir", "zig": "zig", "swift": "swift",
+	"php": "php", "composer": "php", "phpunit": "php",
+}
+
+// CommandName reduces a command line to the program name that gets stored,
+// so arguments (paths, tokens, URLs) never end up in the database.
+func CommandName(program string) string {
+	name := filepath.Base(program)
+	return strings.TrimSuffix(strings.ToLower(name), ".exe")
+}
+
+// InferCommandCategory guesses whether a wrapped command was testing,
+// building or running something. It returns "" when it cannot tell.
+func InferCommandCategory(program string, args []string) string {
+	name := CommandName(program)
+
+	if testCommands[name] {
+		return core.CategoryTesting
+	}
+	var positional []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, strings.ToLower(arg))
+		}
+	}
+
+	if len(positional) > 0 {
+		sub := positional[0]
+		// "docker compose up" and "npm run test:unit" name the real action
+		// one word further.
+		if len(positional) > 1 && (sub == "compose" || (scriptRunners[name] && (sub == "run" || sub == "run-script"))) {
+			sub = positional[1]
+		}
+		sub, _, _ = strings.Cut(sub, ":")
+
+		switch {
+		case testSubcommands[sub]:
+			return core.CategoryTesting
+		case buildSubcommands[sub]:
+			return core.CategoryBuilding
+		case runSubcommands[sub]:
+			return core.CategoryRunning
+		}
+	}
+
+	switch {
+	case buildCommands[name]:
+		return core.CategoryBuilding
+	case runCommands[name]:
+		return core.CategoryRunning
+	}
+
+	return ""
+}
+
+// CommandLanguage returns the language a toolchain command belongs to, or ""
+// for generic tools.
+func CommandLanguage(program string) string {
+	return commandLanguages[CommandName(program)]
+}
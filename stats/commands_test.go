@@ -0,0 +1,46 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestInferCommandCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		args     []string
+		expected string
+	}{
+		{"go test", "go", []string{"test", "./..."}, core.CategoryTesting},
+		{"go build", "go", []string{"build", "-o", "bin/app"}, core.CategoryBuilding},
+		{"go run", "go", []string{"run", "."}, core.CategoryRunning},
+		{"cargo flags first", "cargo", []string{"--release", "build"}, core.CategoryBuilding},
+		{"cargo nextest", "cargo", []string{"nextest", "run"}, core.CategoryTesting},
+		{"pytest", "/usr/bin/pytest", []string{"-x"}, core.CategoryTesting},
+		{"make", "make", nil, core.CategoryBuilding},
+		{"make test", "make", []string{"test"}, core.CategoryTesting},
+		{"npm test", "npm", []string{"test"}, core.CategoryTesting},
+		{"npm run script", "npm", []string{"run", "test:unit"}, core.CategoryTesting},
+		{"pnpm dev", "pnpm", []string{"dev"}, core.CategoryRunning},
+		{"docker compose up", "docker", []string{"compose", "up", "-d"}, core.CategoryRunning},
+		{"docker build", "docker", []string{"build", "."}, core.CategoryBuilding},
+		{"node script", "node", []string{"server.js"}, core.CategoryRunning},
+		{"unknown", "ls", []string{"-la"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, InferCommandCategory(tt.program, tt.args))
+		})
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	require.Equal(t, "go", CommandName("/usr/local/go/bin/go"))
+	require.Equal(t, "cargo", CommandName(`Cargo.exe`))
+	require.Equal(t, "rust", CommandLanguage("/home/me/.cargo/bin/cargo"))
+	require.Equal(t, "", CommandLanguage("ls"))
+}
@@ -0,0 +1,245 @@
+package stats
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/util"
+)
+
+// FileHistory is everything recorded about one file.
+type FileHistory struct {
+	Path         string           `json:"path"`
+	Project      string           `json:"project"`
+	FirstEdited  time.Time        `json:"first_edited"`
+	LastEdited   time.Time        `json:"last_edited"`
+	TotalTime    float64          `json:"total_time"`
+	TotalLines   int              `json:"total_lines"`
+	Edits        int              `json:"edits"`
+	SessionCount int              `json:"session_count"`
+	Days         []FileDay        `json:"days"`
+	Branches     []APIBranchStats `json:"branches"`
+}
+
+// FileDay is one day the file was touched.
+type FileDay struct {
+	Date  string  `json:"date"`
+	Time  float64 `json:"time"`
+	Lines int     `json:"lines"`
+	Edits int     `json:"edits"`
+}
+
+// FileChurn counts how often a file was come back to. Files reopened in
+// many sessions on many days are refactoring candidates.
+type FileChurn struct {
+	Name     string  `json:"name"`
+	Project  string  `json:"project"`
+	Sessions int     `json:"sessions"`
+	Days     int     `json:"days"`
+	Edits    int     `json:"edits"`
+	Time     float64 `json:"time"`
+	Lines    int     `json:"lines"`
+}
+
+// CalculateFile returns the history of the file at path. A path that is
+// not absolute matches any file ending in it, as long as only one does.
+// Other fields of opts.Filter still apply.
+func (c *Calculator) CalculateFile(storage core.Storage, path string, opts APIOptions) (*FileHistory, error) {
+	fs, ok := storage.(core.FilterableStorage)
+	if !ok {
+		return nil, fmt.Errorf("storage does not support filtering")
+	}
+	filter := opts.Filter
+	filter.File = core.EscapeGlob(path)
+
+	touched, err := fs.WithFilter(filter).GetActivitiesSince(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Now.IsZero() {
+		touched = util.Filter(touched, func(a core.Activity) bool {
+			return !a.Timestamp.After(opts.Now)
+		})
+	}
+	if len(touched) == 0 {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	files := util.NewStringSet()
+	for _, a := range touched {
+		files.Add(a.File)
+	}
+	if files.Len() > 1 {
+		matches := files.ToSortedSlice()
+		return nil, fmt.Errorf("%s matches %d files, be more specific: %s",
+			path, len(matches), strings.Join(matches[:min(5, len(matches))], ", "))
+	}
+	file := touched[0].File
+
+	// Durations and sessions depend on what else was going on, so they are
+	// worked out over all activity since the file was first touched.
+	filter.File = ""
+	activities, err := c.activitiesBetween(fs.WithFilter(filter), touched[0].Timestamp, opts.Now)
+	if err != nil {
+		return nil, err
+	}
+	activities, sessions := NewSessionManager(0, 0).GroupAndCalculate(activities)
+
+	h := &FileHistory{Path: file}
+	days := make(map[string]*FileDay)
+	branches := make(map[string]float64)
+	inSessions := make(map[int]bool)
+	for _, a := range activities {
+		if a.File != file {
+			continue
+		}
+		if h.FirstEdited.IsZero() {
+			h.FirstEdited = a.Timestamp
+		}
+		h.LastEdited = a.Timestamp
+		h.Project = a.Project
+		h.TotalTime += a.Duration
+		h.TotalLines += a.Lines
+		h.Edits++
+
+		date := util.DateString(a.Timestamp, c.timezone)
+		d := days[date]
+		if d == nil {
+			d = &FileDay{Date: date}
+			days[date] = d
+		}
+		d.Time += a.Duration
+		d.Lines += a.Lines
+		d.Edits++
+
+		if a.Branch != "" {
+			branches[a.Branch] += a.Duration
+		}
+		if i := sessionAt(sessions, a.Timestamp); i >= 0 {
+			inSessions[i] = true
+		}
+	}
+	h.SessionCount = len(inSessions)
+
+	for _, d := range days {
+		h.Days = append(h.Days, *d)
+	}
+	sort.Slice(h.Days, func(i, j int) bool {
+		return h.Days[i].Date < h.Days[j].Date
+	})
+
+	for branch, t := range branches {
+		pct := 0.0
+		if h.TotalTime > 0 {
+			pct = t / h.TotalTime * 100
+		}
+		h.Branches = append(h.Branches, APIBranchStats{Name: branch, Time: t, PercentTotal: pct})
+	}
+	sort.Slice(h.Branches, func(i, j int) bool {
+		return h.Branches[i].Time > h.Branches[j].Time
+	})
+
+	return h, nil
+}
+
+// CalculateChurn lists the n files revisited in the most sessions over the
+// last opts.LoadRecentDays days (all history when 0). Paths are relative to
+// their project.
+func (c *Calculator) CalculateChurn(storage core.Storage, opts APIOptions, n int) ([]FileChurn, error) {
+	if fs, ok := storage.(core.FilterableStorage); ok && !opts.Filter.IsEmpty() {
+		storage = fs.WithFilter(opts.Filter)
+	}
+
+	var since time.Time
+	if opts.LoadRecentDays > 0 {
+		now := opts.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		since = util.StartOfDay(now, c.timezone).AddDate(0, 0, -(opts.LoadRecentDays - 1))
+	}
+	activities, err := c.activitiesBetween(storage, since, opts.Now)
+	if err != nil {
+		return nil, err
+	}
+	activities, sessions := NewSessionManager(0, 0).GroupAndCalculate(activities)
+
+	type churnAgg struct {
+		FileChurn
+		sessions util.StringSet
+		days     util.StringSet
+	}
+	files := make(map[string]*churnAgg)
+	for _, a := range activities {
+		if a.File == "" {
+			continue
+		}
+		f := files[a.File]
+		if f == nil {
+			f = &churnAgg{
+				FileChurn: FileChurn{Name: ProjectRelativePath(a.File, a.Project), Project: a.Project},
+				sessions:  util.NewStringSet(),
+				days:      util.NewStringSet(),
+			}
+			files[a.File] = f
+		}
+		f.Edits++
+		f.Time += a.Duration
+		f.Lines += a.Lines
+		f.days.Add(util.DateString(a.Timestamp, c.timezone))
+		if i := sessionAt(sessions, a.Timestamp); i >= 0 {
+			f.sessions.Add(sessions[i].ID)
+		}
+	}
+
+	churn := make([]FileChurn, 0, len(files))
+	for _, f := range files {
+		f.Sessions = f.sessions.Len()
+		f.Days = f.days.Len()
+		churn = append(churn, f.FileChurn)
+	}
+	slices.SortFunc(churn, func(a, b FileChurn) int {
+		return cmp.Or(
+			cmp.Compare(b.Sessions, a.Sessions),
+			cmp.Compare(b.Days, a.Days),
+			cmp.Compare(b.Time, a.Time),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	if n > 0 && len(churn) > n {
+		churn = churn[:n]
+	}
+	return churn, nil
+}
+
+// activitiesBetween loads activities from since, dropping any after now
+// when it is set.
+func (c *Calculator) activitiesBetween(storage core.Storage, since, now time.Time) ([]core.Activity, error) {
+	activities, err := storage.GetActivitiesSince(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load activities: %w", err)
+	}
+	if !now.IsZero() {
+		activities = util.Filter(activities, func(a core.Activity) bool {
+			return !a.Timestamp.After(now)
+		})
+	}
+	return activities, nil
+}
+
+// sessionAt returns the index of the session containing t, or -1 when t
+// fell in a session too short to count.
+func sessionAt(sessions []core.Session, t time.Time) int {
+	i := sort.Search(len(sessions), func(i int) bool {
+		return !sessions[i].EndTime.Before(t)
+	})
+	if i < len(sessions) && !sessions[i].StartTime.After(t) {
+		return i
+	}
+	return -1
+}
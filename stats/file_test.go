@@ -0,0 +1,115 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func insertFileEdits(t *testing.T, storage *core.SQLiteStorage) time.Time {
+	t.Helper()
+	day1 := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 8, 12, 14, 0, 0, 0, time.UTC)
+
+	edits := []struct {
+		at     time.Time
+		file   string
+		branch string
+	}{
+		{day1, "/src/codeme/stats/api.go", "main"},
+		{day1.Add(time.Minute), "/src/codeme/main.go", "main"},
+		{day1.Add(2 * time.Minute), "/src/codeme/stats/api.go", "main"},
+		{day1.Add(3 * time.Hour), "/src/codeme/stats/api.go", "fix"},
+		{day2, "/src/codeme/stats/api.go", "fix"},
+		{day2.Add(time.Minute), "/src/other/stats/api.go", ""},
+	}
+	for _, e := range edits {
+		project := "codeme"
+		if e.file == "/src/other/stats/api.go" {
+			project = "other"
+		}
+		insertActivity(t, storage, core.Activity{
+			ID: core.GenerateID(), Timestamp: e.at, Lines: 4, Language: "go",
+			Project: project, Editor: "vim", File: e.file, Branch: e.branch, IsWrite: true,
+		})
+	}
+	return day2.Add(time.Hour)
+}
+
+func TestCalculator_CalculateFile(t *testing.T) {
+	time.Local = time.UTC
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+	now := insertFileEdits(t, storage)
+
+	calc := NewCalculator(time.UTC)
+	h, err := calc.CalculateFile(storage, "/src/codeme/stats/api.go", APIOptions{Now: now})
+	require.NoError(t, err)
+
+	require.Equal(t, "codeme", h.Project)
+	require.Equal(t, 4, h.Edits)
+	require.Equal(t, 16, h.TotalLines)
+	require.Equal(t, 3, h.SessionCount)
+	require.Len(t, h.Days, 2)
+	require.Equal(t, "2026-08-10", h.Days[0].Date)
+	require.Equal(t, 3, h.Days[0].Edits)
+	require.Len(t, h.Branches, 2)
+
+	_, err = calc.CalculateFile(storage, "stats/api.go", APIOptions{Now: now})
+	require.ErrorContains(t, err, "matches 2 files")
+
+	h, err = calc.CalculateFile(storage, "codeme/main.go", APIOptions{Now: now})
+	require.NoError(t, err)
+	require.Equal(t, "/src/codeme/main.go", h.Path)
+	require.Equal(t, 60.0, h.TotalTime, "time runs until the next activity, in any file")
+
+	_, err = calc.CalculateFile(storage, "nope.go", APIOptions{Now: now})
+	require.Error(t, err)
+}
+
+func TestCalculator_CalculateFileLiteralPath(t *testing.T) {
+	time.Local = time.UTC
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	at := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	for i, file := range []string{"/src/web/app/[id]/page.tsx", "/src/web/app/d/page.tsx"} {
+		insertActivity(t, storage, core.Activity{
+			ID: core.GenerateID(), Timestamp: at.Add(time.Duration(i) * time.Minute), Lines: 4,
+			Language: "typescript", Project: "web", Editor: "vim", File: file, IsWrite: true,
+		})
+	}
+
+	calc := NewCalculator(time.UTC)
+	h, err := calc.CalculateFile(storage, "app/[id]/page.tsx", APIOptions{Now: at.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Equal(t, "/src/web/app/[id]/page.tsx", h.Path)
+	require.Equal(t, 1, h.Edits)
+
+	_, err = calc.CalculateFile(storage, "app/*/page.tsx", APIOptions{Now: at.Add(time.Hour)})
+	require.ErrorContains(t, err, "file not found")
+}
+
+func TestCalculator_CalculateChurn(t *testing.T) {
+	time.Local = time.UTC
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+	now := insertFileEdits(t, storage)
+
+	calc := NewCalculator(time.UTC)
+	churn, err := calc.CalculateChurn(storage, APIOptions{Now: now, Filter: core.ActivityFilter{Project: "codeme"}}, 10)
+	require.NoError(t, err)
+	require.Len(t, churn, 2)
+	require.Equal(t, "stats/api.go", churn[0].Name)
+	require.Equal(t, 3, churn[0].Sessions)
+	require.Equal(t, 2, churn[0].Days)
+	require.Equal(t, "main.go", churn[1].Name)
+	require.Equal(t, 1, churn[1].Sessions)
+
+	churn, err = calc.CalculateChurn(storage, APIOptions{Now: now, LoadRecentDays: 1}, 10)
+	require.NoError(t, err)
+	require.Len(t, churn, 2, "only the last day")
+	require.Equal(t, 1, churn[0].Sessions)
+}
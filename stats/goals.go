@@ -0,0 +1,205 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/util"
+)
+
+// goalRecent is how many of the latest periods GoalStatus.Recent holds.
+const goalRecent = 14
+
+// GoalStatus is how a goal is going now and how it went in every day or
+// week since tracking began.
+type GoalStatus struct {
+	core.Goal
+	Current  float64 `json:"current"`
+	Progress float64 `json:"progress"`
+	Met      bool    `json:"met"`
+	// OnTrack is true when progress keeps pace with the time elapsed in
+	// the day or week.
+	OnTrack       bool    `json:"on_track"`
+	CurrentStreak int     `json:"current_streak"`
+	LongestStreak int     `json:"longest_streak"`
+	PeriodsMet    int     `json:"periods_met"`
+	PeriodsTotal  int     `json:"periods_total"`
+	SuccessRate   float64 `json:"success_rate"`
+	// Recent lists the latest periods, oldest first.
+	Recent []GoalResult `json:"recent"`
+}
+
+// GoalResult is a goal's outcome for the day or week starting on Start.
+type GoalResult struct {
+	Start string  `json:"start"`
+	Value float64 `json:"value"`
+	Met   bool    `json:"met"`
+}
+
+// EvaluateGoals evaluates the goals kept in storage, skipping those whose
+// project or language is outside filter.
+func (c *Calculator) EvaluateGoals(storage core.Storage, filter core.ActivityFilter, now time.Time) []GoalStatus {
+	gs, ok := storage.(core.GoalStorage)
+	if !ok {
+		return nil
+	}
+	goals, err := gs.GetGoals()
+	if err != nil {
+		return nil
+	}
+
+	var statuses []GoalStatus
+	for _, g := range goals {
+		scope := filter
+		if g.Project != "" {
+			if scope.Project != "" && scope.Project != g.Project {
+				continue
+			}
+			scope.Project = g.Project
+		}
+		if g.Language != "" {
+			if scope.Language != "" && scope.Language != g.Language {
+				continue
+			}
+			scope.Language = g.Language
+		}
+
+		scoped := storage
+		if !scope.IsEmpty() {
+			fs, ok := storage.(core.FilterableStorage)
+			if !ok {
+				continue
+			}
+			scoped = fs.WithFilter(scope)
+		}
+		days, err := scoped.GetDailySummaries(time.Time{}, now)
+		if err != nil {
+			continue
+		}
+		statuses = append(statuses, c.evaluateGoal(g, days, now))
+	}
+	return statuses
+}
+
+// evaluateGoal checks g against every day or week from the first day in
+// days up to now. The running day or week does not break a streak until
+// it is over.
+func (c *Calculator) evaluateGoal(g core.Goal, days []core.DailySummary, now time.Time) GoalStatus {
+	status := GoalStatus{Goal: g}
+	if len(days) == 0 {
+		return status
+	}
+
+	values := make(map[string]float64, len(days))
+	for _, d := range days {
+		if !g.AppliesOn(util.DateFrom(d.Date, c.timezone).Weekday()) {
+			continue
+		}
+		if g.Metric == core.GoalLines {
+			values[d.Date] = float64(d.TotalLines)
+		} else {
+			values[d.Date] = d.TotalTime
+		}
+	}
+
+	first := util.DateFrom(days[0].Date, c.timezone)
+	today := util.StartOfDay(now, c.timezone)
+
+	var results []GoalResult
+	var running time.Time
+	if g.Period == core.GoalWeekly {
+		running = util.StartOfWeek(now, c.timezone)
+		for week := util.StartOfWeek(first, c.timezone); !week.After(running); week = week.AddDate(0, 0, 7) {
+			r := GoalResult{Start: util.DateString(week, c.timezone)}
+			for d := range 7 {
+				r.Value += values[util.DateString(week.AddDate(0, 0, d), c.timezone)]
+			}
+			results = append(results, r)
+		}
+	} else {
+		running = today
+		for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+			if !g.AppliesOn(day.Weekday()) {
+				continue
+			}
+			date := util.DateString(day, c.timezone)
+			results = append(results, GoalResult{Start: date, Value: values[date]})
+		}
+	}
+
+	streak := 0
+	for i := range results {
+		results[i].Met = results[i].Value >= g.Target
+		if results[i].Met {
+			streak++
+			status.LongestStreak = max(status.LongestStreak, streak)
+		} else {
+			streak = 0
+		}
+	}
+
+	// The running period only counts once it is met or over.
+	completed := results
+	if len(results) > 0 && results[len(results)-1].Start == util.DateString(running, c.timezone) {
+		last := results[len(results)-1]
+		status.Current = last.Value
+		status.Met = last.Met
+		if !last.Met {
+			completed = results[:len(results)-1]
+		}
+	}
+
+	for _, r := range completed {
+		if r.Met {
+			status.PeriodsMet++
+		}
+	}
+	status.PeriodsTotal = len(completed)
+	if status.PeriodsTotal > 0 {
+		status.SuccessRate = float64(status.PeriodsMet) / float64(status.PeriodsTotal) * 100
+	}
+	for i := len(completed) - 1; i >= 0 && completed[i].Met; i-- {
+		status.CurrentStreak++
+	}
+
+	status.Progress = min(100, status.Current/g.Target*100)
+	length := 24 * time.Hour
+	if g.Period == core.GoalWeekly {
+		length *= 7
+	}
+	switch {
+	case status.Met:
+		status.OnTrack = true
+	case g.Period == core.GoalDaily && !g.AppliesOn(today.Weekday()):
+		// A day off.
+		status.OnTrack = true
+	default:
+		status.OnTrack = onPace(status.Progress, running, length, now)
+	}
+
+	status.Recent = results[max(0, len(results)-goalRecent):]
+	return status
+}
+
+// onPace reports whether progress, in percent, keeps up with the share of
+// the period of length starting at start that has passed by now.
+func onPace(progress float64, start time.Time, length time.Duration, now time.Time) bool {
+	return progress >= float64(now.Sub(start))/float64(length)*100
+}
+
+// dailyTargets returns the time and lines targets of the first daily goals
+// that cover all activity and apply today, for DailyGoals.
+func dailyTargets(goals []GoalStatus, today time.Weekday) (timeGoal float64, linesGoal int, ok bool) {
+	for _, g := range goals {
+		if g.Period != core.GoalDaily || g.Project != "" || g.Language != "" || !g.AppliesOn(today) {
+			continue
+		}
+		if g.Metric == core.GoalTime && timeGoal == 0 {
+			timeGoal = g.Target
+		}
+		if g.Metric == core.GoalLines && linesGoal == 0 {
+			linesGoal = int(g.Target)
+		}
+	}
+	return timeGoal, linesGoal, timeGoal > 0 || linesGoal > 0
+}
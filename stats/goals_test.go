@@ -0,0 +1,123 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestEvaluateGoal_Daily(t *testing.T) {
+	calc := NewCalculator(time.UTC)
+	// Wednesday afternoon; the goal skips weekends.
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	days := []core.DailySummary{
+		{Date: "2026-03-04", TotalTime: 4000},
+		{Date: "2026-03-05", TotalTime: 1000},
+		{Date: "2026-03-06", TotalTime: 3600},
+		{Date: "2026-03-07", TotalTime: 100},
+		{Date: "2026-03-09", TotalTime: 5000},
+		{Date: "2026-03-10", TotalTime: 3600},
+		{Date: "2026-03-11", TotalTime: 1200},
+	}
+	weekdays, _ := core.ParseWeekdays("weekdays")
+	g := core.Goal{Period: core.GoalDaily, Metric: core.GoalTime, Target: 3600, Weekdays: weekdays}
+
+	s := calc.evaluateGoal(g, days, now)
+	require.Equal(t, 1200.0, s.Current)
+	require.False(t, s.Met)
+	require.InDelta(t, 33.3, s.Progress, 0.1)
+	require.False(t, s.OnTrack, "half the day is gone")
+	require.Equal(t, 3, s.CurrentStreak, "weekends and today don't break it")
+	require.Equal(t, 3, s.LongestStreak)
+	require.Equal(t, 4, s.PeriodsMet)
+	require.Equal(t, 5, s.PeriodsTotal)
+	require.Equal(t, 80.0, s.SuccessRate)
+	require.Len(t, s.Recent, 6)
+	require.Equal(t, "2026-03-11", s.Recent[5].Start)
+
+	s = calc.evaluateGoal(g, days, time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC))
+	require.True(t, s.OnTrack, "Saturday is a day off")
+	require.Equal(t, 0, s.CurrentStreak, "Thursday and Friday were missed")
+	require.Equal(t, 8, s.PeriodsTotal)
+}
+
+func TestEvaluateGoal_Weekly(t *testing.T) {
+	calc := NewCalculator(time.UTC)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	days := []core.DailySummary{
+		{Date: "2026-02-23", TotalLines: 300},
+		{Date: "2026-03-01", TotalLines: 300},
+		{Date: "2026-03-02", TotalLines: 100},
+		{Date: "2026-03-09", TotalLines: 700},
+	}
+	g := core.Goal{Period: core.GoalWeekly, Metric: core.GoalLines, Target: 500}
+
+	s := calc.evaluateGoal(g, days, now)
+	require.True(t, s.Met)
+	require.Equal(t, 700.0, s.Current)
+	require.Equal(t, 100.0, s.Progress)
+	require.Equal(t, 3, s.PeriodsTotal, "the running week counts once met")
+	require.Equal(t, 2, s.PeriodsMet)
+	require.Equal(t, 1, s.CurrentStreak)
+	require.Equal(t, "2026-02-23", s.Recent[0].Start)
+	require.True(t, s.Recent[0].Met)
+	require.False(t, s.Recent[1].Met)
+}
+
+func TestCalculator_CalculateAPI_Goals(t *testing.T) {
+	time.Local = time.UTC
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2026, 8, 14, 18, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		insertActivity(t, storage, core.Activity{
+			ID: core.GenerateID(), Timestamp: now.Add(-time.Hour + time.Duration(i)*time.Minute),
+			Lines: 100, Language: "go", Project: "codeme", Editor: "vim", File: "/a.go", IsWrite: true,
+		})
+	}
+	insertActivity(t, storage, core.Activity{
+		ID: core.GenerateID(), Timestamp: now.Add(-30 * time.Minute),
+		Lines: 50, Language: "rust", Project: "other", Editor: "vim", File: "/b.rs", IsWrite: true,
+	})
+
+	_, err := storage.AddGoal(core.Goal{Period: core.GoalDaily, Metric: core.GoalLines, Target: 320})
+	require.NoError(t, err)
+	_, err = storage.AddGoal(core.Goal{Period: core.GoalDaily, Metric: core.GoalLines, Target: 320, Project: "codeme"})
+	require.NoError(t, err)
+	_, err = storage.AddGoal(core.Goal{Period: core.GoalDaily, Metric: core.GoalTime, Target: 7200, Weekdays: []time.Weekday{time.Monday}})
+	require.NoError(t, err)
+
+	calc := NewCalculator(time.UTC)
+	s, err := calc.CalculateAPI(storage, APIOptions{Now: now})
+	require.NoError(t, err)
+	require.Len(t, s.Goals, 3)
+	require.True(t, s.Goals[0].Met)
+	require.Equal(t, 350.0, s.Goals[0].Current)
+	require.False(t, s.Goals[1].Met)
+	require.Equal(t, 300.0, s.Goals[1].Current)
+	require.Equal(t, 320, s.Today.DailyGoals.LinesGoal, "daily goals come from the configured goals")
+	require.Zero(t, s.Today.DailyGoals.TimeGoal, "the time goal is for Mondays")
+	require.Equal(t, s.Goals[0].OnTrack, s.Today.DailyGoals.OnTrack)
+
+	calc.Invalidate()
+	s, err = calc.CalculateAPI(storage, APIOptions{Now: now, Filter: core.ActivityFilter{Project: "other"}})
+	require.NoError(t, err)
+	require.Len(t, s.Goals, 2, "goals of other projects are left out")
+	require.Equal(t, 50.0, s.Goals[0].Current)
+}
+
+func TestCalculator_CalculateDailyGoals(t *testing.T) {
+	day := time.Date(2026, 8, 14, 0, 0, 0, 0, time.UTC)
+	period := APIPeriodStats{StartDate: day, EndDate: day.Add(18 * time.Hour), TotalLines: 300}
+
+	calc := NewCalculator(time.UTC)
+	goals := calc.calculateDailyGoals(period, 0, 500)
+	require.Equal(t, 60.0, goals.LinesProgress)
+	require.False(t, goals.OnTrack, "60% with three quarters of the day gone")
+
+	period.EndDate = day.Add(12 * time.Hour)
+	require.True(t, calc.calculateDailyGoals(period, 0, 500).OnTrack)
+}
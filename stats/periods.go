@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tduyng/codeme/util"
+)
+
+// NamedPeriods can be requested through APIOptions.Periods on top of the
+// periods every APIStats has.
+var NamedPeriods = []string{"last_7_days", "last_30_days", "this_quarter", "this_year", "last_year"}
+
+// CustomPeriod is the default name of a period given by dates.
+const CustomPeriod = "custom"
+
+// PeriodRequest asks for one more period in APIStats.Periods: either one of
+// NamedPeriods, or From and To dates (both included) under any name.
+type PeriodRequest struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+// Resolve returns the period's bounds as of now: start included, end
+// excluded. Periods reaching into the future end at now.
+func (p PeriodRequest) Resolve(now time.Time, tz *time.Location) (start, end time.Time, err error) {
+	today := util.StartOfDay(now, tz)
+
+	if !p.From.IsZero() || !p.To.IsZero() {
+		if p.From.IsZero() {
+			return start, end, fmt.Errorf("period %s: from is required", p.name())
+		}
+		start = util.StartOfDay(p.From, tz)
+		end = today.AddDate(0, 0, 1)
+		if !p.To.IsZero() {
+			end = util.StartOfDay(p.To, tz).AddDate(0, 0, 1)
+		}
+		if !end.After(start) {
+			return start, end, fmt.Errorf("period %s: to is before from", p.name())
+		}
+		if end.After(now) {
+			end = now
+		}
+		if !end.After(start) {
+			return start, end, fmt.Errorf("period %s: from is in the future", p.name())
+		}
+		return start, end, nil
+	}
+
+	switch p.Name {
+	case "last_7_days":
+		return today.AddDate(0, 0, -6), now, nil
+	case "last_30_days":
+		return today.AddDate(0, 0, -29), now, nil
+	case "this_quarter":
+		month := time.Month((int(now.Month())-1)/3*3 + 1)
+		return time.Date(now.Year(), month, 1, 0, 0, 0, 0, tz), now, nil
+	case "this_year":
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, tz), now, nil
+	case "last_year":
+		return time.Date(now.Year()-1, 1, 1, 0, 0, 0, 0, tz), time.Date(now.Year(), 1, 1, 0, 0, 0, 0, tz), nil
+	}
+	return start, end, fmt.Errorf("unknown period: %s (want %s, or from and to dates)", p.Name, strings.Join(NamedPeriods, ", "))
+}
+
+func (p PeriodRequest) name() string {
+	if p.Name == "" {
+		return CustomPeriod
+	}
+	return p.Name
+}
+
+// equal compares options for the cache; Periods makes APIOptions
+// incomparable with ==.
+func (o APIOptions) equal(other APIOptions) bool {
+	return o.LoadRecentDays == other.LoadRecentDays &&
+		o.HeatmapWeeks == other.HeatmapWeeks &&
+		o.Filter == other.Filter &&
+		o.Now.Equal(other.Now) &&
+		slices.EqualFunc(o.Periods, other.Periods, func(a, b PeriodRequest) bool {
+			return a.Name == b.Name && a.From.Equal(b.From) && a.To.Equal(b.To)
+		})
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestPeriodRequest_Resolve(t *testing.T) {
+	now := time.Date(2026, 8, 14, 15, 30, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		req        PeriodRequest
+		start, end time.Time
+	}{
+		{PeriodRequest{Name: "last_7_days"}, day(2026, 8, 8), now},
+		{PeriodRequest{Name: "last_30_days"}, day(2026, 7, 16), now},
+		{PeriodRequest{Name: "this_quarter"}, day(2026, 7, 1), now},
+		{PeriodRequest{Name: "this_year"}, day(2026, 1, 1), now},
+		{PeriodRequest{Name: "last_year"}, day(2025, 1, 1), day(2026, 1, 1)},
+		{PeriodRequest{Name: "q1", From: day(2026, 1, 1), To: day(2026, 3, 31)}, day(2026, 1, 1), day(2026, 4, 1)},
+		{PeriodRequest{From: day(2026, 8, 1)}, day(2026, 8, 1), now},
+		{PeriodRequest{From: day(2026, 8, 1), To: day(2026, 12, 31)}, day(2026, 8, 1), now},
+	}
+	for _, tt := range tests {
+		start, end, err := tt.req.Resolve(now, time.UTC)
+		require.NoError(t, err, tt.req.Name)
+		require.Equal(t, tt.start, start, tt.req.Name)
+		require.Equal(t, tt.end, end, tt.req.Name)
+	}
+
+	for _, req := range []PeriodRequest{
+		{Name: "last_decade"},
+		{To: day(2026, 1, 1)},
+		{From: day(2026, 3, 1), To: day(2026, 2, 1)},
+		{From: day(2026, 9, 1)},
+		{From: day(2026, 8, 15), To: day(2026, 8, 20)},
+	} {
+		_, _, err := req.Resolve(now, time.UTC)
+		require.Error(t, err)
+	}
+}
+
+func TestAPIOptions_Equal(t *testing.T) {
+	a := APIOptions{LoadRecentDays: 30, Periods: []PeriodRequest{{Name: "this_year"}}}
+	b := APIOptions{LoadRecentDays: 30, Periods: []PeriodRequest{{Name: "this_year"}}}
+	require.True(t, a.equal(b))
+
+	b.Periods = append(b.Periods, PeriodRequest{Name: "last_year"})
+	require.False(t, a.equal(b))
+}
+
+func TestCalculator_CalculateAPI_Periods(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2026, 8, 14, 18, 0, 0, 0, time.UTC)
+	old := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		insertActivity(t, storage, core.Activity{
+			ID: core.GenerateID(), Timestamp: old.Add(time.Duration(i) * 10 * time.Minute),
+			Lines: 5, Language: "go", Project: "codeme", Editor: "vim", File: "/a.go", IsWrite: true,
+		})
+	}
+	insertActivity(t, storage, core.Activity{
+		ID: core.GenerateID(), Timestamp: now.Add(-2 * time.Hour),
+		Lines: 7, Language: "rust", Project: "other", Editor: "vim", File: "/b.rs", IsWrite: true,
+	})
+
+	calc := NewCalculator(time.UTC)
+	s, err := calc.CalculateAPI(storage, APIOptions{
+		LoadRecentDays: 30,
+		Now:            now,
+		Periods: []PeriodRequest{
+			{Name: "last_year"},
+			{Name: "last_30_days"},
+			{Name: "june", From: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, s.Periods, 3)
+
+	lastYear, ok := s.Period("last_year")
+	require.True(t, ok)
+	require.Equal(t, 15, lastYear.TotalLines)
+	require.Equal(t, "codeme", lastYear.Projects[0].Name)
+	require.Len(t, lastYear.Sessions, 1, "sessions come from activities loaded beyond LoadRecentDays")
+	require.Equal(t, 9, lastYear.PeakHour)
+
+	recent, _ := s.Period("last_30_days")
+	require.Equal(t, 7, recent.TotalLines)
+	require.Equal(t, "rust", recent.Languages[0].Name)
+
+	june, _ := s.Period("june")
+	require.Equal(t, 15, june.TotalLines)
+	require.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), june.EndDate)
+
+	require.Equal(t, 1, s.Meta.LoadedActivities, "periods don't widen the data window")
+	require.Equal(t, "last_30_days", s.Meta.DataWindow)
+	require.Len(t, s.DailyActivity, 1)
+	require.Equal(t, 7, s.Records.MostProductiveDay.Lines)
+
+	_, err = calc.CalculateAPI(storage, APIOptions{Periods: []PeriodRequest{{Name: "nope"}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package stats
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/util"
+)
+
+// projectWeeks is how many weeks of totals a project detail holds.
+const projectWeeks = 26
+
+// projectSessions is how many of the most recent sessions it lists.
+const projectSessions = 10
+
+type ProjectDetail struct {
+	Name           string                    `json:"name"`
+	FirstActivity  time.Time                 `json:"first_activity"`
+	LastActivity   time.Time                 `json:"last_activity"`
+	TotalTime      float64                   `json:"total_time"`
+	TotalLines     int                       `json:"total_lines"`
+	TotalFiles     int                       `json:"total_files"`
+	ActiveDays     int                       `json:"active_days"`
+	StreakInfo     StreakInfo                `json:"streak_info"`
+	Weekly         []WeekStat                `json:"weekly"`
+	Languages      []APIProjectLanguageStats `json:"languages"`
+	Branches       []APIBranchStats          `json:"branches"`
+	Files          []APIFileStats            `json:"top_files"`
+	Sessions       []APISession              `json:"sessions"`
+	SessionCount   int                       `json:"session_count"`
+	HourlyActivity []HourlyActivity          `json:"hourly_activity"`
+	PeakHour       int                       `json:"peak_hour"`
+}
+
+// WeekStat is the time spent in the week starting on Start (a Monday).
+type WeekStat struct {
+	Start string  `json:"start"`
+	Time  float64 `json:"time"`
+	Lines int     `json:"lines"`
+}
+
+type APIBranchStats struct {
+	Name         string  `json:"name"`
+	Time         float64 `json:"time"`
+	PercentTotal float64 `json:"percent_total"`
+}
+
+// CalculateProject gathers everything known about one project over its
+// whole history. Other fields of opts.Filter still apply.
+func (c *Calculator) CalculateProject(storage core.Storage, name string, opts APIOptions) (*ProjectDetail, error) {
+	fs, ok := storage.(core.FilterableStorage)
+	if !ok {
+		return nil, fmt.Errorf("storage does not support filtering")
+	}
+	filter := opts.Filter
+	filter.Project = name
+
+	activities, err := fs.WithFilter(filter).GetActivitiesSince(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if len(activities) == 0 {
+		return nil, fmt.Errorf("project not found: %s", name)
+	}
+
+	now := time.Now().In(c.timezone)
+	if !opts.Now.IsZero() {
+		now = opts.Now.In(c.timezone)
+		activities = util.Filter(activities, func(a core.Activity) bool {
+			return !a.Timestamp.After(now)
+		})
+		if len(activities) == 0 {
+			return nil, fmt.Errorf("project not found: %s", name)
+		}
+	}
+
+	activities, sessions := NewSessionManager(0, 0).GroupAndCalculate(activities)
+
+	detail := &ProjectDetail{
+		Name:          name,
+		FirstActivity: activities[0].Timestamp,
+		LastActivity:  activities[len(activities)-1].Timestamp,
+		StreakInfo:    NewStreakCalculator(c.timezone).CalculateAt(activities, now),
+		SessionCount:  len(sessions),
+	}
+
+	files := util.NewStringSet()
+	languages := make(map[string]*APIProjectLanguageStats)
+	branches := make(map[string]float64)
+	for _, a := range activities {
+		detail.TotalTime += a.Duration
+		detail.TotalLines += a.Lines
+		if a.File != "" {
+			files.Add(a.File)
+		}
+		l := languages[a.Language]
+		if l == nil {
+			l = &APIProjectLanguageStats{Name: a.Language}
+			languages[a.Language] = l
+		}
+		l.Time += a.Duration
+		l.Lines += a.Lines
+		if a.Branch != "" {
+			branches[a.Branch] += a.Duration
+		}
+	}
+	detail.TotalFiles = files.Len()
+
+	for _, l := range languages {
+		if detail.TotalTime > 0 {
+			l.PercentTotal = l.Time / detail.TotalTime * 100
+		}
+		detail.Languages = append(detail.Languages, *l)
+	}
+	sort.Slice(detail.Languages, func(i, j int) bool {
+		return detail.Languages[i].Time > detail.Languages[j].Time
+	})
+
+	for branch, t := range branches {
+		pct := 0.0
+		if detail.TotalTime > 0 {
+			pct = t / detail.TotalTime * 100
+		}
+		detail.Branches = append(detail.Branches, APIBranchStats{Name: branch, Time: t, PercentTotal: pct})
+	}
+	sort.Slice(detail.Branches, func(i, j int) bool {
+		return detail.Branches[i].Time > detail.Branches[j].Time
+	})
+
+	detail.Files = TopFiles(AggregateByFile(activities), detail.TotalTime, 10)
+	for i := range detail.Files {
+		detail.Files[i].Name = ProjectRelativePath(detail.Files[i].Name, name)
+	}
+
+	recent := ConvertSessionsToAPI(sessions)
+	slices.Reverse(recent)
+	detail.Sessions = recent[:min(projectSessions, len(recent))]
+
+	days := AggregateByDay(activities, c.timezone)
+	detail.ActiveDays = len(days)
+	detail.Weekly = c.weeklyTotals(days, detail.FirstActivity, now)
+
+	detail.HourlyActivity = c.buildHourlyActivity(AggregateByHour(activities, c.timezone), detail.TotalTime)
+	detail.PeakHour = c.findPeakHour(detail.HourlyActivity)
+
+	return detail, nil
+}
+
+// weeklyTotals sums days into weeks, from the week of first (at most
+// projectWeeks back) to the current week.
+func (c *Calculator) weeklyTotals(days map[string]*DayAgg, first, now time.Time) []WeekStat {
+	current := util.StartOfWeek(now, c.timezone)
+	start := util.StartOfWeek(first, c.timezone)
+	if oldest := current.AddDate(0, 0, -7*(projectWeeks-1)); start.Before(oldest) {
+		start = oldest
+	}
+
+	var weeks []WeekStat
+	for week := start; !week.After(current); week = week.AddDate(0, 0, 7) {
+		ws := WeekStat{Start: util.DateString(week, c.timezone)}
+		for d := range 7 {
+			if day, ok := days[util.DateString(week.AddDate(0, 0, d), c.timezone)]; ok {
+				ws.Time += day.Time
+				ws.Lines += day.Lines
+			}
+		}
+		weeks = append(weeks, ws)
+	}
+	return weeks
+}
+
+// ProjectRelativePath shortens a file to its path inside the project, found
+// by the last directory named after the project. Other paths are returned
+// unchanged.
+func ProjectRelativePath(file, project string) string {
+	parts := strings.Split(filepath.ToSlash(file), "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] == project {
+			return strings.Join(parts[i+1:], "/")
+		}
+	}
+	return file
+}
@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestProjectRelativePath(t *testing.T) {
+	require.Equal(t, "stats/api.go", ProjectRelativePath("/home/me/src/codeme/stats/api.go", "codeme"))
+	require.Equal(t, "main.go", ProjectRelativePath("/src/codeme/vendor/codeme/main.go", "codeme"), "the innermost match wins")
+	require.Equal(t, "/tmp/x.go", ProjectRelativePath("/tmp/x.go", "codeme"))
+	require.Equal(t, "/src/codeme", ProjectRelativePath("/src/codeme", "codeme"), "the project directory itself is not a file in it")
+}
+
+func TestCalculator_CalculateProject(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2026, 8, 14, 18, 0, 0, 0, time.UTC)
+	first := time.Date(2026, 7, 20, 9, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		insertActivity(t, storage, core.Activity{
+			ID: core.GenerateID(), Timestamp: first.Add(time.Duration(i) * time.Minute),
+			Lines: 5, Language: "go", Project: "codeme", Editor: "vim", Branch: "main",
+			File: "/src/codeme/stats/api.go", IsWrite: true,
+		})
+	}
+	for i := range 2 {
+		insertActivity(t, storage, core.Activity{
+			ID: core.GenerateID(), Timestamp: now.Add(-time.Hour + time.Duration(i)*time.Minute),
+			Lines: 2, Language: "markdown", Project: "codeme", Editor: "vim", Branch: "docs",
+			File: "/src/codeme/README.md", IsWrite: true,
+		})
+	}
+	insertActivity(t, storage, core.Activity{
+		ID: core.GenerateID(), Timestamp: now.Add(-30 * time.Minute),
+		Lines: 9, Language: "rust", Project: "other", Editor: "vim", File: "/src/other/a.rs", IsWrite: true,
+	})
+
+	calc := NewCalculator(time.UTC)
+	p, err := calc.CalculateProject(storage, "codeme", APIOptions{Now: now})
+	require.NoError(t, err)
+
+	require.Equal(t, "codeme", p.Name)
+	require.Equal(t, first, p.FirstActivity.UTC())
+	require.Equal(t, 19, p.TotalLines)
+	require.Equal(t, 2, p.TotalFiles)
+	require.Equal(t, 2, p.ActiveDays)
+	require.Equal(t, 2, p.SessionCount)
+	require.Equal(t, 1, p.StreakInfo.Current)
+
+	require.Equal(t, "go", p.Languages[0].Name)
+	require.Len(t, p.Languages, 2, "other projects are left out")
+	require.Len(t, p.Branches, 2)
+	require.Equal(t, "main", p.Branches[0].Name)
+
+	require.Equal(t, "stats/api.go", p.Files[0].Name)
+	require.Equal(t, "README.md", p.Files[1].Name)
+
+	require.True(t, p.Sessions[0].StartTime.After(p.Sessions[1].StartTime), "newest session first")
+	require.Equal(t, 9, p.PeakHour)
+
+	require.Len(t, p.Weekly, 4)
+	require.Equal(t, "2026-07-20", p.Weekly[0].Start)
+	require.Equal(t, 15, p.Weekly[0].Lines)
+	require.Equal(t, 0, p.Weekly[1].Lines)
+	require.Equal(t, 4, p.Weekly[3].Lines)
+
+	_, err = calc.CalculateProject(storage, "nope", APIOptions{Now: now})
+	require.Error(t, err)
+}
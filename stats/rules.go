@@ -0,0 +1,714 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Achievement rules are small expressions over coding metrics, e.g.
+//
+//	max_week(time("rust")) >= 10h
+//	count_weekends(lines > 0) >= 5
+//	max_day(projects) >= 3
+//
+// Numbers may carry a unit: s, m, h and d for durations (in seconds), k
+// for thousands. Comparisons and && || ! give 1 or 0. Metrics are listed
+// in ruleMetrics and functions in ruleFuncs; aggregations such as max_week
+// evaluate their argument on each day, week, month or weekend of activity.
+
+type ruleNode interface {
+	eval(env *ruleEnv) (float64, error)
+	// isTime reports whether the value is a duration, for display.
+	isTime() bool
+}
+
+type numNode struct {
+	value float64
+	time  bool
+}
+
+type strNode struct{ value string }
+
+type identNode struct{ name string }
+
+type callNode struct {
+	name string
+	args []ruleNode
+}
+
+type unaryNode struct {
+	op string
+	x  ruleNode
+}
+
+type binaryNode struct {
+	op   string
+	l, r ruleNode
+}
+
+func (n numNode) eval(*ruleEnv) (float64, error) { return n.value, nil }
+func (n numNode) isTime() bool                   { return n.time }
+
+func (n strNode) eval(*ruleEnv) (float64, error) {
+	return 0, fmt.Errorf("string %q is not a number", n.value)
+}
+func (n strNode) isTime() bool { return false }
+
+func (n identNode) eval(env *ruleEnv) (float64, error) {
+	m, ok := ruleMetrics[n.name]
+	if !ok {
+		return 0, fmt.Errorf("unknown metric: %s", n.name)
+	}
+	return m.value(env), nil
+}
+
+func (n identNode) isTime() bool { return ruleMetrics[n.name].time }
+
+func (n callNode) eval(env *ruleEnv) (float64, error) {
+	f, ok := ruleFuncs[n.name]
+	if !ok {
+		return 0, fmt.Errorf("unknown function: %s", n.name)
+	}
+	return f.call(env, n.args)
+}
+
+func (n callNode) isTime() bool {
+	f := ruleFuncs[n.name]
+	if f.time {
+		return true
+	}
+	// Aggregations and min/max take the unit of what they aggregate.
+	return f.passUnit && len(n.args) > 0 && n.args[0].isTime()
+}
+
+func (n unaryNode) eval(env *ruleEnv) (float64, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if n.op == "!" {
+		return boolValue(x == 0), nil
+	}
+	return -x, nil
+}
+
+func (n unaryNode) isTime() bool { return n.op == "-" && n.x.isTime() }
+
+func (n binaryNode) eval(env *ruleEnv) (float64, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	// && and || short-circuit.
+	switch n.op {
+	case "&&":
+		if l == 0 {
+			return 0, nil
+		}
+	case "||":
+		if l != 0 {
+			return 1, nil
+		}
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, nil
+		}
+		return l / r, nil
+	case "<":
+		return boolValue(l < r), nil
+	case "<=":
+		return boolValue(l <= r), nil
+	case ">":
+		return boolValue(l > r), nil
+	case ">=":
+		return boolValue(l >= r), nil
+	case "==":
+		return boolValue(l == r), nil
+	case "!=":
+		return boolValue(l != r), nil
+	case "&&", "||":
+		return boolValue(r != 0), nil
+	}
+	return 0, fmt.Errorf("unknown operator: %s", n.op)
+}
+
+func (n binaryNode) isTime() bool {
+	switch n.op {
+	case "+", "-":
+		return n.l.isTime() || n.r.isTime()
+	case "*", "/":
+		return n.l.isTime() != n.r.isTime()
+	}
+	return false
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ruleScope holds the totals of one day, week, month, weekend or all time.
+type ruleScope struct {
+	start          time.Time
+	time           float64
+	lines          float64
+	files          map[string]bool
+	totalFiles     int
+	projects       map[string]float64
+	languages      map[string]*langTotals
+	hours          [24]float64
+	sessions       int
+	longestSession float64
+	activeDays     int
+}
+
+type langTotals struct {
+	time  float64
+	lines float64
+}
+
+func newRuleScope(start time.Time) *ruleScope {
+	return &ruleScope{
+		start:     start,
+		files:     make(map[string]bool),
+		projects:  make(map[string]float64),
+		languages: make(map[string]*langTotals),
+	}
+}
+
+func (s *ruleScope) language(name string) *langTotals {
+	name = strings.ToLower(name)
+	l := s.languages[name]
+	if l == nil {
+		l = &langTotals{}
+		s.languages[name] = l
+	}
+	return l
+}
+
+func (s *ruleScope) merge(o *ruleScope) {
+	s.time += o.time
+	s.lines += o.lines
+	for f := range o.files {
+		s.files[f] = true
+	}
+	for p, t := range o.projects {
+		s.projects[p] += t
+	}
+	for name, l := range o.languages {
+		mine := s.language(name)
+		mine.time += l.time
+		mine.lines += l.lines
+	}
+	for h := range s.hours {
+		s.hours[h] += o.hours[h]
+	}
+	s.sessions += o.sessions
+	s.longestSession = max(s.longestSession, o.longestSession)
+	s.activeDays += o.activeDays
+}
+
+// ruleEnv is what rules are evaluated against: the current scope, plus the
+// days and streak for the functions that look beyond it.
+type ruleEnv struct {
+	scope  *ruleScope
+	days   []*ruleScope
+	streak StreakInfo
+	tz     *time.Location
+}
+
+func (env *ruleEnv) in(scope *ruleScope) *ruleEnv {
+	inner := *env
+	inner.scope = scope
+	return &inner
+}
+
+type ruleMetric struct {
+	value func(env *ruleEnv) float64
+	time  bool
+}
+
+var ruleMetrics = map[string]ruleMetric{
+	"time":  {value: func(env *ruleEnv) float64 { return env.scope.time }, time: true},
+	"lines": {value: func(env *ruleEnv) float64 { return env.scope.lines }},
+	"files": {value: func(env *ruleEnv) float64 {
+		return float64(max(len(env.scope.files), env.scope.totalFiles))
+	}},
+	"projects": {value: func(env *ruleEnv) float64 {
+		n := 0
+		for p := range env.scope.projects {
+			if p != "" {
+				n++
+			}
+		}
+		return float64(n)
+	}},
+	// languages counts programming languages only.
+	"languages": {value: func(env *ruleEnv) float64 {
+		n := 0
+		for name := range env.scope.languages {
+			if IsCodeLanguage(name) {
+				n++
+			}
+		}
+		return float64(n)
+	}},
+	"sessions":        {value: func(env *ruleEnv) float64 { return float64(env.scope.sessions) }},
+	"longest_session": {value: func(env *ruleEnv) float64 { return env.scope.longestSession }, time: true},
+	"days":            {value: func(env *ruleEnv) float64 { return float64(env.scope.activeDays) }},
+	"weekday":         {value: func(env *ruleEnv) float64 { return float64(env.scope.start.Weekday()) }},
+	"streak":          {value: func(env *ruleEnv) float64 { return float64(env.streak.Current) }},
+	"longest_streak": {value: func(env *ruleEnv) float64 {
+		return float64(max(env.streak.Current, env.streak.Longest))
+	}},
+}
+
+type ruleFunc struct {
+	call func(env *ruleEnv, args []ruleNode) (float64, error)
+	time bool
+	// passUnit functions return a value in the unit of their first argument.
+	passUnit bool
+}
+
+var ruleFuncs map[string]ruleFunc
+
+func init() {
+	ruleFuncs = map[string]ruleFunc{
+		"time":         {call: languageMetric(func(l *langTotals) float64 { return l.time }), time: true},
+		"lines":        {call: languageMetric(func(l *langTotals) float64 { return l.lines })},
+		"project_time": {call: projectTime, time: true},
+		"hour_time":    {call: hourTime, time: true},
+		"max":          {call: extreme(math.Max), passUnit: true},
+		"min":          {call: extreme(math.Min), passUnit: true},
+		"max_day":      {call: bucketMax(dayBuckets), passUnit: true},
+		"max_week":     {call: bucketMax(weekBuckets), passUnit: true},
+		"max_month":    {call: bucketMax(monthBuckets), passUnit: true},
+		"count_days":   {call: bucketCount(dayBuckets)},
+		"count_weeks":  {call: bucketCount(weekBuckets)},
+		"count_weekends": {call: bucketCount(func(env *ruleEnv) []*ruleScope {
+			return groupDays(env.days, func(d time.Time) (time.Time, bool) {
+				switch d.Weekday() {
+				case time.Saturday:
+					return d, true
+				case time.Sunday:
+					return d.AddDate(0, 0, -1), true
+				}
+				return d, false
+			})
+		})},
+	}
+}
+
+func stringArg(args []ruleNode, n int, fn string) (string, error) {
+	if len(args) != n {
+		return "", fmt.Errorf("%s takes %d argument(s)", fn, n)
+	}
+	s, ok := args[0].(strNode)
+	if !ok {
+		return "", fmt.Errorf("%s takes a quoted name", fn)
+	}
+	return s.value, nil
+}
+
+func languageMetric(get func(*langTotals) float64) func(*ruleEnv, []ruleNode) (float64, error) {
+	return func(env *ruleEnv, args []ruleNode) (float64, error) {
+		name, err := stringArg(args, 1, "time and lines")
+		if err != nil {
+			return 0, err
+		}
+		l, ok := env.scope.languages[strings.ToLower(name)]
+		if !ok {
+			return 0, nil
+		}
+		return get(l), nil
+	}
+}
+
+func projectTime(env *ruleEnv, args []ruleNode) (float64, error) {
+	name, err := stringArg(args, 1, "project_time")
+	if err != nil {
+		return 0, err
+	}
+	return env.scope.projects[name], nil
+}
+
+func hourTime(env *ruleEnv, args []ruleNode) (float64, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("hour_time takes at least one hour")
+	}
+	var total float64
+	for _, a := range args {
+		h, err := a.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		if h < 0 || h > 23 {
+			return 0, fmt.Errorf("hour_time: hour %v is not between 0 and 23", h)
+		}
+		total += env.scope.hours[int(h)]
+	}
+	return total, nil
+}
+
+func extreme(pick func(a, b float64) float64) func(*ruleEnv, []ruleNode) (float64, error) {
+	return func(env *ruleEnv, args []ruleNode) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("min and max take at least one argument")
+		}
+		var result float64
+		for i, a := range args {
+			v, err := a.eval(env)
+			if err != nil {
+				return 0, err
+			}
+			if i == 0 {
+				result = v
+			} else {
+				result = pick(result, v)
+			}
+		}
+		return result, nil
+	}
+}
+
+func bucketMax(buckets func(*ruleEnv) []*ruleScope) func(*ruleEnv, []ruleNode) (float64, error) {
+	return func(env *ruleEnv, args []ruleNode) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("max_day, max_week and max_month take one argument")
+		}
+		// Checked against an empty scope so that errors show up even
+		// without activity.
+		best, err := args[0].eval(env.in(newRuleScope(time.Time{})))
+		if err != nil {
+			return 0, err
+		}
+		for _, b := range buckets(env) {
+			v, err := args[0].eval(env.in(b))
+			if err != nil {
+				return 0, err
+			}
+			best = max(best, v)
+		}
+		return best, nil
+	}
+}
+
+func bucketCount(buckets func(*ruleEnv) []*ruleScope) func(*ruleEnv, []ruleNode) (float64, error) {
+	return func(env *ruleEnv, args []ruleNode) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("count_days, count_weeks and count_weekends take one condition")
+		}
+		if _, err := args[0].eval(env.in(newRuleScope(time.Time{}))); err != nil {
+			return 0, err
+		}
+		var n float64
+		for _, b := range buckets(env) {
+			v, err := args[0].eval(env.in(b))
+			if err != nil {
+				return 0, err
+			}
+			if v != 0 {
+				n++
+			}
+		}
+		return n, nil
+	}
+}
+
+func dayBuckets(env *ruleEnv) []*ruleScope { return env.days }
+
+func weekBuckets(env *ruleEnv) []*ruleScope {
+	tz := env.tz
+	return groupDays(env.days, func(d time.Time) (time.Time, bool) {
+		return startOfWeekIn(d, tz), true
+	})
+}
+
+func monthBuckets(env *ruleEnv) []*ruleScope {
+	return groupDays(env.days, func(d time.Time) (time.Time, bool) {
+		return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, d.Location()), true
+	})
+}
+
+func startOfWeekIn(d time.Time, tz *time.Location) time.Time {
+	offset := (int(d.In(tz).Weekday()) + 6) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// groupDays merges days into buckets by the start key returns, keeping
+// their order. Days for which key returns false are left out.
+func groupDays(days []*ruleScope, key func(time.Time) (time.Time, bool)) []*ruleScope {
+	var buckets []*ruleScope
+	index := make(map[time.Time]*ruleScope)
+	for _, d := range days {
+		start, ok := key(d.start)
+		if !ok {
+			continue
+		}
+		b := index[start]
+		if b == nil {
+			b = newRuleScope(start)
+			index[start] = b
+			buckets = append(buckets, b)
+		}
+		b.merge(d)
+	}
+	return buckets
+}
+
+// parseRule parses a rule expression.
+func parseRule(src string) (ruleNode, error) {
+	tokens, err := lexRule(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &ruleParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type ruleToken struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value float64
+	time  bool
+}
+
+var ruleUnits = map[string]struct {
+	factor float64
+	time   bool
+}{
+	"s": {1, true},
+	"m": {60, true},
+	"h": {3600, true},
+	"d": {86400, true},
+	"k": {1000, false},
+}
+
+func lexRule(src string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			v, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", src[start:i], start)
+			}
+			t := ruleToken{kind: tokNumber, text: src[start:i], pos: start, value: v}
+			if i < len(src) {
+				if unit, ok := ruleUnits[string(src[i])]; ok && (i+1 == len(src) || !isIdentRune(rune(src[i+1]))) {
+					t.value *= unit.factor
+					t.time = unit.time
+					i++
+					t.text = src[start:i]
+				}
+			}
+			tokens = append(tokens, t)
+
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, ruleToken{kind: tokString, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+
+		case isIdentRune(c):
+			start := i
+			for i < len(src) && (isIdentRune(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, ruleToken{kind: tokIdent, text: src[start:i], pos: start})
+
+		default:
+			op := ""
+			for _, candidate := range []string{">=", "<=", "==", "!=", "&&", "||", ">", "<", "!", "+", "-", "*", "/", "(", ")", ","} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", string(c), i)
+			}
+			tokens = append(tokens, ruleToken{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, ruleToken{kind: tokEOF, text: "end of rule", pos: len(src)}), nil
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+type ruleParser struct {
+	tokens []ruleToken
+	pos    int
+}
+
+func (p *ruleParser) peek() ruleToken { return p.tokens[p.pos] }
+
+func (p *ruleParser) next() ruleToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *ruleParser) acceptOp(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *ruleParser) binary(next func() (ruleNode, error), ops ...string) (ruleNode, error) {
+	l, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp(ops...)
+		if !ok {
+			return l, nil
+		}
+		r, err := next()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *ruleParser) parseOr() (ruleNode, error)  { return p.binary(p.parseAnd, "||") }
+func (p *ruleParser) parseAnd() (ruleNode, error) { return p.binary(p.parseCmp, "&&") }
+func (p *ruleParser) parseSum() (ruleNode, error) { return p.binary(p.parseTerm, "+", "-") }
+func (p *ruleParser) parseTerm() (ruleNode, error) {
+	return p.binary(p.parseUnary, "*", "/")
+}
+
+func (p *ruleParser) parseCmp() (ruleNode, error) {
+	l, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.acceptOp(">=", "<=", "==", "!=", ">", "<")
+	if !ok {
+		return l, nil
+	}
+	r, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return binaryNode{op: op, l: l, r: r}, nil
+}
+
+func (p *ruleParser) parseUnary() (ruleNode, error) {
+	if op, ok := p.acceptOp("!", "-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleParser) parsePrimary() (ruleNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return numNode{value: t.value, time: t.time}, nil
+	case tokString:
+		return strNode{value: t.text}, nil
+	case tokIdent:
+		if _, ok := p.acceptOp("("); !ok {
+			return identNode{name: t.text}, nil
+		}
+		call := callNode{name: t.text}
+		if _, ok := p.acceptOp(")"); ok {
+			return call, nil
+		}
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if _, ok := p.acceptOp(","); ok {
+				continue
+			}
+			if _, ok := p.acceptOp(")"); !ok {
+				u := p.peek()
+				return nil, fmt.Errorf("expected ) at %d, got %q", u.pos, u.text)
+			}
+			return call, nil
+		}
+	case tokOp:
+		if t.text == "(" {
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.acceptOp(")"); !ok {
+				u := p.peek()
+				return nil, fmt.Errorf("expected ) at %d, got %q", u.pos, u.text)
+			}
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
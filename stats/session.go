@@ -49,17 +49,27 @@ func (sm *SessionManager) GroupAndCalculate(activities []core.Activity) ([]core.
 	languages := util.NewStringSet()
 
 	for i := range activities {
+		// Wrapped commands carry their measured run time; the gap to the
+		// next activity starts when the command finished.
+		timed := activities[i].IsTimed()
+		end := activities[i].Timestamp
+		if timed {
+			end = end.Add(time.Duration(activities[i].Duration * float64(time.Second)))
+		}
+
 		var gap float64
 		if i < len(activities)-1 {
-			gap = activities[i+1].Timestamp.Sub(activities[i].Timestamp).Seconds()
+			gap = activities[i+1].Timestamp.Sub(end).Seconds()
 		}
 
 		isLastActivity := i == len(activities)-1
 		isSessionEnd := isLastActivity || gap > timeoutSeconds
 
-		if isSessionEnd {
+		switch {
+		case timed:
+		case isSessionEnd:
 			activities[i].Duration = idleCapSeconds
-		} else {
+		default:
 			activities[i].Duration = gap
 		}
 
@@ -74,7 +84,7 @@ func (sm *SessionManager) GroupAndCalculate(activities []core.Activity) ([]core.
 				s := core.Session{
 					ID:         activities[sessionStart].ID,
 					StartTime:  activities[sessionStart].Timestamp,
-					EndTime:    activities[i].Timestamp,
+					EndTime:    end,
 					Duration:   sessionDuration,
 					Projects:   projects.ToSortedSlice(),
 					Languages:  languages.ToSortedSlice(),
@@ -46,3 +46,23 @@ func TestConvertSessionsToAPI(t *testing.T) {
 	require.Equal(t, "2", apiSessions[1].ID)
 	require.True(t, apiSessions[1].IsActive)
 }
+
+func TestSessionManager_TimedCommand(t *testing.T) {
+	baseTime := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	activities := []core.Activity{
+		{ID: "1", Timestamp: baseTime, Project: "p1", Language: "go"},
+		{ID: "2", Timestamp: baseTime.Add(1 * time.Minute), Duration: 1200, Command: "go", Project: "p1", Language: "go"},
+		{ID: "3", Timestamp: baseTime.Add(22 * time.Minute), Project: "p1", Language: "go"},
+	}
+
+	sm := NewSessionManager(0, 0)
+	result, sessions := sm.GroupAndCalculate(activities)
+
+	// The 20 minute build keeps its own duration and does not split the
+	// session, even though the next edit comes 21 minutes after it started.
+	require.Equal(t, 60.0, result[0].Duration)
+	require.Equal(t, 1200.0, result[1].Duration)
+	require.Len(t, sessions, 1)
+	require.Equal(t, 60.0+1200.0+120.0, sessions[0].Duration)
+}
@@ -1,6 +1,9 @@
 package stats
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/tduyng/codeme/core"
@@ -19,6 +22,12 @@ func NewStreakCalculator(timezone *time.Location) *StreakCalculator {
 }
 
 func (sc *StreakCalculator) Calculate(activities []core.Activity) StreakInfo {
+	return sc.CalculateAt(activities, time.Now())
+}
+
+// CalculateAt calculates streaks as of now, which decides whether the
+// streak is still active.
+func (sc *StreakCalculator) CalculateAt(activities []core.Activity, now time.Time) StreakInfo {
 	if len(activities) == 0 {
 		return StreakInfo{
 			Current:  0,
@@ -27,61 +36,255 @@ func (sc *StreakCalculator) Calculate(activities []core.Activity) StreakInfo {
 		}
 	}
 
-	dayMap := make(map[string]bool)
+	dates := make(map[string]bool)
 	var lastActivity time.Time
-	var latestDay string
-
 	for _, a := range activities {
-		day := util.DateString(a.Timestamp, sc.timezone)
-		dayMap[day] = true
-
+		dates[util.DateString(a.Timestamp, sc.timezone)] = true
 		if a.Timestamp.After(lastActivity) {
 			lastActivity = a.Timestamp
-			latestDay = day
 		}
 	}
 
-	current := 0
-	checkDay, _ := time.ParseInLocation("2006-01-02", latestDay, sc.timezone)
+	info := sc.calculate(dates, now)
+	info.LastActivity = lastActivity
+	return info
+}
 
-	for {
-		dayStr := util.DateString(checkDay, sc.timezone)
-		if !dayMap[dayStr] {
-			break
+// CalculateDays calculates streaks as of now from daily summaries, which
+// cover all history unlike loaded activities. LastActivity is the start of
+// the latest day.
+func (sc *StreakCalculator) CalculateDays(days []core.DailySummary, now time.Time) StreakInfo {
+	dates := make(map[string]bool, len(days))
+	for _, d := range days {
+		if d.TotalTime > 0 || d.TotalLines > 0 || d.ActivityCount > 0 {
+			dates[d.Date] = true
 		}
-		current++
-		checkDay = checkDay.AddDate(0, 0, -1)
 	}
+	return sc.calculate(dates, now)
+}
 
-	longest := 0
-	streak := 0
+// calculate finds the streaks in dates, the days with activity. Current is
+// the run of days up to the latest.
+func (sc *StreakCalculator) calculate(dates map[string]bool, now time.Time) StreakInfo {
+	if len(dates) == 0 {
+		return StreakInfo{}
+	}
+
+	var info StreakInfo
+	var prev time.Time
+	run := 0
+	for _, date := range slices.Sorted(maps.Keys(dates)) {
+		day := util.DateFrom(date, sc.timezone)
+		if run > 0 && day.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		info.Longest = max(info.Longest, run)
+		prev = day
+	}
+	info.Current = run
+	info.LastActivity = prev
+
+	today := util.DateString(now.In(sc.timezone), sc.timezone)
+	yesterday := util.DateString(now.In(sc.timezone).AddDate(0, 0, -1), sc.timezone)
+	info.IsActive = dates[today] || dates[yesterday]
+	return info
+}
+
+// BestStreak returns the longest run of days in days, oldest first, with
+// the time coded over it. Of runs as long, the first wins.
+func (sc *StreakCalculator) BestStreak(days []core.DailySummary) StreakRecord {
+	var best, run StreakRecord
+	var prev time.Time
+	for _, d := range days {
+		if d.TotalTime <= 0 && d.TotalLines <= 0 && d.ActivityCount <= 0 {
+			continue
+		}
+		day := util.DateFrom(d.Date, sc.timezone)
+		if run.DayCount > 0 && day.Equal(prev.AddDate(0, 0, 1)) {
+			run.DayCount++
+			run.EndDate = d.Date
+			run.TotalTime += d.TotalTime
+		} else {
+			run = StreakRecord{DayCount: 1, StartDate: d.Date, EndDate: d.Date, TotalTime: d.TotalTime}
+		}
+		if run.DayCount > best.DayCount {
+			best = run
+		}
+		prev = day
+	}
+	return best
+}
+
+// StreakRule is a streak on its own terms, set in the config file.
+type StreakRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Period is "daily" or "weekly", for weeks in a row.
+	Period string `json:"period,omitempty"`
+	// MinTime, e.g. "30m", and MinLines are what a day or week needs to
+	// count. Without them any activity counts.
+	MinTime  string `json:"min_time,omitempty"`
+	MinLines int    `json:"min_lines,omitempty"`
+	// RestDays, e.g. "weekends", don't break a daily streak when missed.
+	RestDays string `json:"rest_days,omitempty"`
+	// FreezesPerMonth is how many other missed days a month a daily
+	// streak survives.
+	FreezesPerMonth int `json:"freezes_per_month,omitempty"`
+}
+
+// RuleStreak is the streak of a StreakRule. LastActivity is the start of
+// the last day or week that counted.
+type RuleStreak struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Period string `json:"period"`
+	StreakInfo
+	// FreezesLeft is what is left of this month's freezes.
+	FreezesLeft int `json:"freezes_left"`
+}
+
+func (r StreakRule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("streak without id")
+	}
+	if r.Period != "" && r.Period != core.GoalDaily && r.Period != core.GoalWeekly {
+		return fmt.Errorf("streak %s: invalid period %q (want %s or %s)", r.ID, r.Period, core.GoalDaily, core.GoalWeekly)
+	}
+	if r.MinLines < 0 || r.FreezesPerMonth < 0 {
+		return fmt.Errorf("streak %s: min_lines and freezes_per_month can't be negative", r.ID)
+	}
+	if r.MinTime != "" {
+		if _, err := time.ParseDuration(r.MinTime); err != nil {
+			return fmt.Errorf("streak %s: invalid min_time: %w", r.ID, err)
+		}
+	}
+	if _, err := core.ParseWeekdays(r.RestDays); err != nil {
+		return fmt.Errorf("streak %s: %w", r.ID, err)
+	}
+	if r.Period == core.GoalWeekly && (r.RestDays != "" || r.FreezesPerMonth > 0) {
+		return fmt.Errorf("streak %s: rest days and freezes only apply to daily streaks", r.ID)
+	}
+	return nil
+}
 
-	checkDay, _ = time.ParseInLocation("2006-01-02", latestDay, sc.timezone)
+// CalculateRule calculates rule's streak from days, one summary per day
+// with activity, as of now. The running day or week doesn't break the
+// streak until it is over.
+func (sc *StreakCalculator) CalculateRule(rule StreakRule, days []core.DailySummary, now time.Time) RuleStreak {
+	result := RuleStreak{ID: rule.ID, Name: rule.Name, Period: rule.Period, FreezesLeft: rule.FreezesPerMonth}
+	if result.Period == "" {
+		result.Period = core.GoalDaily
+	}
+	minTime, _ := time.ParseDuration(rule.MinTime)
+	restDays, _ := core.ParseWeekdays(rule.RestDays)
 
-	for range 365 {
-		dayStr := util.DateString(checkDay, sc.timezone)
+	weekly := result.Period == core.GoalWeekly
+	periodOf := func(t time.Time) time.Time {
+		if weekly {
+			return util.StartOfWeek(t, sc.timezone)
+		}
+		return util.StartOfDay(t, sc.timezone)
+	}
 
-		if dayMap[dayStr] {
+	type totals struct {
+		time  float64
+		lines int
+	}
+	byPeriod := make(map[string]*totals)
+	var first time.Time
+	for _, d := range days {
+		start := periodOf(util.DateFrom(d.Date, sc.timezone))
+		key := util.DateString(start, sc.timezone)
+		if byPeriod[key] == nil {
+			byPeriod[key] = &totals{}
+		}
+		byPeriod[key].time += d.TotalTime
+		byPeriod[key].lines += d.TotalLines
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+	}
+	if first.IsZero() {
+		return result
+	}
+	counts := func(key string) bool {
+		t := byPeriod[key]
+		return t != nil && (t.time > 0 || t.lines > 0) &&
+			t.time >= minTime.Seconds() && t.lines >= rule.MinLines
+	}
+
+	running := periodOf(now)
+	streak := 0
+	frozen := make(map[string]int)
+	// Months of the freezes spent since the last day that counted; they
+	// are given back if the gap breaks the streak anyway.
+	var pending []string
+	for start := first; !start.After(running); {
+		key := util.DateString(start, sc.timezone)
+		month := start.Format("2006-01")
+		switch {
+		case counts(key):
 			streak++
-			if streak > longest {
-				longest = streak
+			result.Longest = max(result.Longest, streak)
+			result.LastActivity = start
+			pending = nil
+		case start.Equal(running):
+			// Still time to make it count.
+		case slices.Contains(restDays, start.Weekday()):
+			// A day off.
+		case streak > 0 && frozen[month] < rule.FreezesPerMonth:
+			frozen[month]++
+			pending = append(pending, month)
+		default:
+			for _, m := range pending {
+				frozen[m]--
 			}
-		} else {
+			pending = nil
 			streak = 0
 		}
 
-		checkDay = checkDay.AddDate(0, 0, -1)
+		if weekly {
+			start = start.AddDate(0, 0, 7)
+		} else {
+			start = start.AddDate(0, 0, 1)
+		}
 	}
 
-	today := util.DateString(time.Now().In(sc.timezone), sc.timezone)
-	yesterday := util.DateString(time.Now().In(sc.timezone).AddDate(0, 0, -1), sc.timezone)
+	result.Current = streak
+	result.IsActive = streak > 0
+	result.FreezesLeft = rule.FreezesPerMonth - frozen[running.Format("2006-01")]
+	return result
+}
 
-	isActive := dayMap[today] || dayMap[yesterday]
+// SetStreakRules sets the streaks calculated besides StreakInfo.
+func (c *Calculator) SetStreakRules(rules []StreakRule) error {
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("duplicate streak id: %s", r.ID)
+		}
+		seen[r.ID] = true
+	}
+	c.streakRules = rules
+	c.cache.Invalidate()
+	return nil
+}
 
-	return StreakInfo{
-		Current:      current,
-		Longest:      longest,
-		LastActivity: lastActivity,
-		IsActive:     isActive,
+// calculateStreakRules calculates the streaks of the rules over days, all
+// the daily summaries.
+func (c *Calculator) calculateStreakRules(days []core.DailySummary, now time.Time) []RuleStreak {
+	if len(c.streakRules) == 0 {
+		return nil
+	}
+	sc := NewStreakCalculator(c.timezone)
+	streaks := make([]RuleStreak, 0, len(c.streakRules))
+	for _, r := range c.streakRules {
+		streaks = append(streaks, sc.CalculateRule(r, days, now))
 	}
+	return streaks
 }
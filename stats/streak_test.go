@@ -1,6 +1,8 @@
 package stats
 
 import (
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -229,8 +231,9 @@ func TestStreakCalculator_EdgeCases(t *testing.T) {
 		calc := NewStreakCalculator(time.UTC)
 		result := calc.Calculate(activities)
 
-		// Should calculate longest within 365 day window
-		require.LessOrEqual(t, result.Longest, 365)
+		// The longest streak is not cut at a year
+		require.Equal(t, 400, result.Longest)
+		require.Equal(t, 400, result.Current)
 	})
 }
 
@@ -265,3 +268,219 @@ func TestStreakCalculator_LongestVsCurrent(t *testing.T) {
 	require.Equal(t, 10, result.Current)
 	require.GreaterOrEqual(t, result.Longest, 10) // Longest is at least as long as current
 }
+
+func TestStreakCalculator_CalculateRule(t *testing.T) {
+	// Friday 13 March 2026.
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.UTC)
+	hour := float64(time.Hour / time.Second)
+	summaries := func(days map[string]float64) []core.DailySummary {
+		var result []core.DailySummary
+		for date, seconds := range days {
+			result = append(result, core.DailySummary{Date: date, TotalTime: seconds, TotalLines: 10})
+		}
+		slices.SortFunc(result, func(a, b core.DailySummary) int { return strings.Compare(a.Date, b.Date) })
+		return result
+	}
+	workWeeks := map[string]float64{
+		"2026-03-02": hour, "2026-03-03": hour, "2026-03-04": hour, "2026-03-05": hour, "2026-03-06": hour,
+		"2026-03-09": hour, "2026-03-10": hour, "2026-03-11": hour, "2026-03-12": hour,
+	}
+
+	tests := []struct {
+		name    string
+		rule    StreakRule
+		days    map[string]float64
+		current int
+		longest int
+		left    int
+	}{
+		{
+			name:    "minimum time",
+			rule:    StreakRule{MinTime: "30m"},
+			days:    map[string]float64{"2026-03-09": hour, "2026-03-10": hour, "2026-03-11": 600, "2026-03-12": hour},
+			current: 1,
+			longest: 2,
+		},
+		{
+			name:    "weekends break a plain streak",
+			days:    workWeeks,
+			current: 4,
+			longest: 5,
+		},
+		{
+			name:    "weekends off",
+			rule:    StreakRule{RestDays: "weekends"},
+			days:    workWeeks,
+			current: 9,
+			longest: 9,
+		},
+		{
+			name:    "freeze covers a missed day",
+			rule:    StreakRule{FreezesPerMonth: 1},
+			days:    map[string]float64{"2026-03-09": hour, "2026-03-10": hour, "2026-03-12": hour},
+			current: 3,
+			longest: 3,
+			left:    0,
+		},
+		{
+			name:    "freezes run out",
+			rule:    StreakRule{FreezesPerMonth: 1},
+			days:    map[string]float64{"2026-03-08": hour, "2026-03-10": hour, "2026-03-12": hour},
+			current: 1,
+			longest: 2,
+			left:    0,
+		},
+		{
+			name: "a long gap gives its freezes back",
+			rule: StreakRule{FreezesPerMonth: 2},
+			days: map[string]float64{
+				"2026-03-02": hour, "2026-03-03": hour,
+				"2026-03-09": hour, "2026-03-11": hour, "2026-03-12": hour,
+			},
+			current: 3,
+			longest: 3,
+			left:    1,
+		},
+		{
+			name:    "today still counts once met",
+			rule:    StreakRule{MinLines: 10, FreezesPerMonth: 2},
+			days:    map[string]float64{"2026-03-12": hour, "2026-03-13": hour},
+			current: 2,
+			longest: 2,
+			left:    2,
+		},
+		{
+			name: "weekly",
+			rule: StreakRule{Period: core.GoalWeekly, MinTime: "5h"},
+			days: map[string]float64{
+				"2026-02-16": 2 * hour,
+				"2026-02-23": 3 * hour, "2026-02-25": 3 * hour,
+				"2026-03-02": 6 * hour,
+				"2026-03-10": hour,
+			},
+			current: 2,
+			longest: 2,
+		},
+	}
+
+	sc := NewStreakCalculator(time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.ID = "rule"
+			require.NoError(t, tt.rule.Validate())
+			streak := sc.CalculateRule(tt.rule, summaries(tt.days), now)
+			require.Equal(t, tt.current, streak.Current)
+			require.Equal(t, tt.longest, streak.Longest)
+			require.Equal(t, tt.current > 0, streak.IsActive)
+			require.Equal(t, tt.left, streak.FreezesLeft)
+		})
+	}
+
+	t.Run("freezes renew each month", func(t *testing.T) {
+		rule := StreakRule{ID: "rule", FreezesPerMonth: 1}
+		days := summaries(map[string]float64{"2026-02-27": hour, "2026-03-01": hour, "2026-03-03": hour})
+		streak := sc.CalculateRule(rule, days, time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC))
+		require.Equal(t, 3, streak.Current)
+		require.Equal(t, 0, streak.FreezesLeft)
+		require.Equal(t, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), streak.LastActivity)
+	})
+
+	t.Run("no activity", func(t *testing.T) {
+		streak := sc.CalculateRule(StreakRule{ID: "rule", Name: "Rule"}, nil, now)
+		require.Equal(t, RuleStreak{ID: "rule", Name: "Rule", Period: core.GoalDaily}, streak)
+	})
+}
+
+func TestStreakRule_Validate(t *testing.T) {
+	for _, rule := range []StreakRule{
+		{},
+		{ID: "a", Period: "monthly"},
+		{ID: "a", MinTime: "30"},
+		{ID: "a", RestDays: "someday"},
+		{ID: "a", FreezesPerMonth: -1},
+		{ID: "a", Period: core.GoalWeekly, RestDays: "weekends"},
+	} {
+		require.Error(t, rule.Validate(), "%+v", rule)
+	}
+
+	calc := NewCalculator(time.UTC)
+	require.Error(t, calc.SetStreakRules([]StreakRule{{ID: "a"}, {ID: "a"}}))
+}
+
+func TestCalculator_CalculateAPI_StreakRules(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.UTC)
+	// A long Monday to Friday streak, long ago.
+	for d := range 12 {
+		day := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC).AddDate(0, 0, d)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		insertActivity(t, storage, core.Activity{ID: day.String(), Timestamp: day, Duration: 600, Lines: 5, Language: "go"})
+	}
+
+	calc := NewCalculator(time.UTC)
+	require.NoError(t, calc.SetStreakRules([]StreakRule{{ID: "workdays", Name: "Workdays", RestDays: "weekends"}}))
+	stats, err := calc.CalculateAPI(storage, APIOptions{Now: now, LoadRecentDays: 30})
+	require.NoError(t, err)
+
+	require.Len(t, stats.Streaks, 1)
+	require.Equal(t, "Workdays", stats.Streaks[0].Name)
+	require.Equal(t, 10, stats.Streaks[0].Longest, "history beyond the loaded days counts")
+	require.Zero(t, stats.Streaks[0].Current)
+}
+
+func TestStreakCalculator_CalculateDays(t *testing.T) {
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.UTC)
+	var days []core.DailySummary
+	// 500 days in a row two years ago, then 3 up to yesterday.
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 500 {
+		days = append(days, core.DailySummary{Date: start.AddDate(0, 0, i).Format("2006-01-02"), TotalTime: 60, ActivityCount: 1})
+	}
+	for i := 3; i >= 1; i-- {
+		days = append(days, core.DailySummary{Date: now.AddDate(0, 0, -i).Format("2006-01-02"), TotalTime: 600, ActivityCount: 2})
+	}
+
+	sc := NewStreakCalculator(time.UTC)
+	info := sc.CalculateDays(days, now)
+	require.Equal(t, 3, info.Current)
+	require.Equal(t, 500, info.Longest)
+	require.True(t, info.IsActive)
+	require.Equal(t, time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC), info.LastActivity)
+
+	best := sc.BestStreak(days)
+	require.Equal(t, StreakRecord{DayCount: 500, StartDate: "2023-01-01", EndDate: "2024-05-14", TotalTime: 500 * 60}, best)
+
+	require.Equal(t, StreakInfo{}, sc.CalculateDays(nil, now))
+	require.Equal(t, StreakRecord{}, sc.BestStreak(nil))
+}
+
+func TestCalculator_CalculateAPI_FullHistoryStreak(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Date(2026, 3, 13, 12, 0, 0, 0, time.UTC)
+	// 20 days in a row, two years before the loaded window.
+	for d := range 20 {
+		day := time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC).AddDate(0, 0, d)
+		insertActivity(t, storage, core.Activity{ID: day.String(), Timestamp: day, Duration: 300, Lines: 5, Language: "go"})
+	}
+	insertActivity(t, storage, core.Activity{ID: "today", Timestamp: now.Add(-time.Hour), Duration: 300, Lines: 5, Language: "go"})
+
+	stats, err := NewCalculator(time.UTC).CalculateAPI(storage, APIOptions{Now: now, LoadRecentDays: 30})
+	require.NoError(t, err)
+
+	require.Equal(t, 1, stats.StreakInfo.Current)
+	require.Equal(t, 20, stats.StreakInfo.Longest)
+	require.True(t, stats.StreakInfo.IsActive)
+	require.WithinDuration(t, now.Add(-time.Hour), stats.StreakInfo.LastActivity, 0)
+
+	best := stats.Records.BestStreak
+	require.Equal(t, 20, best.DayCount)
+	require.Equal(t, "2024-01-10", best.StartDate)
+	require.Equal(t, "2024-01-29", best.EndDate)
+	require.Positive(t, best.TotalTime)
+}
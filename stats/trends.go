@@ -0,0 +1,161 @@
+package stats
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/tduyng/codeme/core"
+	"github.com/tduyng/codeme/util"
+)
+
+// trendDays is how many days of moving averages the API returns.
+const trendDays = 30
+
+type Trends struct {
+	DayOverDay     Comparison      `json:"day_over_day"`
+	WeekOverWeek   Comparison      `json:"week_over_week"`
+	MonthOverMonth Comparison      `json:"month_over_month"`
+	MovingAverages []MovingAverage `json:"moving_averages"`
+}
+
+// Comparison holds the change of each metric from one period to the one
+// before it. The current week and month are still running, so they are
+// compared with complete ones.
+type Comparison struct {
+	Time       Delta        `json:"time"`
+	Lines      Delta        `json:"lines"`
+	Files      Delta        `json:"files"`
+	Sessions   Delta        `json:"sessions"`
+	FocusScore Delta        `json:"focus_score"`
+	Languages  []NamedDelta `json:"languages"`
+	Projects   []NamedDelta `json:"projects"`
+}
+
+type Delta struct {
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+	Change   float64 `json:"change"`
+	// PercentChange is nil when there is nothing to compare with.
+	PercentChange *float64 `json:"percent_change"`
+}
+
+type NamedDelta struct {
+	Name string `json:"name"`
+	Delta
+}
+
+// MovingAverage is the average time and lines per day over the 7 and 30
+// days ending on Date. Days without activity count as zero.
+type MovingAverage struct {
+	Date     string  `json:"date"`
+	Time7d   float64 `json:"time_7d"`
+	Time30d  float64 `json:"time_30d"`
+	Lines7d  float64 `json:"lines_7d"`
+	Lines30d float64 `json:"lines_30d"`
+}
+
+func newDelta(cur, prev float64) Delta {
+	d := Delta{Current: cur, Previous: prev, Change: cur - prev}
+	if prev != 0 {
+		pct := (cur - prev) / prev * 100
+		d.PercentChange = &pct
+	}
+	return d
+}
+
+func compare(cur, prev APIPeriodStats) Comparison {
+	c := Comparison{
+		Time:       newDelta(cur.TotalTime, prev.TotalTime),
+		Lines:      newDelta(float64(cur.TotalLines), float64(prev.TotalLines)),
+		Files:      newDelta(float64(cur.TotalFiles), float64(prev.TotalFiles)),
+		Sessions:   newDelta(float64(cur.SessionCount), float64(prev.SessionCount)),
+		FocusScore: newDelta(float64(cur.FocusScore), float64(prev.FocusScore)),
+	}
+
+	curLangs, prevLangs := make(map[string]float64), make(map[string]float64)
+	for _, l := range cur.Languages {
+		curLangs[l.Name] = l.Time
+	}
+	for _, l := range prev.Languages {
+		prevLangs[l.Name] = l.Time
+	}
+	c.Languages = namedDeltas(curLangs, prevLangs)
+
+	curProjects, prevProjects := make(map[string]float64), make(map[string]float64)
+	for _, p := range cur.Projects {
+		curProjects[p.Name] = p.Time
+	}
+	for _, p := range prev.Projects {
+		prevProjects[p.Name] = p.Time
+	}
+	c.Projects = namedDeltas(curProjects, prevProjects)
+
+	return c
+}
+
+// namedDeltas compares time per name across both periods, so names that
+// dropped to zero show up too. The biggest current time comes first.
+func namedDeltas(cur, prev map[string]float64) []NamedDelta {
+	deltas := make([]NamedDelta, 0, len(cur))
+	for name, t := range cur {
+		deltas = append(deltas, NamedDelta{Name: name, Delta: newDelta(t, prev[name])})
+	}
+	for name, t := range prev {
+		if _, ok := cur[name]; !ok {
+			deltas = append(deltas, NamedDelta{Name: name, Delta: newDelta(0, t)})
+		}
+	}
+	slices.SortFunc(deltas, func(a, b NamedDelta) int {
+		return cmp.Or(
+			cmp.Compare(b.Current, a.Current),
+			cmp.Compare(b.Previous, a.Previous),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return deltas
+}
+
+// movingAverages returns one entry per day for the last trendDays days
+// up to now, oldest first. Days come from the daily summaries, so the
+// averages don't depend on how many days of activities were loaded.
+func movingAverages(days []core.DailySummary, now time.Time, tz *time.Location) []MovingAverage {
+	daily := make(map[string]core.DailySummary, len(days))
+	for _, d := range days {
+		daily[d.Date] = d
+	}
+
+	today := util.StartOfDay(now, tz)
+	first := today.AddDate(0, 0, -(trendDays - 1))
+
+	// Daily values from 29 days before the first entry, so every entry has
+	// a full 30-day window.
+	start := first.AddDate(0, 0, -29)
+	var times, lines []float64
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		day := daily[util.DateString(d, tz)]
+		times = append(times, day.TotalTime)
+		lines = append(lines, float64(day.TotalLines))
+	}
+
+	averages := make([]MovingAverage, 0, trendDays)
+	for i := range trendDays {
+		end := 29 + i
+		averages = append(averages, MovingAverage{
+			Date:     util.DateString(first.AddDate(0, 0, i), tz),
+			Time7d:   mean(times[end-6 : end+1]),
+			Time30d:  mean(times[end-29 : end+1]),
+			Lines7d:  mean(lines[end-6 : end+1]),
+			Lines30d: mean(lines[end-29 : end+1]),
+		})
+	}
+	return averages
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tduyng/codeme/core"
+)
+
+func TestNewDelta(t *testing.T) {
+	d := newDelta(150, 100)
+	require.Equal(t, 50.0, d.Change)
+	require.NotNil(t, d.PercentChange)
+	require.Equal(t, 50.0, *d.PercentChange)
+
+	d = newDelta(10, 0)
+	require.Equal(t, 10.0, d.Change)
+	require.Nil(t, d.PercentChange, "nothing to compare with")
+}
+
+func TestNamedDeltas(t *testing.T) {
+	deltas := namedDeltas(
+		map[string]float64{"go": 600, "rust": 120},
+		map[string]float64{"go": 300, "python": 900},
+	)
+
+	require.Len(t, deltas, 3)
+	require.Equal(t, "go", deltas[0].Name)
+	require.Equal(t, 100.0, *deltas[0].PercentChange)
+	require.Equal(t, "rust", deltas[1].Name)
+	require.Nil(t, deltas[1].PercentChange)
+	require.Equal(t, "python", deltas[2].Name, "dropped languages are listed too")
+	require.Equal(t, -100.0, *deltas[2].PercentChange)
+}
+
+func TestMovingAverages(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	daily := []core.DailySummary{
+		{Date: "2026-03-02", TotalTime: 1600, TotalLines: 50},
+		{Date: "2026-03-25", TotalTime: 700, TotalLines: 0},
+		{Date: "2026-03-31", TotalTime: 700, TotalLines: 70},
+	}
+
+	averages := movingAverages(daily, now, time.UTC)
+	require.Len(t, averages, trendDays)
+	require.Equal(t, "2026-03-02", averages[0].Date)
+
+	last := averages[len(averages)-1]
+	require.Equal(t, "2026-03-31", last.Date)
+	require.Equal(t, 200.0, last.Time7d)
+	require.Equal(t, 10.0, last.Lines7d)
+	require.Equal(t, 100.0, last.Time30d)
+	require.Equal(t, 4.0, last.Lines30d)
+}
+
+func TestCalculator_CalculateAPI_Trends(t *testing.T) {
+	time.Local = time.UTC
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Monday, the first day of the week, and the Tuesday before it.
+	monday := time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		insertActivity(t, storage, core.Activity{
+			ID: core.GenerateID(), Timestamp: monday.Add(time.Duration(i) * 5 * time.Minute),
+			Lines: 10, Language: "go", Project: "codeme", Editor: "vim", File: "/a.go", IsWrite: true,
+		})
+	}
+	insertActivity(t, storage, core.Activity{
+		ID: core.GenerateID(), Timestamp: monday.AddDate(0, 0, -6),
+		Lines: 10, Language: "rust", Project: "codeme", Editor: "vim", File: "/a.rs", IsWrite: true,
+	})
+
+	calc := NewCalculator(time.UTC)
+	s, err := calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 60, Now: monday.Add(8 * time.Hour)})
+	require.NoError(t, err)
+
+	require.Equal(t, 10, s.LastWeek.TotalLines, "last week must not include this Monday")
+	require.Equal(t, 0, s.Yesterday.TotalLines)
+
+	wow := s.Trends.WeekOverWeek
+	require.Equal(t, 40.0, wow.Lines.Current)
+	require.Equal(t, 10.0, wow.Lines.Previous)
+	require.Equal(t, 300.0, *wow.Lines.PercentChange)
+	require.Len(t, wow.Languages, 2)
+	require.Equal(t, "go", wow.Languages[0].Name)
+	require.Equal(t, "rust", wow.Languages[1].Name)
+
+	require.Nil(t, s.Trends.DayOverDay.Lines.PercentChange)
+
+	last := s.Trends.MovingAverages[len(s.Trends.MovingAverages)-1]
+	require.Equal(t, "2026-03-09", last.Date)
+	require.InDelta(t, 50.0/7, last.Lines7d, 0.001, "the Tuesday before is within 7 days")
+
+	s, err = calc.CalculateAPI(storage, APIOptions{LoadRecentDays: 1, Now: monday.Add(8 * time.Hour)})
+	require.NoError(t, err)
+	last = s.Trends.MovingAverages[len(s.Trends.MovingAverages)-1]
+	require.InDelta(t, 50.0/7, last.Lines7d, 0.001, "not limited to the loaded days")
+}
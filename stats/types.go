@@ -1,7 +1,11 @@
 // stats/types.go
 package stats
 
-import "time"
+import (
+	"time"
+
+	"github.com/tduyng/codeme/core"
+)
 
 type APIStats struct {
 	Today         APIPeriodStats       `json:"today"`
@@ -11,11 +15,15 @@ type APIStats struct {
 	ThisMonth     APIPeriodStats       `json:"this_month"`
 	LastMonth     APIPeriodStats       `json:"last_month"`
 	AllTime       APIPeriodStats       `json:"all_time"`
+	Periods       []APIPeriodStats     `json:"periods,omitempty"`
 	StreakInfo    StreakInfo           `json:"streak_info"`
 	Achievements  []Achievement        `json:"achievements"`
+	Goals         []GoalStatus         `json:"goals"`
+	Streaks       []RuleStreak         `json:"streaks"`
 	Records       Records              `json:"records"`
 	DailyActivity map[string]DailyStat `json:"daily_activity"`
 	WeeklyHeatmap []HeatmapDay         `json:"weekly_heatmap"`
+	Trends        Trends               `json:"trends"`
 	GeneratedAt   time.Time            `json:"generated_at"`
 	Meta          APIMeta              `json:"_meta"`
 }
@@ -30,6 +38,9 @@ type APIPeriodStats struct {
 	Languages          []APILanguageStats `json:"languages"`
 	Projects           []APIProjectStats  `json:"projects"`
 	Editors            []APIEditorStats   `json:"editors"`
+	Categories         []APICategoryStats `json:"categories"`
+	Machines           []APIMachineStats  `json:"machines"`
+	Commands           []APICommandStats  `json:"commands"`
 	Files              []APIFileStats     `json:"top_files"`
 	HourlyActivity     []HourlyActivity   `json:"hourly_activity"`
 	PeakHour           int                `json:"peak_hour"`
@@ -60,6 +71,16 @@ type APIProjectStats struct {
 	Files        int     `json:"files"`
 	PercentTotal float64 `json:"percent_total"`
 	MainLanguage string  `json:"main_lang"`
+	// Languages is the project's time per language, most first.
+	Languages []APIProjectLanguageStats `json:"languages"`
+}
+
+type APIProjectLanguageStats struct {
+	Name  string  `json:"name"`
+	Time  float64 `json:"time"`
+	Lines int     `json:"lines"`
+	// PercentTotal is the share of the project's time.
+	PercentTotal float64 `json:"percent_total"`
 }
 
 type APIEditorStats struct {
@@ -68,6 +89,30 @@ type APIEditorStats struct {
 	PercentTotal float64 `json:"percent_total"`
 }
 
+type APICategoryStats struct {
+	Name         string  `json:"name"`
+	Time         float64 `json:"time"`
+	Lines        int     `json:"lines"`
+	PercentTotal float64 `json:"percent_total"`
+}
+
+type APICommandStats struct {
+	Project     string  `json:"project"`
+	Category    string  `json:"category"`
+	Command     string  `json:"command"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	Time        float64 `json:"time"`
+}
+
+type APIMachineStats struct {
+	Name         string  `json:"name"`
+	Time         float64 `json:"time"`
+	Lines        int     `json:"lines"`
+	PercentTotal float64 `json:"percent_total"`
+}
+
 type APIFileStats struct {
 	Name         string    `json:"name"`
 	Time         float64   `json:"time"`
@@ -107,6 +152,24 @@ type Achievement struct {
 	Description string `json:"description"`
 	Icon        string `json:"icon"`
 	Unlocked    bool   `json:"unlocked"`
+	// Tier is the step of a tiered achievement, from 1.
+	Tier   int  `json:"tier,omitempty"`
+	Hidden bool `json:"hidden,omitempty"`
+	// UnlockedAt is when the unlock was first recorded.
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+	// IsNew marks unlocks of the last 24 hours.
+	IsNew bool `json:"is_new"`
+	// Context says what earned it, e.g. "30-day streak".
+	Context string `json:"context,omitempty"`
+	// Current is the rule's value and Target the value that unlocks it, in
+	// seconds when Unit is "seconds".
+	Current  float64 `json:"current"`
+	Target   float64 `json:"target"`
+	Unit     string  `json:"unit,omitempty"`
+	Progress float64 `json:"progress"`
+	// EstimatedAt is when a locked achievement should unlock at the pace
+	// of the last two weeks.
+	EstimatedAt *time.Time `json:"estimated_at,omitempty"`
 }
 
 type Records struct {
@@ -187,5 +250,12 @@ type HourlyActivity struct {
 }
 
 type APIOptions struct {
-	LoadRecentDays int
+	LoadRecentDays int                 `json:"load_recent_days,omitempty"`
+	HeatmapWeeks   int                 `json:"heatmap_weeks,omitempty"`
+	Filter         core.ActivityFilter `json:"filter,omitzero"`
+	// Now computes stats as of a past moment, e.g. for reports on an earlier
+	// week. Activities after it are ignored. Zero means the current time.
+	Now time.Time `json:"now,omitzero"`
+	// Periods adds these periods to APIStats.Periods, in order.
+	Periods []PeriodRequest `json:"periods,omitempty"`
 }